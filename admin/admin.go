@@ -0,0 +1,172 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rking788/guardian-helper/bungie"
+	"github.com/rking788/guardian-helper/db"
+)
+
+const (
+	// DefaultUnknownValueLimit is the number of unknown values returned when no limit is specified.
+	DefaultUnknownValueLimit = 25
+)
+
+// TranslationRequest is the body of a request to map a value Alexa did not understand
+// to an actual item or class name.
+type TranslationRequest struct {
+	Kind        string `json:"kind"`
+	AlexaValue  string `json:"alexa_value"`
+	Translation string `json:"translation"`
+}
+
+// Authenticated will wrap the provided handler so that it is only called when the request includes
+// the admin token from the ADMIN_TOKEN environment variable as a bearer token. If no admin token
+// is configured, all admin requests will be rejected.
+func Authenticated(handler http.HandlerFunc) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		adminToken := os.Getenv("ADMIN_TOKEN")
+		if adminToken == "" {
+			http.Error(w, "Admin endpoints are disabled", http.StatusForbidden)
+			return
+		}
+
+		header := r.Header.Get("Authorization")
+		provided := strings.TrimPrefix(header, "Bearer ")
+		if !strings.HasPrefix(header, "Bearer ") || subtle.ConstantTimeCompare([]byte(provided), []byte(adminToken)) != 1 {
+			http.Error(w, "Not Authorized", http.StatusUnauthorized)
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+// UnknownValues will respond with the most frequent values that Alexa provided but could not be
+// matched to an item or class. The kind query parameter selects either item or class values and the
+// optional limit parameter controls how many values are returned.
+func UnknownValues(w http.ResponseWriter, r *http.Request) {
+
+	tableName, ok := unknownTableForKind(r.URL.Query().Get("kind"))
+	if !ok {
+		http.Error(w, "kind must be either item or class", http.StatusBadRequest)
+		return
+	}
+
+	limit := DefaultUnknownValueLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	values, err := db.FindUnknownValues(tableName, limit)
+	if err != nil {
+		fmt.Println("Failed to load unknown values: ", err.Error())
+		http.Error(w, "Failed to load unknown values", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, values)
+}
+
+// AddTranslation will save a translation from a value provided by Alexa to a real item or class name.
+// The unknown value entries are removed and the translations are reloaded so the change takes effect
+// immediately.
+func AddTranslation(w http.ResponseWriter, r *http.Request) {
+
+	var request TranslationRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	request.AlexaValue = strings.ToLower(strings.TrimSpace(request.AlexaValue))
+	request.Translation = strings.ToLower(strings.TrimSpace(request.Translation))
+	if request.AlexaValue == "" || request.Translation == "" {
+		http.Error(w, "alexa_value and translation are required", http.StatusBadRequest)
+		return
+	}
+
+	tableName, ok := unknownTableForKind(request.Kind)
+	if !ok {
+		http.Error(w, "kind must be either item or class", http.StatusBadRequest)
+		return
+	}
+
+	valid, err := isValidTranslation(request.Kind, request.Translation)
+	if err != nil {
+		fmt.Println("Failed to validate translation: ", err.Error())
+		http.Error(w, "Failed to validate translation", http.StatusInternalServerError)
+		return
+	} else if !valid {
+		http.Error(w, fmt.Sprintf("%s is not a known %s name", request.Translation, request.Kind), http.StatusBadRequest)
+		return
+	}
+
+	err = db.SaveTranslation(request.Kind, request.AlexaValue, request.Translation)
+	if err != nil {
+		fmt.Println("Failed to save translation: ", err.Error())
+		http.Error(w, "Failed to save translation", http.StatusInternalServerError)
+		return
+	}
+
+	err = db.DeleteUnknownValue(request.AlexaValue, tableName)
+	if err != nil {
+		fmt.Println("Failed to remove unknown value after adding translation: ", err.Error())
+	}
+
+	bungie.PopulateTranslations()
+
+	writeJSON(w, request)
+}
+
+func unknownTableForKind(kind string) (string, bool) {
+	switch kind {
+	case db.ItemTranslation:
+		return db.UnknownItemTable, true
+	case db.ClassTranslation:
+		return db.UnknownClassTable, true
+	}
+
+	return "", false
+}
+
+// isValidTranslation checks that the translation refers to a real item, item family, or class name.
+func isValidTranslation(kind, translation string) (bool, error) {
+
+	if kind == db.ClassTranslation {
+		return bungie.IsClassName(translation), nil
+	}
+
+	exists, err := db.ItemNameExists(translation)
+	if err != nil || exists {
+		return exists, err
+	}
+
+	family, err := db.GetItemFamily(translation)
+	if err != nil {
+		return false, err
+	}
+
+	return len(family) > 0, nil
+}
+
+func writeJSON(w http.ResponseWriter, value interface{}) {
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	err := json.NewEncoder(w).Encode(value)
+	if err != nil {
+		fmt.Println("Failed to write JSON response: ", err.Error())
+	}
+}
@@ -0,0 +1,54 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestAuthenticated(t *testing.T) {
+
+	handler := Authenticated(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	cases := []struct {
+		adminToken    string
+		authorization string
+		status        int
+	}{
+		{"", "Bearer anything", http.StatusForbidden},
+		{"secret", "", http.StatusUnauthorized},
+		{"secret", "Bearer wrong", http.StatusUnauthorized},
+		{"secret", "secret", http.StatusUnauthorized},
+		{"secret", "Bearer secret", http.StatusNoContent},
+	}
+
+	defer os.Unsetenv("ADMIN_TOKEN")
+	for _, c := range cases {
+		os.Setenv("ADMIN_TOKEN", c.adminToken)
+
+		req := httptest.NewRequest("GET", "/admin/unknown-values?kind=item", nil)
+		if c.authorization != "" {
+			req.Header.Set("Authorization", c.authorization)
+		}
+		recorder := httptest.NewRecorder()
+		handler(recorder, req)
+
+		if recorder.Code != c.status {
+			t.Errorf("Expected status %d with token(%s) and header(%s), got %d",
+				c.status, c.adminToken, c.authorization, recorder.Code)
+		}
+	}
+}
+
+func TestUnknownValuesRequiresKind(t *testing.T) {
+
+	recorder := httptest.NewRecorder()
+	UnknownValues(recorder, httptest.NewRequest("GET", "/admin/unknown-values?kind=weapon", nil))
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected a bad request for an invalid kind, got %d", recorder.Code)
+	}
+}
@@ -1,132 +1,60 @@
 package alexa
 
-// TODO: This file really needs a refactor. Endpoints that require a linked account
-// should use some kind of middleware instead of having the check in individually handlers.
-
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 	"strconv"
 	"time"
 
 	"github.com/rking788/guardian-helper/bungie"
+	"github.com/rking788/guardian-helper/db"
+	"github.com/rking788/guardian-helper/i18n"
 	"github.com/rking788/guardian-helper/trials"
 
 	"strings"
 
-	"github.com/garyburd/redigo/redis"
 	"github.com/mikeflynn/go-alexa/skillserver"
 )
 
-// Session is responsible for storing information related to a specific skill invocation.
-// A session will remain open if the LaunchRequest was received.
-type Session struct {
-	ID                   string
-	Action               string
-	ItemName             string
-	DestinationClassHash int
-	SourceClassHash      int
-	Quantity             int
-}
-
-var (
-	redisConnPool = newRedisPool(os.Getenv("REDIS_URL"))
+// Actions that can be in progress in a session while waiting for more information from the user
+const (
+	CountItemAction     = "CountItem"
+	TransferItemAction  = "TransferItem"
+	MaxLightAction      = "EquipMaxLight"
+	UnloadEngramsAction = "UnloadEngrams"
 )
 
-// Redis related functions
-
-func newRedisPool(addr string) *redis.Pool {
-	// 25 is the maximum number of active connections for the Heroku Redis free tier
-	return &redis.Pool{
-		MaxIdle:     3,
-		MaxActive:   25,
-		IdleTimeout: 240 * time.Second,
-		Dial:        func() (redis.Conn, error) { return redis.DialURL(addr) },
-	}
-}
-
-// GetSession will attempt to read a session from the cache, if an existing one is not found, an empty session
-// will be created with the specified sessionID.
-func GetSession(sessionID string) (session *Session) {
-	session = &Session{ID: sessionID}
-
-	conn := redisConnPool.Get()
-	defer conn.Close()
-
-	key := fmt.Sprintf("sessions:%s", sessionID)
-	reply, err := redis.String(conn.Do("GET", key))
-	if err != nil {
-		// NOTE: This is a normal situation, if the session is not stored in the cache, it will hit this condition.
-		return
-	}
-
-	err = json.Unmarshal([]byte(reply), session)
-
-	return
-}
-
-// SaveSession will persist the given session to the cache. This will allow support for long running
-// Alexa sessions that continually prompt the user for more information.
-func SaveSession(session *Session) {
-
-	conn := redisConnPool.Get()
-	defer conn.Close()
-
-	sessionBytes, err := json.Marshal(session)
-	if err != nil {
-		fmt.Println("Couldn't marshal session to string: ", err.Error())
-		return
-	}
-
-	key := fmt.Sprintf("sessions:%s", session.ID)
-	_, err = conn.Do("SET", key, string(sessionBytes))
-	if err != nil {
-		fmt.Println("Failed to set session: ", err.Error())
-	}
-}
-
-// ClearSession will remove the specified session from the local cache, this will be done
-// when the user completes a full request session.
-func ClearSession(sessionID string) {
-
-	conn := redisConnPool.Get()
-	defer conn.Close()
-
-	key := fmt.Sprintf("sessions:%s", sessionID)
-	_, err := conn.Do("DEL", key)
-	if err != nil {
-		fmt.Println("Failed to delete the session from the Redis cache: ", err.Error())
-	}
+// vaultClassHash is stored in the session in place of a class hash when the vault is requested.
+const vaultClassHash = -1
+
+// resetDialog will clear any values collected for a previous action and start the specified action.
+// Use an empty action when the current action is complete.
+func (session *Session) resetDialog(action string) {
+	session.Action = action
+	session.ItemName = ""
+	session.ItemHash = 0
+	session.DestinationClassHash = 0
+	session.SourceClassHash = 0
+	session.Quantity = 0
+	session.Tier = bungie.UnknownTier
+	session.KeepTier = bungie.UnknownTier
 }
 
 // Handler is the type of function that should be used to respond to a specific intent.
-type Handler func(*skillserver.EchoRequest) *skillserver.EchoResponse
-
-func AuthWrapper(handler Handler) Handler {
-
-	return func(req *skillserver.EchoRequest) *skillserver.EchoResponse {
-		accessToken := req.Session.User.AccessToken
-		if accessToken == "" {
-			response := skillserver.NewEchoResponse()
-			response.
-				OutputSpeech("Sorry Guardian, it looks like your Bungie.net account needs to be linked in the Alexa app.").
-				LinkAccountCard()
-			return response
-		}
-
-		return handler(req)
-	}
-}
+type Handler func(*Request) *skillserver.EchoResponse
 
 // WelcomePrompt is responsible for prompting the user with information about what they can ask
 // the skill to do.
-func WelcomePrompt(echoRequest *skillserver.EchoRequest) (response *skillserver.EchoResponse) {
+func WelcomePrompt(echoRequest *Request) (response *skillserver.EchoResponse) {
 	response = skillserver.NewEchoResponse()
+	l := echoRequest.Localizer()
+
+	welcome := l.Sprintf("welcome")
+	if echoRequest.Preferences().Brief() {
+		welcome = l.Sprintf("welcome.brief")
+	}
 
-	response.OutputSpeech("Welcome Guardian, would you like to equip max light, unload engrams, or transfer an item to a specific character, " +
-		"find out how many of an item you have, or ask about Trials of Osiris?").
-		Reprompt("Do you want to equip max light, unload engrams, transfer an item, find out how much of an item you have, or ask about Trials of Osiris?").
+	response.OutputSpeech(welcome).
+		Reprompt(l.Sprintf("welcome.reprompt")).
 		EndSession(false)
 
 	return
@@ -134,108 +62,416 @@ func WelcomePrompt(echoRequest *skillserver.EchoRequest) (response *skillserver.
 
 // HelpPrompt provides the required information to satisfy the HelpIntent built-in Alexa intent. This should
 // provider information to the user to let them know what the skill can do without providing exact commands.
-func HelpPrompt(echoRequest *skillserver.EchoRequest) (response *skillserver.EchoResponse) {
+func HelpPrompt(echoRequest *Request) (response *skillserver.EchoResponse) {
 	response = skillserver.NewEchoResponse()
 
-	response.OutputSpeech("Welcome Guardian, I am here to help manage your Destiny in-game inventory. You can ask " +
-		"me to equip your max light loadout, unload engrams from your inventory, or transfer items between your available " +
-		"characters including the vault. You can also ask how many of an " +
-		"item you have. Trials of Osiris statistics provided by Trials Report are available too.").
+	response.OutputSpeech(echoRequest.Localizer().Sprintf("help")).
 		EndSession(false)
 
 	return
 }
 
+// EndSession handles the AMAZON.StopIntent and AMAZON.CancelIntent built-in intents by closing the
+// session without saying anything.
+func EndSession(request *Request) *skillserver.EchoResponse {
+	return skillserver.NewEchoResponse()
+}
+
 // CountItem calls the Bungie API to see count the number of Items on all characters and
-// in the vault.
-func CountItem(echoRequest *skillserver.EchoRequest) (response *skillserver.EchoResponse) {
+// in the vault. If the item was not provided, the user will be asked which item should be counted.
+func CountItem(echoRequest *Request) (response *skillserver.EchoResponse) {
+
+	l := echoRequest.Localizer()
+	session := GetSession(echoRequest.GetSessionID())
+	if session.Action != CountItemAction {
+		session.resetDialog(CountItemAction)
+	}
+
+	if item, _ := echoRequest.GetSlotValue("Item"); item != "" {
+		session.ItemName, session.ItemHash = itemSlotValue(echoRequest, item)
+	}
+
+	if session.ItemName == "" {
+		SaveSession(session)
+		return askQuestion(l.Sprintf("count.ask_item"))
+	}
+
+	itemName, itemHash := session.ItemName, session.ItemHash
+	session.resetDialog("")
+	SaveSession(session)
 
 	accessToken := echoRequest.Session.User.AccessToken
-	item, _ := echoRequest.GetSlotValue("Item")
-	lowerItem := strings.ToLower(item)
-	response, err := bungie.CountItem(lowerItem, accessToken)
-	if err != nil {
-		fmt.Println("Error counting the number of items: ", err.Error())
+	count, err := bungie.CountItem(itemName, itemHash, accessToken, echoRequest.Locale, echoRequest.Preferences())
+	if err == bungie.ErrUnknownItem {
 		response = skillserver.NewEchoResponse()
-		response.OutputSpeech("Sorry Guardian, an error occurred counting that item.")
+		response.OutputSpeech(l.Sprintf("count.not_found", itemName))
+		return
+	} else if err != nil {
+		fmt.Println("Error counting the number of items: ", err.Error())
+		return bungieErrorResponse(l, err, "count.error")
 	}
 
-	return
+	echoRequest.SetDisplay(itemCountDisplay(l, count))
+	return renderItemCount(l, count, echoRequest.Preferences().Brief())
 }
 
 // TransferItem will attempt to transfer either a specific quantity or all of a
 // specific item to a specified character. The item name and destination are the
-// required fields. The quantity and source are optional.
-func TransferItem(request *skillserver.EchoRequest) (response *skillserver.EchoResponse) {
+// required fields. The quantity and source are optional. The user's default character is used
+// when the destination is missing. If the item or destination are still missing, the user will
+// be asked for them and the values provided so far are kept in the session until the transfer
+// can be completed.
+func TransferItem(request *Request) (response *skillserver.EchoResponse) {
+
+	l := request.Localizer()
+	session := GetSession(request.GetSessionID())
+	if session.Action != TransferItemAction {
+		session.resetDialog(TransferItemAction)
+	}
 
-	accessToken := request.Session.User.AccessToken
 	countStr, _ := request.GetSlotValue("Count")
-	count := -1
 	if countStr != "" {
 		tempCount, ok := strconv.Atoi(countStr)
 		if ok != nil {
 			response = skillserver.NewEchoResponse()
-			response.OutputSpeech("Sorry Guardian, I didn't understand the number you asked to be transferred. Do not specify a quantity if you want all to be transferred.")
+			response.OutputSpeech(l.Sprintf("transfer.invalid_count"))
 			return
 		}
 
 		if tempCount <= 0 {
-			output := fmt.Sprintf("Sorry Guardian, you need to specify a positive, non-zero number to be transferred, not %d", tempCount)
+			output := l.Sprintf("transfer.non_positive_count", tempCount)
 			fmt.Println(output)
+			response = skillserver.NewEchoResponse()
 			response.OutputSpeech(output)
 			return
 		}
 
-		count = tempCount
+		session.Quantity = tempCount
 	}
 
-	item, _ := request.GetSlotValue("Item")
-	sourceClass, _ := request.GetSlotValue("Source")
-	destinationClass, _ := request.GetSlotValue("Destination")
-	if destinationClass == "" {
-		response = skillserver.NewEchoResponse()
-		response.OutputSpeech("Sorry Guardian, you must specify a destination for the items to be transferred.")
-		return
+	if item, _ := request.GetSlotValue("Item"); item != "" {
+		session.ItemName, session.ItemHash = itemSlotValue(request, item)
+	}
+
+	if sourceClass, _ := request.GetSlotValue("Source"); sourceClass != "" {
+		hash, ok := classHashFromName(l, sourceClass)
+		if !ok {
+			SaveSession(session)
+			return unknownCharacterResponse(l, sourceClass, l.Sprintf("transfer.ask_source"))
+		}
+		session.SourceClassHash = hash
+	}
+
+	if destinationClass, _ := request.GetSlotValue("Destination"); destinationClass != "" {
+		hash, ok := classHashFromName(l, destinationClass)
+		if !ok {
+			SaveSession(session)
+			return unknownCharacterResponse(l, destinationClass, l.Sprintf("transfer.ask_destination", itemNameOrDefault(l, session.ItemName)))
+		}
+		session.DestinationClassHash = hash
+	}
+
+	// Use the default character when a destination was not provided
+	if session.DestinationClassHash == 0 {
+		if hash, ok := bungie.ClassHashFromName(request.Preferences().DefaultCharacter); ok {
+			session.DestinationClassHash = int(hash)
+		}
+	}
+
+	if session.ItemName == "" {
+		SaveSession(session)
+		return askQuestion(l.Sprintf("transfer.ask_item"))
+	} else if session.DestinationClassHash == 0 {
+		SaveSession(session)
+		return askQuestion(l.Sprintf("transfer.ask_destination", session.ItemName))
+	}
+
+	item, itemHash := session.ItemName, session.ItemHash
+	sourceClass := classNameFromHash(session.SourceClassHash)
+	destinationClass := classNameFromHash(session.DestinationClassHash)
+	count := -1
+	if session.Quantity > 0 {
+		count = session.Quantity
 	}
 
-	output := fmt.Sprintf("Transferring %d of your %s from your %s to your %s", count, strings.ToLower(item), strings.ToLower(sourceClass), strings.ToLower(destinationClass))
+	session.resetDialog("")
+	SaveSession(session)
+
+	output := fmt.Sprintf("Transferring %d of your %s from your %s to your %s", count, item, sourceClass, destinationClass)
 	fmt.Println(output)
-	response, err := bungie.TransferItem(strings.ToLower(item), accessToken, strings.ToLower(sourceClass), strings.ToLower(destinationClass), count)
-	if err != nil {
-		response = skillserver.NewEchoResponse()
-		response.OutputSpeech("Sorry Guardian, an error occurred trying to transfer that item.")
-		return
+
+	accessToken := request.Session.User.AccessToken
+	return runLongOperation(request, l.Sprintf("transfer.description", item), l.Sprintf("transfer.progress", item),
+		func() *skillserver.EchoResponse {
+			result, err := bungie.TransferItem(item, itemHash, accessToken, sourceClass, destinationClass, count, request.Locale, request.Preferences())
+			switch {
+			case err == bungie.ErrUnknownItem:
+				response := skillserver.NewEchoResponse()
+				response.OutputSpeech(l.Sprintf("count.not_found", item))
+				return response
+			case err == bungie.ErrCharacterNotFound:
+				response := skillserver.NewEchoResponse()
+				response.OutputSpeech(l.Sprintf("transfer.no_character", item, localizedClassName(l, destinationClass)))
+				return response
+			case err != nil:
+				fmt.Println("Error transferring items: ", err.Error())
+				return bungieErrorResponse(l, err, "transfer.error")
+			}
+			request.SetDisplay(transferDisplay(l, result))
+			return renderTransfer(l, result)
+		})
+}
+
+// ContinueDialog handles the answers to the questions asked by the CountItem and TransferItem
+// handlers when required values were missing. The pending action in the session determines which
+// handler should receive the answer.
+func ContinueDialog(request *Request) (response *skillserver.EchoResponse) {
+
+	session := GetSession(request.GetSessionID())
+	switch session.Action {
+	case CountItemAction:
+		return CountItem(request)
+	case TransferItemAction:
+		return TransferItem(request)
 	}
 
+	response = skillserver.NewEchoResponse()
+	response.OutputSpeech(request.Localizer().Sprintf("dialog.unknown")).
+		EndSession(false)
+
 	return
 }
 
-func MaxLight(request *skillserver.EchoRequest) (response *skillserver.EchoResponse) {
+// MaxLight will equip the max light loadout on the current character. If the user requires
+// confirmation, the changes will be described and the user will be asked before anything is moved.
+func MaxLight(request *Request) (response *skillserver.EchoResponse) {
 
 	accessToken := request.Session.User.AccessToken
-	response, err := bungie.EquipMaxLightGear(accessToken)
-	if err != nil {
-		fmt.Println("Error occurred equipping max light: ", err.Error())
-		response = skillserver.NewEchoResponse()
-		response.OutputSpeech("Sorry Guardian, an error occurred equipping your max light gear.")
+	l := request.Localizer()
+	if requiresConfirmation(request) {
+		plan, err := bungie.PlanMaxLight(accessToken, request.Locale, request.Preferences())
+		if err != nil {
+			fmt.Println("Error occurred planning max light: ", err.Error())
+			response = bungieErrorResponse(l, err, "maxlight.error")
+			return
+		}
+
+		// Nothing needs to be moved so there is nothing disruptive to confirm
+		if plan.TransferCount > 0 {
+			session := GetSession(request.GetSessionID())
+			session.resetDialog(MaxLightAction)
+			SaveSession(session)
+
+			return askQuestion(l.Sprintf("confirm.continue", describeMaxLightPlan(l, plan)))
+		}
 	}
 
-	return
+	return equipMaxLight(request)
+}
+
+func equipMaxLight(request *Request) *skillserver.EchoResponse {
+
+	accessToken := request.Session.User.AccessToken
+	l := request.Localizer()
+	return runLongOperation(request, l.Sprintf("maxlight.description"), l.Sprintf("maxlight.progress"),
+		func() *skillserver.EchoResponse {
+			loadout, err := bungie.EquipMaxLightGear(accessToken, request.Locale, request.Preferences())
+			if err != nil {
+				fmt.Println("Error occurred equipping max light: ", err.Error())
+				return bungieErrorResponse(l, err, "maxlight.error")
+			}
+			request.SetDisplay(loadoutDisplay(l, loadout))
+			return renderLoadout(l, loadout)
+		})
 }
 
 // UnloadEngrams will take all engrams on all of the current user's characters and transfer them all to the
-// vault to allow the player to continue farming.
-func UnloadEngrams(request *skillserver.EchoRequest) (response *skillserver.EchoResponse) {
+// vault to allow the player to continue farming. The optional Tier slot will only move engrams of that tier
+// and the optional KeepTier slot will leave engrams of that tier on the characters. If the user requires
+// confirmation, they will be told how many engrams will be moved and asked before anything is moved.
+func UnloadEngrams(request *Request) (response *skillserver.EchoResponse) {
 
 	accessToken := request.Session.User.AccessToken
-	response, err := bungie.UnloadEngrams(accessToken)
-	if err != nil {
-		fmt.Println("Error occurred unloading engrams: ", err.Error())
+	l := request.Localizer()
+
+	onlyTier, ok := engramTierSlotValue(request, "Tier")
+	if !ok {
+		response = skillserver.NewEchoResponse()
+		response.OutputSpeech(l.Sprintf("unload.unknown_tier"))
+		return
+	}
+	keepTier, ok := engramTierSlotValue(request, "KeepTier")
+	if !ok {
 		response = skillserver.NewEchoResponse()
-		response.OutputSpeech("Sorry Guardian, an error occurred moving your engrams.")
+		response.OutputSpeech(l.Sprintf("unload.unknown_keep_tier"))
+		return
 	}
 
-	return
+	if requiresConfirmation(request) {
+		count, err := bungie.PlanUnloadEngrams(accessToken, onlyTier, keepTier, request.Preferences())
+		if err != nil {
+			fmt.Println("Error occurred planning engram unload: ", err.Error())
+			response = bungieErrorResponse(l, err, "unload.error")
+			return
+		}
+
+		if count > 0 {
+			session := GetSession(request.GetSessionID())
+			session.resetDialog(UnloadEngramsAction)
+			session.Tier = onlyTier
+			session.KeepTier = keepTier
+			SaveSession(session)
+
+			return askQuestion(l.Plural("unload.confirm", count, count))
+		}
+	}
+
+	return unloadEngrams(request, onlyTier, keepTier)
+}
+
+func unloadEngrams(request *Request, onlyTier, keepTier uint) *skillserver.EchoResponse {
+
+	accessToken := request.Session.User.AccessToken
+	l := request.Localizer()
+	return runLongOperation(request, l.Sprintf("unload.description"), l.Sprintf("unload.progress"),
+		func() *skillserver.EchoResponse {
+			result, err := bungie.UnloadEngrams(accessToken, onlyTier, keepTier, request.Preferences())
+			if err != nil {
+				fmt.Println("Error occurred unloading engrams: ", err.Error())
+				return bungieErrorResponse(l, err, "unload.error")
+			}
+			return renderUnload(l, result)
+		})
+}
+
+// CharacterSummary will describe the class, race, light level, and last played time
+// for all of the current user's characters.
+func CharacterSummary(request *Request) (response *skillserver.EchoResponse) {
+
+	accessToken := request.Session.User.AccessToken
+	characters, err := bungie.CharacterSummary(accessToken, request.Preferences())
+	if err != nil {
+		fmt.Println("Error occurred loading character summary: ", err.Error())
+		return bungieErrorResponse(request.Localizer(), err, "characters.error")
+	}
+
+	return renderCharacters(request.Localizer(), characters, request.Preferences().Brief(), time.Now())
+}
+
+// engramTierSlotValue will read an engram tier from the specified slot. If the slot is empty, UnknownTier
+// is returned so that no filtering is done. false will be returned if the tier is not recognized.
+// Tier names in the request's locale are matched by prefix so inflected forms like "legendäre" work.
+func engramTierSlotValue(request *Request, slotName string) (uint, bool) {
+
+	tierName, _ := request.GetSlotValue(slotName)
+	tierName = strings.TrimSuffix(strings.TrimSuffix(strings.ToLower(tierName), "s"), " engram")
+	if tierName == "" {
+		return bungie.UnknownTier, true
+	}
+
+	if tier, ok := bungie.TierTypeFromName(tierName); ok {
+		return tier, true
+	}
+
+	l := request.Localizer()
+	for _, name := range []string{"uncommon", "rare", "legendary", "exotic"} {
+		if strings.HasPrefix(tierName, strings.ToLower(l.Name("tier", name))) {
+			return bungie.TierTypeFromName(name)
+		}
+	}
+
+	return bungie.UnknownTier, false
+}
+
+// bungieErrorResponse will build the response for an error returned by Bungie.net. The user is only
+// asked to link their account again when Bungie.net rejected the access token, outages and throttling
+// are reported as such, and any other error is answered with the handler's own message.
+func bungieErrorResponse(l *i18n.Localizer, err error, messageID string) *skillserver.EchoResponse {
+
+	response := skillserver.NewEchoResponse()
+	switch err {
+	case bungie.ErrAuthorization:
+		response.
+			OutputSpeech(l.Sprintf("error.link_account")).
+			LinkAccountCard()
+	case bungie.ErrNoDestinyAccount:
+		response.OutputSpeech(l.Sprintf("error.no_destiny_account"))
+	case bungie.ErrThrottled, bungie.ErrUnavailable:
+		response.OutputSpeech(l.Sprintf("error.bungie_unavailable"))
+	default:
+		response.OutputSpeech(l.Sprintf(messageID))
+	}
+
+	return response
+}
+
+// askQuestion will create a response that asks the user for more information and keeps the session open
+// for the answer.
+func askQuestion(question string) *skillserver.EchoResponse {
+	response := skillserver.NewEchoResponse()
+	response.OutputSpeech(question).
+		Reprompt(question).
+		EndSession(false)
+
+	return response
+}
+
+// unknownCharacterResponse will ask the user for a character again because the provided name was not a
+// Destiny class or the vault.
+func unknownCharacterResponse(l *i18n.Localizer, className, question string) *skillserver.EchoResponse {
+	db.InsertUnknownValueIntoTable(strings.ToLower(className), db.UnknownClassTable)
+	return askQuestion(l.Sprintf("character.unknown", className, question))
+}
+
+// classHashFromName will find the class hash to store in the session for the provided class name.
+// Class names in the Localizer's locale are accepted as well as the English names.
+func classHashFromName(l *i18n.Localizer, className string) (int, bool) {
+	className = bungie.TranslateClassName(strings.ToLower(className))
+	if name, ok := l.FindName("class", className, []string{"titan", "hunter", "warlock", "vault"}); ok {
+		className = name
+	}
+	if className == "vault" {
+		return vaultClassHash, true
+	}
+
+	hash, ok := bungie.ClassHashFromName(className)
+	return int(hash), ok
+}
+
+// classNameFromHash is the inverse of classHashFromName, an empty string is returned if no class was selected.
+func classNameFromHash(hash int) string {
+	if hash == vaultClassHash {
+		return "vault"
+	} else if hash == 0 {
+		return ""
+	}
+
+	return bungie.ClassNameFromHash(uint(hash))
+}
+
+// itemSlotValue will use the item matched by Alexa entity resolution if there is one, otherwise the
+// spoken value is used. The hash is zero if the value was not resolved to a single item, for
+// example when an item family was requested.
+func itemSlotValue(request *Request, spoken string) (string, uint) {
+
+	resolved, ok := request.ResolvedSlotValue("Item")
+	if !ok {
+		return strings.ToLower(spoken), 0
+	}
+
+	hash, err := strconv.ParseUint(resolved.ID, 10, 32)
+	if err != nil {
+		return strings.ToLower(resolved.Name), 0
+	}
+
+	return strings.ToLower(resolved.Name), uint(hash)
+}
+
+func itemNameOrDefault(l *i18n.Localizer, itemName string) string {
+	if itemName == "" {
+		return l.Sprintf("items.default")
+	}
+	return itemName
 }
 
 /*
@@ -243,69 +479,69 @@ func UnloadEngrams(request *skillserver.EchoRequest) (response *skillserver.Echo
  */
 
 // CurrentTrialsMap will return a brief description of the current map in the active Trials of Osiris week.
-func CurrentTrialsMap(request *skillserver.EchoRequest) (response *skillserver.EchoResponse) {
+func CurrentTrialsMap(request *Request) *skillserver.EchoResponse {
 
-	response, err := trials.GetCurrentMap()
+	currentMap, err := trials.GetCurrentMap()
 	if err != nil {
-		response = skillserver.NewEchoResponse()
-		response.OutputSpeech("Sorry Guardian, I cannot access this information right now, please try again later.")
-		return
+		return trialsUnavailable(request)
 	}
 
-	return
+	return renderTrialsMap(request.Localizer(), currentMap)
 }
 
 // CurrentTrialsWeek will return a brief description of the current map in the active Trials of Osiris week.
-func CurrentTrialsWeek(request *skillserver.EchoRequest) (response *skillserver.EchoResponse) {
+// This requires the ResolveMembership middleware.
+func CurrentTrialsWeek(request *Request) *skillserver.EchoResponse {
 
-	accessToken := request.Session.User.AccessToken
-	response, err := trials.GetCurrentWeek(accessToken)
+	week, err := trials.GetCurrentWeek(request.Membership().MembershipID)
 	if err != nil {
-		response = skillserver.NewEchoResponse()
-		response.OutputSpeech("Sorry Guardian, I cannot access this information right now, please try again later.")
-		return
+		return trialsUnavailable(request)
 	}
 
-	return
+	return renderTrialsWeek(request.Localizer(), week)
 }
 
 // PopularWeapons will check Trials Report for the most popular specific weapons for the current week.
-func PopularWeapons(request *skillserver.EchoRequest) (response *skillserver.EchoResponse) {
+func PopularWeapons(request *Request) *skillserver.EchoResponse {
 
-	response, err := trials.GetWeaponUsagePercentages()
+	weapons, err := trials.GetWeaponUsagePercentages()
 	if err != nil {
-		response = skillserver.NewEchoResponse()
-		response.OutputSpeech("Sorry Guardian, I cannot access this information at this time, please try again later")
-		return
+		return trialsUnavailable(request)
 	}
 
-	return
+	request.SetDisplay(popularWeaponsDisplay(request.Localizer(), weapons))
+	return renderPopularWeapons(request.Localizer(), weapons)
 }
 
 // PersonalTopWeapons will check Trials Report for the most used weapons for the current user.
-func PersonalTopWeapons(request *skillserver.EchoRequest) (response *skillserver.EchoResponse) {
+// This requires the ResolveMembership middleware.
+func PersonalTopWeapons(request *Request) *skillserver.EchoResponse {
 
-	accessToken := request.Session.User.AccessToken
-	response, err := trials.GetPersonalTopWeapons(accessToken)
+	weapons, err := trials.GetPersonalTopWeapons(request.Membership().MembershipID, request.Locale)
 	if err != nil {
-		response = skillserver.NewEchoResponse()
-		response.OutputSpeech("Sorry Guardian, I cannot access this information at this time, please try again later")
-		return
+		return trialsUnavailable(request)
 	}
 
-	return
+	request.SetDisplay(topWeaponsDisplay(request.Localizer(), weapons))
+	return renderTopWeapons(request.Localizer(), weapons)
 }
 
 // PopularWeaponTypes will return info about what classes of weapons are getting
 // the most kills in Trials of Osiris.
-func PopularWeaponTypes(echoRequest *skillserver.EchoRequest) (response *skillserver.EchoResponse) {
+func PopularWeaponTypes(echoRequest *Request) *skillserver.EchoResponse {
 
-	response, err := trials.GetPopularWeaponTypes()
+	stats, err := trials.GetPopularWeaponTypes()
 	if err != nil {
-		response = skillserver.NewEchoResponse()
-		response.OutputSpeech("Sorry Guardian, I cannot access this information at this time, pleast try again later")
-		return
+		return trialsUnavailable(echoRequest)
 	}
 
-	return
+	return renderWeaponTypes(echoRequest.Localizer(), stats)
+}
+
+// trialsUnavailable is the response when Trials Report could not be reached or returned an
+// unexpected response.
+func trialsUnavailable(request *Request) *skillserver.EchoResponse {
+	response := skillserver.NewEchoResponse()
+	response.OutputSpeech(request.Localizer().Sprintf("trials.unavailable"))
+	return response
 }
@@ -0,0 +1,151 @@
+package alexa
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mikeflynn/go-alexa/skillserver"
+	"github.com/rking788/guardian-helper/bungie"
+	"github.com/rking788/guardian-helper/db"
+	"github.com/rking788/guardian-helper/i18n"
+)
+
+func TestDescribeMaxLightPlan(t *testing.T) {
+
+	plan := &bungie.MaxLightPlan{
+		CharacterClass: "warlock",
+		TransferCount:  7,
+		Unequipped: []*bungie.UnequippedItem{
+			{ItemName: "gjallarhorn", CharacterClass: "titan"},
+		},
+	}
+
+	expected := "This will move 7 items to your warlock and unequip your gjallarhorn from your titan."
+	if result := describeMaxLightPlan(i18n.For(i18n.EnglishUS), plan); result != expected {
+		t.Errorf("Unexpected plan description: %s", result)
+	}
+
+	plan = &bungie.MaxLightPlan{CharacterClass: "hunter", TransferCount: 1}
+	expected = "This will move 1 item to your hunter."
+	if result := describeMaxLightPlan(i18n.For(i18n.EnglishUS), plan); result != expected {
+		t.Errorf("Unexpected plan description: %s", result)
+	}
+
+	expected = "Dabei wird 1 Gegenstand zu deinem Jäger bewegt."
+	if result := describeMaxLightPlan(i18n.For("de-DE"), plan); result != expected {
+		t.Errorf("Unexpected German plan description: %s", result)
+	}
+}
+
+func TestBungieErrorResponse(t *testing.T) {
+
+	l := i18n.For(i18n.EnglishUS)
+	tests := []struct {
+		err      error
+		speech   string
+		linkCard bool
+	}{
+		{bungie.ErrAuthorization, l.Sprintf("error.link_account"), true},
+		{bungie.ErrThrottled, l.Sprintf("error.bungie_unavailable"), false},
+		{bungie.ErrUnavailable, l.Sprintf("error.bungie_unavailable"), false},
+		{bungie.ErrNoDestinyAccount, l.Sprintf("error.no_destiny_account"), false},
+		{errors.New("Failed to transfer"), l.Sprintf("transfer.error"), false},
+	}
+
+	for _, test := range tests {
+		response := bungieErrorResponse(l, test.err, "transfer.error")
+		if response.Response.OutputSpeech.Text != test.speech {
+			t.Errorf("Unexpected speech for %v: %s", test.err, response.Response.OutputSpeech.Text)
+		}
+		hasLinkCard := response.Response.Card != nil && response.Response.Card.Type == "LinkAccount"
+		if hasLinkCard != test.linkCard {
+			t.Errorf("Expected link account card(%v) for %v", test.linkCard, test.err)
+		}
+	}
+}
+
+func TestSSMLBuilder(t *testing.T) {
+
+	setLexicon([]*db.Pronunciation{
+		{Word: "gjallarhorn", Alphabet: "ipa", Phoneme: "ˈjɑːlɑːrhɔːrn"},
+	})
+	defer setLexicon(nil)
+
+	result := NewSSML().Text("You have 2 Gjallarhorn's & 1 ship").String()
+	expected := `<speak>You have <emphasis level="moderate">2</emphasis> <phoneme alphabet="ipa" ph="ˈjɑːlɑːrhɔːrn">` +
+		`Gjallarhorn</phoneme>&#39;s &amp; <emphasis level="moderate">1</emphasis> ship</speak>`
+	if result != expected {
+		t.Errorf("Unexpected SSML: %s", result)
+	}
+
+	result = NewSSML().List([]string{"titan", "hunter", "warlock"}).String()
+	expected = `<speak>titan<break strength="medium"/>hunter<break strength="medium"/>and warlock</speak>`
+	if result != expected {
+		t.Errorf("Unexpected SSML list: %s", result)
+	}
+}
+
+func TestConvertToSSML(t *testing.T) {
+
+	response := askQuestion("Which character, titan or hunter?")
+	ConvertToSSML(response)
+
+	expected := `<speak>Which character,<break strength="medium"/>titan or hunter?</speak>`
+	if response.Response.OutputSpeech.Type != "SSML" || response.Response.OutputSpeech.SSML != expected {
+		t.Errorf("Unexpected output speech: %+v", response.Response.OutputSpeech)
+	}
+	if reprompt := response.Response.Reprompt.OutputSpeech; reprompt.SSML != expected || reprompt.Text != "" {
+		t.Errorf("Unexpected reprompt: %+v", reprompt)
+	}
+}
+
+func TestRenderDisplay(t *testing.T) {
+
+	response := skillserver.NewEchoResponse().
+		StandardCard("Spinmetal", "Titan: 20\nVault: 200\nTotal: 220", "", "https://www.bungie.net/icon.jpg")
+	count := &bungie.ItemCount{
+		ItemName:  "spinmetal",
+		IconURL:   "https://www.bungie.net/icon.jpg",
+		Locations: []*bungie.ItemLocation{{CharacterClass: "titan", Quantity: 20}, {CharacterClass: "vault", Quantity: 200}},
+	}
+
+	request := newTestIntentRequest("CountItem", nil)
+	request.SetDisplay(itemCountDisplay(english, count))
+	RenderDisplay(request, response)
+	if len(request.Directives()) != 0 {
+		t.Fatalf("Expected no templates for a device without a display")
+	}
+
+	request = newTestIntentRequest("CountItem", nil)
+	request.SupportsDisplay = true
+	request.SetDisplay(itemCountDisplay(english, count))
+	RenderDisplay(request, response)
+	if len(request.Directives()) != 1 {
+		t.Fatalf("Expected a single template, got %d", len(request.Directives()))
+	}
+	if template := request.Directives()[0].(*RenderTemplate).Template; template.Type != ListTemplate {
+		t.Errorf("Expected the list template set by the handler, got %+v", template)
+	}
+
+	body, _ := MarshalResponse(request, response)
+	if !strings.Contains(string(body), `"directives":[{"type":"Display.RenderTemplate"`) ||
+		!strings.Contains(string(body), `"card":{`) {
+		t.Errorf("Expected the directive and the card in the response: %s", body)
+	}
+
+	// Templates set after the response was rendered are ignored
+	request.SetDisplay(nil)
+	if request.closeDisplay() == nil {
+		t.Error("Expected the template to be kept after the response was rendered")
+	}
+
+	request = newTestIntentRequest("CharacterSummary", nil)
+	request.SupportsDisplay = true
+	RenderDisplay(request, response)
+	template := request.Directives()[0].(*RenderTemplate).Template
+	if template.Type != BodyTemplate || template.TextContent.PrimaryText.Text != "Titan: 20\nVault: 200\nTotal: 220" ||
+		template.Image == nil {
+		t.Errorf("Expected the card to be shown as a body template without a handler template, got %+v", template)
+	}
+}
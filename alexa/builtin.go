@@ -0,0 +1,89 @@
+package alexa
+
+import (
+	"github.com/mikeflynn/go-alexa/skillserver"
+)
+
+// RememberResponse is a middleware that keeps the speech of each response in the session so it can
+// be repeated with the AMAZON.RepeatIntent.
+func RememberResponse(handler Handler) Handler {
+
+	return func(request *Request) *skillserver.EchoResponse {
+		response := handler(request)
+		if response == nil || response.Response.OutputSpeech == nil || request.GetIntentName() == "AMAZON.RepeatIntent" {
+			return response
+		}
+
+		session := GetSession(request.GetSessionID())
+		session.LastSpeech = response.Response.OutputSpeech.Text
+		session.LastReprompt = ""
+		if response.Response.Reprompt != nil {
+			session.LastReprompt = response.Response.Reprompt.OutputSpeech.Text
+		}
+		SaveSession(session)
+
+		return response
+	}
+}
+
+// Repeat handles the AMAZON.RepeatIntent by saying the last response in the session again.
+func Repeat(request *Request) *skillserver.EchoResponse {
+
+	session := GetSession(request.GetSessionID())
+	response := skillserver.NewEchoResponse()
+	if session.LastSpeech == "" {
+		response.OutputSpeech(request.Localizer().Sprintf("repeat.nothing")).
+			EndSession(false)
+		return response
+	}
+
+	response.OutputSpeech(session.LastSpeech)
+	if session.LastReprompt != "" {
+		response.Reprompt(session.LastReprompt)
+	}
+	response.EndSession(false)
+
+	return response
+}
+
+// StartOver handles the AMAZON.StartOverIntent by dropping anything in progress in the session and
+// welcoming the user again.
+func StartOver(request *Request) *skillserver.EchoResponse {
+
+	ClearSession(request.GetSessionID())
+	return WelcomePrompt(request)
+}
+
+// Fallback handles the AMAZON.FallbackIntent, and any other request that cannot be handled, by
+// asking again for whatever the current session is waiting for. If nothing is in progress, the
+// user is told what they can ask.
+func Fallback(request *Request) *skillserver.EchoResponse {
+
+	l := request.Localizer()
+	session := GetSession(request.GetSessionID())
+
+	question := ""
+	switch session.Action {
+	case CountItemAction:
+		question = l.Sprintf("count.ask_item")
+	case TransferItemAction:
+		if session.ItemName == "" {
+			question = l.Sprintf("transfer.ask_item")
+		} else {
+			question = l.Sprintf("transfer.ask_destination", session.ItemName)
+		}
+	case MaxLightAction, UnloadEngramsAction:
+		question = l.Sprintf("fallback.confirm")
+	}
+
+	if question != "" {
+		return askQuestion(l.Sprintf("fallback", question))
+	}
+
+	response := skillserver.NewEchoResponse()
+	response.OutputSpeech(l.Sprintf("fallback.suggestions")).
+		Reprompt(l.Sprintf("welcome.reprompt")).
+		EndSession(false)
+
+	return response
+}
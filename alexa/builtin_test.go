@@ -0,0 +1,58 @@
+package alexa
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRepeatLastResponse(t *testing.T) {
+
+	SetSessionStore(NewMemorySessionStore(time.Minute))
+
+	response := Repeat(newTestIntentRequest("AMAZON.RepeatIntent", nil))
+	if text := response.Response.OutputSpeech.Text; text != "Sorry Guardian, I haven't said anything yet. What would you like me to do?" {
+		t.Errorf("Unexpected response with nothing to repeat: %s", text)
+	}
+
+	RememberResponse(CountItem)(newTestIntentRequest("CountItem", nil))
+	handler := RememberResponse(Repeat)
+	response = handler(newTestIntentRequest("AMAZON.RepeatIntent", nil))
+	if text := response.Response.OutputSpeech.Text; text != "Which item would you like me to count?" {
+		t.Errorf("Expected the last response to be repeated, got: %s", text)
+	}
+	if response.Response.Reprompt == nil || response.Response.ShouldEndSession {
+		t.Errorf("Expected the reprompt to be repeated and the session to stay open")
+	}
+}
+
+func TestStartOverClearsSession(t *testing.T) {
+
+	SetSessionStore(NewMemorySessionStore(time.Minute))
+
+	TransferItem(newTestIntentRequest("TransferItem", map[string]string{"Item": "motes"}))
+	response := StartOver(newTestIntentRequest("AMAZON.StartOverIntent", nil))
+	if response.Response.ShouldEndSession {
+		t.Errorf("Expected the welcome prompt to keep the session open")
+	}
+
+	if session := GetSession("test-session"); session.Action != "" || session.ItemName != "" {
+		t.Errorf("Expected the session to be cleared, got %+v", session)
+	}
+}
+
+func TestFallbackAsksForPendingValue(t *testing.T) {
+
+	SetSessionStore(NewMemorySessionStore(time.Minute))
+
+	TransferItem(newTestIntentRequest("TransferItem", map[string]string{"Item": "motes"}))
+	response := Fallback(newTestIntentRequest("AMAZON.FallbackIntent", nil))
+	if text := response.Response.OutputSpeech.Text; text != "Sorry Guardian, I didn't catch that. Which character should get your motes?" {
+		t.Errorf("Unexpected fallback during a transfer: %s", text)
+	}
+
+	ClearSession("test-session")
+	response = Fallback(newTestIntentRequest("AMAZON.FallbackIntent", nil))
+	if response.Response.ShouldEndSession || response.Response.Reprompt == nil {
+		t.Errorf("Expected the suggestions to keep the session open")
+	}
+}
@@ -0,0 +1,93 @@
+package alexa
+
+import (
+	"github.com/mikeflynn/go-alexa/skillserver"
+	"github.com/rking788/guardian-helper/bungie"
+	"github.com/rking788/guardian-helper/db"
+	"github.com/rking788/guardian-helper/i18n"
+)
+
+// ConfirmAction handles the AMAZON.YesIntent by performing the action that is waiting for
+// confirmation in the current session.
+func ConfirmAction(request *Request) (response *skillserver.EchoResponse) {
+
+	session := GetSession(request.GetSessionID())
+	action, onlyTier, keepTier := session.Action, session.Tier, session.KeepTier
+	session.resetDialog("")
+	SaveSession(session)
+
+	switch action {
+	case MaxLightAction:
+		return equipMaxLight(request)
+	case UnloadEngramsAction:
+		return unloadEngrams(request, onlyTier, keepTier)
+	}
+
+	response = skillserver.NewEchoResponse()
+	response.OutputSpeech(request.Localizer().Sprintf("confirm.nothing"))
+	return
+}
+
+// CancelAction handles the AMAZON.NoIntent by dropping the action that is waiting for
+// confirmation in the current session without changing anything.
+func CancelAction(request *Request) (response *skillserver.EchoResponse) {
+
+	session := GetSession(request.GetSessionID())
+	action := session.Action
+	session.resetDialog("")
+	SaveSession(session)
+
+	response = skillserver.NewEchoResponse()
+	if action == MaxLightAction || action == UnloadEngramsAction {
+		response.OutputSpeech(request.Localizer().Sprintf("cancel.action"))
+	} else {
+		response.OutputSpeech(request.Localizer().Sprintf("cancel.ok"))
+	}
+
+	return
+}
+
+// EnableConfirmations will ask the user to confirm large or disruptive actions from now on.
+func EnableConfirmations(request *Request) *skillserver.EchoResponse {
+	return setConfirmations(request, true)
+}
+
+// DisableConfirmations will stop asking the user to confirm large or disruptive actions.
+func DisableConfirmations(request *Request) *skillserver.EchoResponse {
+	return setConfirmations(request, false)
+}
+
+func setConfirmations(request *Request, requireConfirmation bool) *skillserver.EchoResponse {
+
+	l := request.Localizer()
+	speech := l.Sprintf("confirmations.disabled")
+	if requireConfirmation {
+		speech = l.Sprintf("confirmations.enabled")
+	}
+
+	return savePreferences(request, func(prefs *db.UserPreferences) {
+		prefs.RequireConfirmation = requireConfirmation
+	}, speech)
+}
+
+// requiresConfirmation checks if the user wants to confirm disruptive actions, if the setting
+// cannot be loaded the user will be asked to be safe.
+func requiresConfirmation(request *Request) bool {
+	return request.Preferences().RequireConfirmation
+}
+
+// describeMaxLightPlan will summarize the changes needed to equip the max light loadout.
+// For example: "This will move 7 items and unequip your gjallarhorn from your titan."
+func describeMaxLightPlan(l *i18n.Localizer, plan *bungie.MaxLightPlan) string {
+
+	description := l.Plural("maxlight.plan", uint(plan.TransferCount), plan.TransferCount, l.Name("class", plan.CharacterClass))
+	if len(plan.Unequipped) > 0 {
+		unequipped := make([]string, 0, len(plan.Unequipped))
+		for _, item := range plan.Unequipped {
+			unequipped = append(unequipped, l.Sprintf("maxlight.plan.unequip_item", item.ItemName, l.Name("class", item.CharacterClass)))
+		}
+		description = l.Sprintf("maxlight.plan.unequip", description, l.List(unequipped))
+	}
+
+	return description + "."
+}
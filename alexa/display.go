@@ -0,0 +1,191 @@
+package alexa
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/mikeflynn/go-alexa/skillserver"
+)
+
+const (
+	// RenderTemplateDirective is the directive type used to show a template on devices with a screen
+	RenderTemplateDirective = "Display.RenderTemplate"
+	// ListTemplate is a vertical list of text items
+	ListTemplate = "ListTemplate1"
+	// BodyTemplate is a block of text with an optional image on the right
+	BodyTemplate = "BodyTemplate2"
+)
+
+// DisplayText is the text content of a template or list item.
+type DisplayText struct {
+	PrimaryText   *TextField `json:"primaryText,omitempty"`
+	SecondaryText *TextField `json:"secondaryText,omitempty"`
+}
+
+// TextField is a single piece of text in a template.
+type TextField struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// DisplayImage is an image shown in a template.
+type DisplayImage struct {
+	ContentDescription string        `json:"contentDescription"`
+	Sources            []ImageSource `json:"sources"`
+}
+
+// ImageSource is the URL of one size of a DisplayImage.
+type ImageSource struct {
+	URL string `json:"url"`
+}
+
+// ListItem is a single entry in a ListTemplate.
+type ListItem struct {
+	Token       string        `json:"token"`
+	Image       *DisplayImage `json:"image,omitempty"`
+	TextContent DisplayText   `json:"textContent"`
+}
+
+// DisplayTemplate is the template shown by a Display.RenderTemplate directive.
+type DisplayTemplate struct {
+	Type        string        `json:"type"`
+	Token       string        `json:"token"`
+	BackButton  string        `json:"backButton"`
+	Title       string        `json:"title"`
+	Image       *DisplayImage `json:"image,omitempty"`
+	TextContent *DisplayText  `json:"textContent,omitempty"`
+	ListItems   []*ListItem   `json:"listItems,omitempty"`
+}
+
+// RenderTemplate is the Display.RenderTemplate directive.
+type RenderTemplate struct {
+	Type     string           `json:"type"`
+	Template *DisplayTemplate `json:"template"`
+}
+
+// NewListTemplate will create a Display.RenderTemplate directive with a list of items.
+func NewListTemplate(title string, items []*ListItem) *RenderTemplate {
+	return &RenderTemplate{
+		Type: RenderTemplateDirective,
+		Template: &DisplayTemplate{
+			Type:       ListTemplate,
+			Token:      "list",
+			BackButton: "HIDDEN",
+			Title:      title,
+			ListItems:  items,
+		},
+	}
+}
+
+// NewBodyTemplate will create a Display.RenderTemplate directive with a block of text and an
+// optional image.
+func NewBodyTemplate(title, text, imageURL string) *RenderTemplate {
+	template := &DisplayTemplate{
+		Type:        BodyTemplate,
+		Token:       "body",
+		BackButton:  "HIDDEN",
+		Title:       title,
+		TextContent: &DisplayText{PrimaryText: plainText(text)},
+	}
+	if imageURL != "" {
+		template.Image = newDisplayImage(title, imageURL)
+	}
+
+	return &RenderTemplate{Type: RenderTemplateDirective, Template: template}
+}
+
+// newListItem will create the list item at the index, the secondary text is left out if it is empty.
+func newListItem(index int, primary, secondary string) *ListItem {
+
+	item := &ListItem{Token: "item-" + strconv.Itoa(index), TextContent: DisplayText{PrimaryText: plainText(primary)}}
+	if secondary != "" {
+		item.TextContent.SecondaryText = plainText(secondary)
+	}
+
+	return item
+}
+
+func plainText(text string) *TextField {
+	return &TextField{Type: "PlainText", Text: text}
+}
+
+func newDisplayImage(description, url string) *DisplayImage {
+	return &DisplayImage{
+		ContentDescription: description,
+		Sources:            []ImageSource{{URL: url}},
+	}
+}
+
+// SetDisplay will set the template shown on devices with a screen for this request, see the display
+// functions in render.go. Templates set after the response was rendered, like by a long running
+// operation that finished in the background, are ignored.
+func (request *Request) SetDisplay(template *RenderTemplate) {
+	request.displayLock.Lock()
+	defer request.displayLock.Unlock()
+
+	if !request.displayClosed {
+		request.display = template
+	}
+}
+
+// closeDisplay will stop accepting templates for the request and return the template that was set.
+func (request *Request) closeDisplay() *RenderTemplate {
+	request.displayLock.Lock()
+	defer request.displayLock.Unlock()
+
+	request.displayClosed = true
+	return request.display
+}
+
+// RenderDisplay will add the display template set by the handler when the device that sent the
+// request has a screen. If the handler did not set one, the response's card is shown as a block of
+// text instead. Devices without a screen only receive the card in the Alexa app.
+func RenderDisplay(request *Request, response *skillserver.EchoResponse) {
+
+	template := request.closeDisplay()
+	if !request.SupportsDisplay || len(request.Directives()) > 0 {
+		return
+	} else if template != nil {
+		request.AddDirective(template)
+		return
+	}
+
+	card := response.Response.Card
+	if card == nil || card.Content == "" {
+		return
+	}
+
+	imageURL := card.Image.LargeImageURL
+	if imageURL == "" {
+		imageURL = card.Image.SmallImageURL
+	}
+	request.AddDirective(NewBodyTemplate(card.Title, card.Content, imageURL))
+}
+
+// responseWithDirectives adds the directives to the response body since skillserver does not
+// support sending them.
+type responseWithDirectives struct {
+	Version           string                 `json:"version"`
+	SessionAttributes map[string]interface{} `json:"sessionAttributes,omitempty"`
+	Response          struct {
+		skillserver.EchoRespBody
+		Directives []interface{} `json:"directives,omitempty"`
+	} `json:"response"`
+}
+
+// MarshalResponse will serialize the response along with any directives that were added to the request.
+func MarshalResponse(request *Request, response *skillserver.EchoResponse) ([]byte, error) {
+
+	if len(request.Directives()) == 0 {
+		return response.String()
+	}
+
+	body := responseWithDirectives{
+		Version:           response.Version,
+		SessionAttributes: response.SessionAttributes,
+	}
+	body.Response.EchoRespBody = response.Response
+	body.Response.Directives = request.Directives()
+
+	return json.Marshal(body)
+}
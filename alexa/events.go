@@ -0,0 +1,168 @@
+package alexa
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/mikeflynn/go-alexa/skillserver"
+	"github.com/rking788/guardian-helper/db"
+)
+
+// Request types of the Alexa Skill Events sent when users enable, disable, or link the skill.
+const (
+	SkillEnabledEvent            = "AlexaSkillEvent.SkillEnabled"
+	SkillDisabledEvent           = "AlexaSkillEvent.SkillDisabled"
+	SkillAccountLinkedEvent      = "AlexaSkillEvent.SkillAccountLinked"
+	SkillPermissionAcceptedEvent = "AlexaSkillEvent.SkillPermissionAccepted"
+	SkillPermissionChangedEvent  = "AlexaSkillEvent.SkillPermissionChanged"
+)
+
+var (
+	// SkillEventMaxAge is how old a skill event can be before it is rejected. Events are not sent
+	// while the user is waiting for a response, so they are allowed to be much older than requests.
+	SkillEventMaxAge = time.Hour
+
+	// createUserData and deleteUserData store and remove the data kept for an Alexa user
+	createUserData = db.CreateUserPreferences
+	deleteUserData = db.DeleteUserData
+)
+
+// SkillEvent is an Alexa Skill Event, these are sent to the skill's event endpoint instead of
+// the skill's request endpoint and do not have a session.
+type SkillEvent struct {
+	Type          string
+	RequestID     string
+	Timestamp     time.Time
+	ApplicationID string
+	UserID        string
+	// AccessToken is the Bungie.net access token if the account is linked
+	AccessToken string
+}
+
+// skillEventEnvelope describes the JSON body of a skill event.
+type skillEventEnvelope struct {
+	Context struct {
+		System struct {
+			Application struct {
+				ApplicationID string `json:"applicationId"`
+			} `json:"application"`
+			User struct {
+				UserID      string `json:"userId"`
+				AccessToken string `json:"accessToken"`
+			} `json:"user"`
+		} `json:"System"`
+	} `json:"context"`
+	Request struct {
+		Type      string `json:"type"`
+		RequestID string `json:"requestId"`
+		Timestamp string `json:"timestamp"`
+		Body      struct {
+			AccessToken string `json:"accessToken"`
+		} `json:"body"`
+	} `json:"request"`
+}
+
+// ParseSkillEvent will decode the skill event from the JSON request body.
+func ParseSkillEvent(body []byte) (*SkillEvent, error) {
+
+	envelope := skillEventEnvelope{}
+	err := json.Unmarshal(body, &envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, envelope.Request.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid skill event timestamp(%s): %s", envelope.Request.Timestamp, err.Error())
+	}
+
+	event := &SkillEvent{
+		Type:          envelope.Request.Type,
+		RequestID:     envelope.Request.RequestID,
+		Timestamp:     timestamp,
+		ApplicationID: envelope.Context.System.Application.ApplicationID,
+		UserID:        envelope.Context.System.User.UserID,
+		AccessToken:   envelope.Context.System.User.AccessToken,
+	}
+	if event.Type == SkillAccountLinkedEvent {
+		event.AccessToken = envelope.Request.Body.AccessToken
+	}
+	if event.UserID == "" {
+		return nil, fmt.Errorf("The skill event(%s) does not have a user ID", event.RequestID)
+	}
+
+	return event, nil
+}
+
+// HandleSkillEvent will create or delete the data stored for the user in the event. When the skill
+// is disabled, the user's preferences, background jobs, rate limit counts, and cached Destiny
+// membership are removed. Sessions are stored by session ID rather than user, they are not removed
+// here and expire after the session TTL.
+func HandleSkillEvent(event *SkillEvent) error {
+
+	fmt.Printf("Received skill event(%s) with ID: %s\n", event.Type, event.RequestID)
+
+	switch event.Type {
+	case SkillEnabledEvent, SkillAccountLinkedEvent:
+		return createUserData(event.UserID)
+	case SkillDisabledEvent:
+		jobs.clear(event.UserID)
+		rateLimiters.forget(event.UserID)
+		if event.AccessToken != "" {
+			memberships.forget(event.AccessToken)
+		}
+		return deleteUserData(event.UserID)
+	case SkillPermissionAcceptedEvent, SkillPermissionChangedEvent:
+		// The skill does not request any permissions so there is nothing to update
+		return nil
+	}
+
+	return fmt.Errorf("Unknown skill event type: %s", event.Type)
+}
+
+// SkillEventHandler will create the HTTP handler for the skill event endpoint. Requests are verified
+// to be from Alexa, like requests to the skill, and events for other applications are rejected. The
+// verification is skipped when the _dev query parameter is set, the same as skillserver.
+func SkillEventHandler(appID string) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		if r.URL.Query().Get("_dev") == "" && !skillserver.IsValidAlexaRequest(w, r) {
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		event, err := ParseSkillEvent(body)
+		if err != nil {
+			fmt.Println("Failed to read the skill event: ", err.Error())
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		} else if event.ApplicationID != appID {
+			fmt.Println("Skill event for another application: ", event.ApplicationID)
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		} else if time.Since(event.Timestamp) > SkillEventMaxAge && r.URL.Query().Get("_dev") == "" {
+			fmt.Printf("Skill event(%s) is too old: %s\n", event.RequestID, event.Timestamp)
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		err = HandleSkillEvent(event)
+		if err != nil {
+			// Alexa will send the event again if it is not acknowledged
+			fmt.Println("Failed to handle the skill event: ", err.Error())
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
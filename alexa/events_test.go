@@ -0,0 +1,120 @@
+package alexa
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rking788/guardian-helper/bungie"
+)
+
+const testSkillDisabledEvent = `{
+	"version": "1.0",
+	"context": {"System": {"application": {"applicationId": "test-app"},
+		"user": {"userId": "test-user", "accessToken": "test-token"}}},
+	"request": {"type": "AlexaSkillEvent.SkillDisabled", "requestId": "event-1", "timestamp": "%s",
+		"body": {"userInformationPersistenceStatus": "NOT_PERSISTED"}}
+}`
+
+func testSkillEvent(eventType string, timestamp time.Time) string {
+	body := strings.Replace(testSkillDisabledEvent, "AlexaSkillEvent.SkillDisabled", eventType, 1)
+	return strings.Replace(body, "%s", timestamp.UTC().Format(time.RFC3339), 1)
+}
+
+func TestParseSkillEvent(t *testing.T) {
+
+	event, err := ParseSkillEvent([]byte(testSkillEvent(SkillDisabledEvent, time.Now())))
+	if err != nil {
+		t.Fatalf("Failed to parse the skill event: %s", err.Error())
+	}
+	if event.Type != SkillDisabledEvent || event.UserID != "test-user" || event.ApplicationID != "test-app" ||
+		event.AccessToken != "test-token" {
+		t.Errorf("Unexpected skill event: %+v", event)
+	}
+
+	linked := strings.Replace(testSkillEvent(SkillAccountLinkedEvent, time.Now()),
+		`"body": {`, `"body": {"accessToken": "linked-token", `, 1)
+	event, err = ParseSkillEvent([]byte(linked))
+	if err != nil || event.AccessToken != "linked-token" {
+		t.Errorf("Expected the linked access token from the event body, got %+v, %v", event, err)
+	}
+
+	if _, err := ParseSkillEvent([]byte(`{"request": {"type": "AlexaSkillEvent.SkillEnabled"}}`)); err == nil {
+		t.Error("Expected an error for an event without a timestamp or user")
+	}
+}
+
+func TestSkillDisabledDeletesUserData(t *testing.T) {
+
+	deleted := ""
+	originalDelete, originalMemberships := deleteUserData, memberships
+	defer func() { deleteUserData, memberships = originalDelete, originalMemberships }()
+	deleteUserData = func(alexaUserID string) error {
+		deleted = alexaUserID
+		return nil
+	}
+	memberships = newMembershipCache(time.Minute, func(string, uint) (*bungie.Membership, error) {
+		return &bungie.Membership{MembershipID: "4611686018"}, nil
+	})
+	memberships.get("test-token", bungie.PSN)
+	jobs.add("test-user", &backgroundJob{description: "engram unload", done: true})
+	limiter := newRateLimiter(1, time.Minute)
+	rateLimiters.add(limiter)
+	limiter.allow("test-user", time.Now())
+
+	err := HandleSkillEvent(&SkillEvent{Type: SkillDisabledEvent, UserID: "test-user", AccessToken: "test-token"})
+	if err != nil {
+		t.Fatalf("Failed to handle the skill event: %s", err.Error())
+	}
+
+	if deleted != "test-user" {
+		t.Errorf("Expected the user's data to be deleted, deleted: %s", deleted)
+	}
+	if completed := jobs.takeCompleted("test-user"); len(completed) != 0 {
+		t.Errorf("Expected the user's background jobs to be removed, found %d", len(completed))
+	}
+	if len(memberships.entries) != 0 {
+		t.Errorf("Expected the cached membership to be removed, found %d", len(memberships.entries))
+	}
+	if !limiter.allow("test-user", time.Now()) {
+		t.Error("Expected the user's rate limit count to be removed")
+	}
+}
+
+func TestSkillEventHandler(t *testing.T) {
+
+	created := ""
+	originalCreate := createUserData
+	defer func() { createUserData = originalCreate }()
+	createUserData = func(alexaUserID string) error {
+		created = alexaUserID
+		return nil
+	}
+
+	handler := SkillEventHandler("test-app")
+	tests := []struct {
+		appID  string
+		body   string
+		status int
+	}{
+		{"test-app", testSkillEvent(SkillEnabledEvent, time.Now()), http.StatusOK},
+		{"other-app", testSkillEvent(SkillEnabledEvent, time.Now()), http.StatusBadRequest},
+		{"test-app", testSkillEvent("AlexaSkillEvent.Unknown", time.Now()), http.StatusInternalServerError},
+		{"test-app", `not json`, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		body := strings.Replace(test.body, "test-app", test.appID, 1)
+		recorder := httptest.NewRecorder()
+		handler(recorder, httptest.NewRequest("POST", "/alexa/events?_dev=1", strings.NewReader(body)))
+		if recorder.Code != test.status {
+			t.Errorf("Expected status %d for %s, got %d", test.status, body, recorder.Code)
+		}
+	}
+
+	if created != "test-user" {
+		t.Errorf("Expected preferences to be created when the skill is enabled, created: %s", created)
+	}
+}
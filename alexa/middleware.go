@@ -0,0 +1,274 @@
+package alexa
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mikeflynn/go-alexa/skillserver"
+	"github.com/rking788/guardian-helper/bungie"
+)
+
+const (
+	// MembershipCacheTTL is how long the Destiny membership for an access token is kept before it
+	// is loaded from Bungie.net again.
+	MembershipCacheTTL = 10 * time.Minute
+)
+
+// Middleware wraps a Handler to add behavior before or after the handler runs, like checking that
+// the account is linked or recovering from a panic.
+type Middleware func(Handler) Handler
+
+// Chain will wrap the handler with each of the middleware. The first middleware is the outermost,
+// so it runs first and sees the response of all of the others.
+func Chain(handler Handler, middleware ...Middleware) Handler {
+
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+
+	return handler
+}
+
+// Recover is a middleware that will respond with a friendly message instead of dropping the
+// request if a handler panics.
+func Recover(handler Handler) Handler {
+
+	return func(request *Request) (response *skillserver.EchoResponse) {
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Printf("Recovered from panic handling %s: %v\n%s\n", request.GetIntentName(), r, debug.Stack())
+				response = skillserver.NewEchoResponse()
+				response.OutputSpeech(request.Localizer().Sprintf("error.unexpected"))
+			}
+		}()
+
+		return handler(request)
+	}
+}
+
+// Timing is a middleware that will log how long each intent took to handle.
+func Timing(handler Handler) Handler {
+
+	return func(request *Request) *skillserver.EchoResponse {
+		start := time.Now()
+		response := handler(request)
+
+		name := request.GetIntentName()
+		if name == "" {
+			name = request.GetRequestType()
+		}
+		fmt.Printf("Handled %s in %s\n", name, time.Since(start))
+
+		return response
+	}
+}
+
+// AuthWrapper is a middleware that will ask the user to link their Bungie.net account instead of
+// calling the handler when the request does not include an access token.
+func AuthWrapper(handler Handler) Handler {
+
+	return func(req *Request) *skillserver.EchoResponse {
+		accessToken := req.Session.User.AccessToken
+		if accessToken == "" {
+			response := skillserver.NewEchoResponse()
+			response.
+				OutputSpeech(req.Localizer().Sprintf("error.link_account")).
+				LinkAccountCard()
+			return response
+		}
+
+		return handler(req)
+	}
+}
+
+// ResolveMembership is a middleware that will load the Destiny membership for the linked account
+// and make it available to the handler with request.Membership(). This must run after AuthWrapper.
+func ResolveMembership(handler Handler) Handler {
+
+	return func(request *Request) *skillserver.EchoResponse {
+		membership, err := memberships.get(request.Session.User.AccessToken, request.Preferences().Platform)
+		if err != nil {
+			fmt.Println("Error loading the Destiny membership for the linked account: ", err.Error())
+			return bungieErrorResponse(request.Localizer(), err, "error.unexpected")
+		}
+
+		request.withValue(membershipKey, membership)
+		return handler(request)
+	}
+}
+
+// RateLimit will create a middleware that allows each user to make at most limit requests in
+// every window. Requests over the limit are answered without calling the handler so a single user
+// cannot exhaust the Bungie.net API quota.
+func RateLimit(limit int, window time.Duration) Middleware {
+
+	limiter := newRateLimiter(limit, window)
+	rateLimiters.add(limiter)
+	return func(handler Handler) Handler {
+		return func(request *Request) *skillserver.EchoResponse {
+			if !limiter.allow(request.GetUserID(), time.Now()) {
+				fmt.Println("Rate limit exceeded for a user, intent: ", request.GetIntentName())
+				response := skillserver.NewEchoResponse()
+				response.OutputSpeech(request.Localizer().Sprintf("error.rate_limited"))
+				return response
+			}
+
+			return handler(request)
+		}
+	}
+}
+
+// rateLimiter counts the requests from each user in fixed windows.
+type rateLimiter struct {
+	sync.Mutex
+	limit   int
+	window  time.Duration
+	windows map[string]*rateWindow
+}
+
+// rateLimiterList holds every rateLimiter created by RateLimit so the requests counted for a user
+// can be forgotten when they disable the skill.
+type rateLimiterList struct {
+	sync.Mutex
+	limiters []*rateLimiter
+}
+
+var rateLimiters = &rateLimiterList{}
+
+func (list *rateLimiterList) add(limiter *rateLimiter) {
+	list.Lock()
+	defer list.Unlock()
+
+	list.limiters = append(list.limiters, limiter)
+}
+
+// forget will remove the requests counted for the user by every rate limiter.
+func (list *rateLimiterList) forget(userID string) {
+	list.Lock()
+	defer list.Unlock()
+
+	for _, limiter := range list.limiters {
+		limiter.forget(userID)
+	}
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		window:  window,
+		windows: make(map[string]*rateWindow),
+	}
+}
+
+// allow will count a request from the user and return false if the user is over the limit.
+func (limiter *rateLimiter) allow(userID string, now time.Time) bool {
+	limiter.Lock()
+	defer limiter.Unlock()
+
+	current, ok := limiter.windows[userID]
+	if !ok || now.Sub(current.start) >= limiter.window {
+		limiter.removeExpired(now)
+		current = &rateWindow{start: now}
+		limiter.windows[userID] = current
+	}
+
+	current.count++
+	return current.count <= limiter.limit
+}
+
+// forget will remove the requests counted for the user.
+func (limiter *rateLimiter) forget(userID string) {
+	limiter.Lock()
+	defer limiter.Unlock()
+
+	delete(limiter.windows, userID)
+}
+
+// removeExpired will drop the windows that have ended so users that stop making requests
+// are not kept forever. The lock must be held by the caller.
+func (limiter *rateLimiter) removeExpired(now time.Time) {
+	for userID, window := range limiter.windows {
+		if now.Sub(window.start) >= limiter.window {
+			delete(limiter.windows, userID)
+		}
+	}
+}
+
+// membershipCache keeps the Destiny membership for each access token and platform so it does not need
+// to be loaded from Bungie.net on every request.
+type membershipCache struct {
+	sync.Mutex
+	ttl     time.Duration
+	load    func(accessToken string, platform uint) (*bungie.Membership, error)
+	entries map[string]*cachedMembership
+}
+
+type cachedMembership struct {
+	membership *bungie.Membership
+	expires    time.Time
+}
+
+var memberships = newMembershipCache(MembershipCacheTTL, loadMembership)
+
+func newMembershipCache(ttl time.Duration, load func(string, uint) (*bungie.Membership, error)) *membershipCache {
+	return &membershipCache{
+		ttl:     ttl,
+		load:    load,
+		entries: make(map[string]*cachedMembership),
+	}
+}
+
+func loadMembership(accessToken string, platform uint) (*bungie.Membership, error) {
+	client := bungie.NewClient(accessToken, os.Getenv("BUNGIE_API_KEY"))
+	client.MembershipType = platform
+	return client.GetCurrentMembership()
+}
+
+func (cache *membershipCache) get(accessToken string, platform uint) (*bungie.Membership, error) {
+
+	key := fmt.Sprintf("%d:%s", platform, accessToken)
+	now := time.Now()
+	cache.Lock()
+	entry, ok := cache.entries[key]
+	cache.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.membership, nil
+	}
+
+	membership, err := cache.load(accessToken, platform)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Lock()
+	defer cache.Unlock()
+	for cachedKey, entry := range cache.entries {
+		if !now.Before(entry.expires) {
+			delete(cache.entries, cachedKey)
+		}
+	}
+	cache.entries[key] = &cachedMembership{membership: membership, expires: now.Add(cache.ttl)}
+
+	return membership, nil
+}
+
+// forget will remove the cached memberships for the access token on every platform.
+func (cache *membershipCache) forget(accessToken string) {
+	cache.Lock()
+	defer cache.Unlock()
+
+	for key := range cache.entries {
+		if strings.HasSuffix(key, ":"+accessToken) {
+			delete(cache.entries, key)
+		}
+	}
+}
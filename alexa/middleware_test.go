@@ -0,0 +1,128 @@
+package alexa
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeflynn/go-alexa/skillserver"
+	"github.com/rking788/guardian-helper/bungie"
+)
+
+func speechHandler(speech string) Handler {
+	return func(request *Request) *skillserver.EchoResponse {
+		response := skillserver.NewEchoResponse()
+		response.OutputSpeech(speech)
+		return response
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+
+	order := make([]string, 0)
+	record := func(name string) Middleware {
+		return func(handler Handler) Handler {
+			return func(request *Request) *skillserver.EchoResponse {
+				order = append(order, name)
+				return handler(request)
+			}
+		}
+	}
+
+	Chain(speechHandler("done"), record("first"), record("second"))(newTestIntentRequest("CountItem", nil))
+	if strings.Join(order, ",") != "first,second" {
+		t.Errorf("Expected the middleware to run in order, got: %v", order)
+	}
+}
+
+func TestRecover(t *testing.T) {
+
+	panics := func(request *Request) *skillserver.EchoResponse {
+		panic("something broke")
+	}
+
+	response := Recover(panics)(newTestIntentRequest("CountItem", nil))
+	if response == nil || !strings.HasPrefix(response.Response.OutputSpeech.Text, "Sorry Guardian, something went wrong") {
+		t.Errorf("Expected a friendly response after a panic, got: %+v", response)
+	}
+}
+
+func TestAuthWrapper(t *testing.T) {
+
+	request := newTestIntentRequest("CountItem", nil)
+	request.Session.User.AccessToken = ""
+
+	response := AuthWrapper(speechHandler("done"))(request)
+	if response.Response.Card == nil || response.Response.Card.Type != "LinkAccount" {
+		t.Errorf("Expected a link account card without an access token")
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+
+	handler := RateLimit(2, time.Minute)(speechHandler("done"))
+	request := newTestIntentRequest("CountItem", nil)
+
+	for i := 0; i < 2; i++ {
+		if response := handler(request); response.Response.OutputSpeech.Text != "done" {
+			t.Errorf("Expected request %d to be allowed", i+1)
+		}
+	}
+	if response := handler(request); response.Response.OutputSpeech.Text == "done" {
+		t.Errorf("Expected the third request to be rate limited")
+	}
+
+	other := newTestIntentRequest("CountItem", nil)
+	other.Session.User.UserID = "other-user"
+	if response := handler(other); response.Response.OutputSpeech.Text != "done" {
+		t.Errorf("Expected other users to have their own limit")
+	}
+}
+
+func TestRateLimiterWindow(t *testing.T) {
+
+	limiter := newRateLimiter(1, time.Minute)
+	now := time.Now()
+	if !limiter.allow("user", now) || limiter.allow("user", now.Add(time.Second)) {
+		t.Errorf("Expected only one request in the window")
+	}
+	if !limiter.allow("user", now.Add(time.Minute)) {
+		t.Errorf("Expected a new window to allow requests again")
+	}
+}
+
+func TestResolveMembership(t *testing.T) {
+
+	loads := 0
+	original := memberships
+	defer func() { memberships = original }()
+	memberships = newMembershipCache(time.Minute, func(accessToken string, platform uint) (*bungie.Membership, error) {
+		loads++
+		if accessToken != "test-token" {
+			return nil, errors.New("Unknown access token")
+		}
+		return &bungie.Membership{MembershipType: bungie.XBOX, MembershipID: "4611686018"}, nil
+	})
+
+	handler := ResolveMembership(func(request *Request) *skillserver.EchoResponse {
+		response := skillserver.NewEchoResponse()
+		response.OutputSpeech(request.Membership().MembershipID)
+		return response
+	})
+
+	for i := 0; i < 2; i++ {
+		if response := handler(newTestIntentRequest("TrialsCurrentWeek", nil)); response.Response.OutputSpeech.Text != "4611686018" {
+			t.Errorf("Expected the membership to be in the request, got: %s", response.Response.OutputSpeech.Text)
+		}
+	}
+	if loads != 1 {
+		t.Errorf("Expected the membership to be cached, loaded %d times", loads)
+	}
+
+	request := newTestIntentRequest("TrialsCurrentWeek", nil)
+	request.Session.User.AccessToken = "expired-token"
+	if response := handler(request); response.Response.OutputSpeech.Text == "4611686018" {
+		t.Errorf("Expected the handler to not be called when the membership cannot be loaded")
+	}
+}
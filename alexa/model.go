@@ -0,0 +1,412 @@
+package alexa
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rking788/guardian-helper/db"
+)
+
+// Names of the custom slot types used by the skill
+const (
+	ItemSlotType       = "ITEM_TYPE"
+	ClassSlotType      = "CLASS_TYPE"
+	EngramTierSlotType = "ENGRAM_TIER"
+	PlatformSlotType   = "PLATFORM_TYPE"
+	VerbositySlotType  = "VERBOSITY_TYPE"
+	NumberSlotType     = "AMAZON.NUMBER"
+)
+
+// SlotType is a custom slot type in the interaction model.
+type SlotType struct {
+	Name string
+	// File is the name of the values files in the custom-slot-types directory without an extension,
+	// the values are written to a .txt file and the values with IDs and synonyms to a .json file
+	File string
+	// Values are the fixed values of the slot type, each value is also used as its ID
+	Values []string
+	// Load will read the values from the database when there are no fixed Values
+	Load func() ([]*db.SlotValue, error)
+}
+
+// SlotValues will return the values of the slot type, either the fixed Values or the values read
+// with Load.
+func (slotType *SlotType) SlotValues() ([]*db.SlotValue, error) {
+
+	if slotType.Load != nil {
+		return slotType.Load()
+	}
+
+	values := make([]*db.SlotValue, 0, len(slotType.Values))
+	for _, value := range slotType.Values {
+		values = append(values, &db.SlotValue{ID: value, Name: value})
+	}
+
+	return values, nil
+}
+
+// Slot is a named value that Alexa will read from an utterance.
+type Slot struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Intent describes one of the intents the skill understands, along with the sample utterances
+// Alexa uses to recognize it. Built-in AMAZON intents do not need samples.
+type Intent struct {
+	Name    string
+	Slots   []Slot
+	Samples []string
+}
+
+// SlotTypes are the custom slot types used by the intents in the InteractionModel.
+var SlotTypes = []*SlotType{
+	{Name: ItemSlotType, File: "Item", Load: db.LoadItemSlotValues},
+	{Name: ClassSlotType, File: "Class", Values: []string{"warlock", "titan", "hunter", "vault"}},
+	{Name: EngramTierSlotType, File: "EngramTier", Values: []string{"uncommon", "rare", "legendary", "exotic"}},
+	{Name: PlatformSlotType, File: "Platform", Values: []string{"xbox", "psn", "playstation", "play station", "ps4"}},
+	{Name: VerbositySlotType, File: "Verbosity", Values: []string{"brief", "detailed"}},
+}
+
+// InteractionModel is the registry of every intent the skill understands. The intent schema,
+// slot types, and sample utterances uploaded to the Alexa developer portal are generated from
+// this with the interaction-model command, and every intent must have a handler.
+var InteractionModel = []*Intent{
+	{
+		Name:  "CountItem",
+		Slots: []Slot{{"Item", ItemSlotType}},
+		Samples: []string{
+			"how many {Item} do I have",
+			"how much {Item} do I have",
+			"how many {Item} I have",
+			"count my {Item}",
+			"to count my {Item}",
+			"count {Item}",
+			"count an item",
+		},
+	},
+	{
+		Name: "TransferItem",
+		Slots: []Slot{
+			{"Count", NumberSlotType},
+			{"Item", ItemSlotType},
+			{"Destination", ClassSlotType},
+			{"Source", ClassSlotType},
+		},
+		Samples: []string{
+			"transfer {Item} to my {Destination}",
+			"transfer {Count} {Item} to my {Destination}",
+			"transfer {Item} from my {Source} to my {Destination}",
+			"transfer {Count} {Item} from my {Source} to my {Destination}",
+			"move {Item} to my {Destination}",
+			"move {Count} {Item} to my {Destination}",
+			"move {Count} {Item} from my {Source} to my {Destination}",
+			"send {Item} to my {Destination}",
+			"give my {Destination} {Count} {Item}",
+			"transfer {Item}",
+			"transfer an item",
+		},
+	},
+	{
+		Name: "TrialsCurrentMap",
+		Samples: []string{
+			"what is the current trials map",
+			"what is the trials map",
+			"what map is trials on",
+			"which map is trials on this week",
+		},
+	},
+	{
+		Name: "TrialsCurrentWeek",
+		Samples: []string{
+			"how am I doing in trials",
+			"how have I done in trials this week",
+			"what are my trials stats",
+			"for my trials stats this week",
+		},
+	},
+	{
+		Name: "TrialsTopWeapons",
+		Samples: []string{
+			"what are the top weapons in trials",
+			"what are the most popular weapons in trials",
+			"for the top trials weapons",
+		},
+	},
+	{
+		Name: "TrialsPopularWeaponTypes",
+		Samples: []string{
+			"what are the most popular weapon types in trials",
+			"which weapon types are popular in trials",
+			"what weapon types are people using in trials",
+		},
+	},
+	{
+		Name: "TrialsPersonalTopWeapons",
+		Samples: []string{
+			"what are my top weapons in trials",
+			"which weapons do I use the most in trials",
+			"for my most used trials weapons",
+		},
+	},
+	{
+		Name:  "UnloadEngrams",
+		Slots: []Slot{{"Tier", EngramTierSlotType}, {"KeepTier", EngramTierSlotType}},
+		Samples: []string{
+			"unload engrams",
+			"unload my engrams",
+			"move my engrams to the vault",
+			"unload my {Tier} engrams",
+			"unload {Tier} engrams",
+			"move my {Tier} engrams to the vault",
+			"unload my engrams but keep my {KeepTier} engrams",
+			"unload my engrams except {KeepTier}",
+		},
+	},
+	{
+		Name: "EquipMaxLight",
+		Samples: []string{
+			"equip max light",
+			"equip my max light gear",
+			"equip my highest light gear",
+			"give me max light",
+		},
+	},
+	{
+		Name: "CharacterSummary",
+		Samples: []string{
+			"describe my characters",
+			"tell me about my characters",
+			"what light level are my characters",
+			"when did I last play",
+		},
+	},
+	{
+		Name:    "ProvideItem",
+		Slots:   []Slot{{"Item", ItemSlotType}},
+		Samples: []string{"{Item}", "my {Item}", "the {Item}"},
+	},
+	{
+		Name:    "ProvideCharacter",
+		Slots:   []Slot{{"Destination", ClassSlotType}},
+		Samples: []string{"{Destination}", "my {Destination}", "to my {Destination}", "the {Destination}"},
+	},
+	{
+		Name: "EnableConfirmations",
+		Samples: []string{
+			"turn on confirmations",
+			"enable confirmations",
+			"ask me before moving my gear",
+		},
+	},
+	{
+		Name: "DisableConfirmations",
+		Samples: []string{
+			"turn off confirmations",
+			"disable confirmations",
+			"stop asking me before moving my gear",
+		},
+	},
+	{
+		Name:  "SetPlatform",
+		Slots: []Slot{{"Platform", PlatformSlotType}},
+		Samples: []string{
+			"always use my {Platform} account",
+			"use my {Platform} account",
+			"switch to my {Platform} account",
+			"I play on {Platform}",
+		},
+	},
+	{
+		Name:  "SetDefaultCharacter",
+		Slots: []Slot{{"Character", ClassSlotType}},
+		Samples: []string{
+			"make my {Character} my default character",
+			"set my default character to my {Character}",
+			"use my {Character} by default",
+			"my main character is my {Character}",
+		},
+	},
+	{
+		Name:  "SetVerbosity",
+		Slots: []Slot{{"Verbosity", VerbositySlotType}},
+		Samples: []string{
+			"use {Verbosity} responses",
+			"give me {Verbosity} answers",
+			"make your answers {Verbosity}",
+			"switch to {Verbosity} mode",
+		},
+	},
+	{Name: "AMAZON.YesIntent"},
+	{Name: "AMAZON.NoIntent"},
+	{Name: "AMAZON.HelpIntent"},
+	{Name: "AMAZON.StopIntent"},
+	{Name: "AMAZON.CancelIntent"},
+	{Name: "AMAZON.RepeatIntent"},
+	{Name: "AMAZON.StartOverIntent"},
+	{Name: "AMAZON.FallbackIntent"},
+}
+
+var sampleSlotPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// ValidateInteractionModel will check that every slot has a known type and that the slots used in
+// the sample utterances belong to the intent.
+func ValidateInteractionModel() error {
+
+	slotTypes := make(map[string]bool)
+	for _, slotType := range SlotTypes {
+		slotTypes[slotType.Name] = true
+	}
+
+	names := make(map[string]bool)
+	for _, intent := range InteractionModel {
+		if names[intent.Name] {
+			return fmt.Errorf("Intent %s is defined more than once", intent.Name)
+		}
+		names[intent.Name] = true
+
+		slots := make(map[string]bool)
+		for _, slot := range intent.Slots {
+			if !slotTypes[slot.Type] && !strings.HasPrefix(slot.Type, "AMAZON.") {
+				return fmt.Errorf("Slot %s of %s has an unknown type: %s", slot.Name, intent.Name, slot.Type)
+			}
+			slots[slot.Name] = true
+		}
+
+		for _, sample := range intent.Samples {
+			for _, match := range sampleSlotPattern.FindAllStringSubmatch(sample, -1) {
+				if !slots[match[1]] {
+					return fmt.Errorf("Sample \"%s\" of %s uses an unknown slot: %s", sample, intent.Name, match[1])
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckHandlers will return an error if an intent in the InteractionModel has no handler or if
+// a handler does not have an intent in the InteractionModel.
+func CheckHandlers(handlers map[string]Handler) error {
+
+	missing := make([]string, 0)
+	intents := make(map[string]bool)
+	for _, intent := range InteractionModel {
+		intents[intent.Name] = true
+		if _, ok := handlers[intent.Name]; !ok {
+			missing = append(missing, intent.Name)
+		}
+	}
+
+	unknown := make([]string, 0)
+	for name := range handlers {
+		if !intents[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+
+	if len(missing) > 0 && len(unknown) > 0 {
+		return fmt.Errorf("Intents without a handler: %s, handlers without an intent: %s",
+			strings.Join(missing, ", "), strings.Join(unknown, ", "))
+	} else if len(missing) > 0 {
+		return fmt.Errorf("Intents without a handler: %s", strings.Join(missing, ", "))
+	} else if len(unknown) > 0 {
+		return fmt.Errorf("Handlers without an intent: %s", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+// schemaIntent is the format of an intent in the intent schema.
+type schemaIntent struct {
+	Intent string `json:"intent"`
+	Slots  []Slot `json:"slots,omitempty"`
+}
+
+// WriteIntentSchema will write the intent schema JSON for the InteractionModel.
+func WriteIntentSchema(w io.Writer) error {
+
+	schema := struct {
+		Intents []schemaIntent `json:"intents"`
+	}{}
+	for _, intent := range InteractionModel {
+		schema.Intents = append(schema.Intents, schemaIntent{Intent: intent.Name, Slots: intent.Slots})
+	}
+
+	body, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(body, '\n'))
+	return err
+}
+
+// WriteSampleUtterances will write the sample utterances for the InteractionModel, one per line
+// prefixed by the intent name.
+func WriteSampleUtterances(w io.Writer) error {
+
+	buffer := bytes.NewBufferString("")
+	for _, intent := range InteractionModel {
+		for _, sample := range intent.Samples {
+			buffer.WriteString(intent.Name + " " + sample + "\n")
+		}
+	}
+
+	_, err := buffer.WriteTo(w)
+	return err
+}
+
+// WriteSlotTypeValues will write the names of the slot values, one per line.
+func WriteSlotTypeValues(w io.Writer, values []*db.SlotValue) error {
+
+	buffer := bytes.NewBufferString("")
+	for _, value := range values {
+		buffer.WriteString(value.Name + "\n")
+	}
+
+	_, err := buffer.WriteTo(w)
+	return err
+}
+
+// slotTypeJSON is the format of a custom slot type in the Alexa interaction model JSON, this
+// includes the IDs used for entity resolution.
+type slotTypeJSON struct {
+	Name   string          `json:"name"`
+	Values []slotValueJSON `json:"values"`
+}
+
+type slotValueJSON struct {
+	ID   string `json:"id"`
+	Name struct {
+		Value    string   `json:"value"`
+		Synonyms []string `json:"synonyms,omitempty"`
+	} `json:"name"`
+}
+
+// WriteSlotTypeJSON will write the slot type in the Alexa interaction model JSON format, including
+// the ID and synonyms of each value.
+func WriteSlotTypeJSON(w io.Writer, slotType *SlotType, values []*db.SlotValue) error {
+
+	result := slotTypeJSON{Name: slotType.Name, Values: make([]slotValueJSON, 0, len(values))}
+	for _, value := range values {
+		valueJSON := slotValueJSON{ID: value.ID}
+		valueJSON.Name.Value = value.Name
+		valueJSON.Name.Synonyms = value.Synonyms
+		result.Values = append(result.Values, valueJSON)
+	}
+
+	body, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(body, '\n'))
+	return err
+}
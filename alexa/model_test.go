@@ -0,0 +1,84 @@
+package alexa
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mikeflynn/go-alexa/skillserver"
+)
+
+func TestValidateInteractionModel(t *testing.T) {
+
+	err := ValidateInteractionModel()
+	if err != nil {
+		t.Errorf("Invalid interaction model: %s", err.Error())
+	}
+
+	original := InteractionModel
+	defer func() { InteractionModel = original }()
+	InteractionModel = []*Intent{{Name: "CountItem", Samples: []string{"count my {Item}"}}}
+	if err := ValidateInteractionModel(); err == nil {
+		t.Errorf("Expected a sample with an undefined slot to be invalid")
+	}
+}
+
+func TestCheckHandlers(t *testing.T) {
+
+	handlers := make(map[string]Handler)
+	for _, intent := range InteractionModel {
+		handlers[intent.Name] = EndSession
+	}
+	if err := CheckHandlers(handlers); err != nil {
+		t.Errorf("Unexpected error: %s", err.Error())
+	}
+
+	delete(handlers, "EquipMaxLight")
+	handlers["Unregistered"] = func(*Request) *skillserver.EchoResponse { return nil }
+	err := CheckHandlers(handlers)
+	if err == nil || !strings.Contains(err.Error(), "EquipMaxLight") || !strings.Contains(err.Error(), "Unregistered") {
+		t.Errorf("Expected the missing handler and unknown intent to be reported, got: %v", err)
+	}
+}
+
+func TestWriteSampleUtterances(t *testing.T) {
+
+	buffer := bytes.NewBufferString("")
+	WriteSampleUtterances(buffer)
+	if !strings.Contains(buffer.String(), "TransferItem transfer {Count} {Item} to my {Destination}\n") {
+		t.Errorf("Expected the transfer samples in the output")
+	}
+	if strings.Contains(buffer.String(), "AMAZON.") {
+		t.Errorf("Built-in intents should not have samples")
+	}
+}
+
+const testResolutionEnvelope = `{
+	"request": {"type": "IntentRequest", "locale": "en-US", "intent": {"name": "CountItem", "slots": {
+		"Item": {"name": "Item", "value": "spin mental", "resolutions": {"resolutionsPerAuthority": [
+			{"authority": "amzn1.er-authority.echo-sdk.skill.ITEM_TYPE", "status": {"code": "ER_SUCCESS_MATCH"},
+				"values": [{"value": {"name": "spinmetal", "id": "2882093969"}}]}
+		]}},
+		"Destination": {"name": "Destination", "value": "paladin", "resolutions": {"resolutionsPerAuthority": [
+			{"authority": "amzn1.er-authority.echo-sdk.skill.CLASS_TYPE", "status": {"code": "ER_SUCCESS_NO_MATCH"}}
+		]}}
+	}}}
+}`
+
+func TestItemSlotValueUsesEntityResolution(t *testing.T) {
+
+	request := NewRequest(newTestIntentRequest("CountItem", map[string]string{"Item": "spin mental"}).EchoRequest,
+		[]byte(testResolutionEnvelope))
+
+	if name, hash := itemSlotValue(request, "spin mental"); name != "spinmetal" || hash != 2882093969 {
+		t.Errorf("Expected the resolved item, got: %s (%d)", name, hash)
+	}
+	if _, ok := request.ResolvedSlotValue("Destination"); ok {
+		t.Errorf("Expected an unmatched slot to not be resolved")
+	}
+
+	request = newTestIntentRequest("CountItem", map[string]string{"Item": "Spin Mental"})
+	if name, hash := itemSlotValue(request, "Spin Mental"); name != "spin mental" || hash != 0 {
+		t.Errorf("Expected the spoken value without entity resolution, got: %s (%d)", name, hash)
+	}
+}
@@ -0,0 +1,104 @@
+package alexa
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mikeflynn/go-alexa/skillserver"
+	"github.com/rking788/guardian-helper/bungie"
+	"github.com/rking788/guardian-helper/db"
+	"github.com/rking788/guardian-helper/i18n"
+)
+
+// SetPlatform will save the platform of the Destiny account that should be used when the Bungie.net
+// account has more than one, for example "always use my PlayStation account".
+func SetPlatform(request *Request) *skillserver.EchoResponse {
+
+	l := request.Localizer()
+	platformName, _ := request.GetSlotValue("Platform")
+	platform, ok := bungie.PlatformFromName(platformName)
+	if !ok {
+		response := skillserver.NewEchoResponse()
+		response.OutputSpeech(l.Sprintf("preferences.unknown_platform", platformName))
+		return response
+	}
+
+	return savePreferences(request, func(prefs *db.UserPreferences) {
+		prefs.Platform = platform
+	}, l.Sprintf("preferences.platform", l.Name("platform", bungie.PlatformName(platform))))
+}
+
+// SetDefaultCharacter will save the character that should be used for max light and transfers when
+// a character is not specified.
+func SetDefaultCharacter(request *Request) *skillserver.EchoResponse {
+
+	l := request.Localizer()
+	className, _ := request.GetSlotValue("Character")
+	hash, ok := classHashFromName(l, className)
+	if !ok {
+		response := skillserver.NewEchoResponse()
+		response.OutputSpeech(l.Sprintf("character.unknown", className, l.Sprintf("preferences.character_hint")))
+		return response
+	} else if hash == vaultClassHash {
+		response := skillserver.NewEchoResponse()
+		response.OutputSpeech(l.Sprintf("preferences.vault_character", l.Sprintf("preferences.character_hint")))
+		return response
+	}
+
+	className = classNameFromHash(hash)
+	return savePreferences(request, func(prefs *db.UserPreferences) {
+		prefs.DefaultCharacter = className
+	}, l.Sprintf("preferences.character", l.Name("class", className)))
+}
+
+// SetVerbosity will save whether the user prefers brief or detailed responses.
+func SetVerbosity(request *Request) *skillserver.EchoResponse {
+
+	l := request.Localizer()
+	spoken, _ := request.GetSlotValue("Verbosity")
+	verbosity, ok := verbositySlotValue(l, spoken)
+	if !ok {
+		response := skillserver.NewEchoResponse()
+		response.OutputSpeech(l.Sprintf("preferences.unknown_verbosity"))
+		return response
+	}
+
+	return savePreferences(request, func(prefs *db.UserPreferences) {
+		prefs.Verbosity = verbosity
+	}, l.Sprintf("preferences.verbosity."+verbosity))
+}
+
+// verbositySlotValue will find the verbosity for the spoken value, "brief" and "detailed" in English.
+func verbositySlotValue(l *i18n.Localizer, spoken string) (string, bool) {
+
+	verbosity, ok := l.FindName("verbosity", spoken, []string{db.VerbosityBrief, db.VerbosityNormal})
+	if !ok && strings.ToLower(strings.TrimSpace(spoken)) == "detailed" {
+		return db.VerbosityNormal, true
+	}
+
+	return verbosity, ok
+}
+
+// savePreferences will apply the change to the user's preferences, save them, and respond with the
+// speech if they were saved. Nothing is saved if the preferences could not be loaded, saving the
+// defaults would reset the user's other settings.
+func savePreferences(request *Request, change func(*db.UserPreferences), speech string) *skillserver.EchoResponse {
+
+	response := skillserver.NewEchoResponse()
+	prefs, err := request.savedPreferences()
+	if err != nil {
+		response.OutputSpeech(request.Localizer().Sprintf("confirmations.save_error"))
+		return response
+	}
+
+	change(prefs)
+	err = db.SaveUserPreferences(prefs)
+	if err != nil {
+		fmt.Println("Failed to save the user preferences: ", err.Error())
+		response.OutputSpeech(request.Localizer().Sprintf("confirmations.save_error"))
+		return response
+	}
+
+	response.OutputSpeech(speech)
+	return response
+}
@@ -0,0 +1,102 @@
+package alexa
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rking788/guardian-helper/db"
+	"github.com/rking788/guardian-helper/i18n"
+)
+
+func TestVerbositySlotValue(t *testing.T) {
+
+	tests := []struct {
+		locale    string
+		spoken    string
+		verbosity string
+		ok        bool
+	}{
+		{i18n.EnglishUS, "brief", db.VerbosityBrief, true},
+		{i18n.EnglishUS, "Detailed", db.VerbosityNormal, true},
+		{"de-DE", "kurz", db.VerbosityBrief, true},
+		{"de-DE", "ausführlich", db.VerbosityNormal, true},
+		{"de-DE", "detailed", db.VerbosityNormal, true},
+		{i18n.EnglishUS, "loud", "", false},
+	}
+
+	for _, test := range tests {
+		verbosity, ok := verbositySlotValue(i18n.For(test.locale), test.spoken)
+		if verbosity != test.verbosity || ok != test.ok {
+			t.Errorf("Unexpected verbosity for %s(%s): %s, %v", test.spoken, test.locale, verbosity, ok)
+		}
+	}
+}
+
+func TestPreferencesRejectUnknownValues(t *testing.T) {
+
+	response := SetPlatform(newTestIntentRequest("SetPlatform", map[string]string{"Platform": "dreamcast"}))
+	if text := response.Response.OutputSpeech.Text; text != "Sorry Guardian, I don't know the platform dreamcast. You can say Xbox or PlayStation." {
+		t.Errorf("Unexpected response for an unknown platform: %s", text)
+	}
+
+	response = SetDefaultCharacter(newTestIntentRequest("SetDefaultCharacter", map[string]string{"Character": "vault"}))
+	if text := response.Response.OutputSpeech.Text; text != "Sorry Guardian, the vault can't be your default character. You can choose your titan, hunter, or warlock." {
+		t.Errorf("Unexpected response for the vault as the default character: %s", text)
+	}
+
+	response = SetVerbosity(newTestIntentRequest("SetVerbosity", map[string]string{"Verbosity": "loud"}))
+	if text := response.Response.OutputSpeech.Text; text != i18n.For(i18n.EnglishUS).Sprintf("preferences.unknown_verbosity") {
+		t.Errorf("Unexpected response for an unknown verbosity: %s", text)
+	}
+}
+
+func TestPreferencesNotSavedAfterLoadError(t *testing.T) {
+
+	failedLoad := func(request *Request) *Request {
+		request.withValue(preferencesErrorKey, errors.New("connection refused"))
+		return request
+	}
+	expected := i18n.For(i18n.EnglishUS).Sprintf("confirmations.save_error")
+
+	request := failedLoad(newTestIntentRequest("SetVerbosity", map[string]string{"Verbosity": "brief"}))
+	if text := SetVerbosity(request).Response.OutputSpeech.Text; text != expected {
+		t.Errorf("Unexpected response when the preferences could not be loaded: %s", text)
+	}
+	if verbosity := request.Preferences().Verbosity; verbosity != db.VerbosityNormal {
+		t.Errorf("Expected the preferences to be left alone, got verbosity %s", verbosity)
+	}
+
+	request = failedLoad(newTestIntentRequest("DisableConfirmations", nil))
+	if text := DisableConfirmations(request).Response.OutputSpeech.Text; text != expected {
+		t.Errorf("Unexpected response when the preferences could not be loaded: %s", text)
+	}
+	if !request.Preferences().RequireConfirmation {
+		t.Error("Expected confirmations to stay enabled when the preferences could not be loaded")
+	}
+}
+
+func TestTransferUsesDefaultCharacter(t *testing.T) {
+
+	SetSessionStore(NewMemorySessionStore(time.Minute))
+
+	request := newTestIntentRequest("TransferItem", nil)
+	request.Preferences().DefaultCharacter = "hunter"
+	response := TransferItem(request)
+	if text := response.Response.OutputSpeech.Text; text != "Which item would you like to transfer?" {
+		t.Errorf("Expected to be asked for the item, got: %s", text)
+	}
+
+	if session := GetSession("test-session"); classNameFromHash(session.DestinationClassHash) != "hunter" {
+		t.Errorf("Expected the default character to be the destination, got %+v", session)
+	}
+}
+
+func TestBriefWelcome(t *testing.T) {
+
+	request := newTestIntentRequest("AMAZON.StartOverIntent", nil)
+	request.Preferences().Verbosity = db.VerbosityBrief
+	if text := WelcomePrompt(request).Response.OutputSpeech.Text; text != "What would you like to do, Guardian?" {
+		t.Errorf("Unexpected brief welcome: %s", text)
+	}
+}
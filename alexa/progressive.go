@@ -0,0 +1,295 @@
+package alexa
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/mikeflynn/go-alexa/skillserver"
+)
+
+// BackgroundThreshold is how long a long running operation can take before the skill responds
+// and lets the operation finish in the background. Alexa will time out a request after 8 seconds.
+var BackgroundThreshold = 6 * time.Second
+
+// BackgroundJobTTL is how long the result of a background job is kept for the user's next request.
+// Jobs that are still running after this long are dropped too, so users that never make another
+// request are not kept forever.
+const BackgroundJobTTL = time.Hour
+
+const (
+	// DirectivesPath is the path of the Alexa directive service relative to the request's apiEndpoint
+	DirectivesPath = "/v1/directives"
+)
+
+// DirectiveClient is responsible for sending directives to the Alexa service while a request
+// is still being processed.
+type DirectiveClient interface {
+	// SendProgressiveResponse will ask Alexa to speak the text while the skill is still working.
+	SendProgressiveResponse(request *Request, speech string) error
+}
+
+// HTTPDirectiveClient sends directives to the Alexa directive service over HTTP.
+type HTTPDirectiveClient struct {
+	*http.Client
+	// BaseURL overrides the apiEndpoint provided with each request when it is not empty,
+	// this allows a local stand-in for the Alexa service to be used.
+	BaseURL string
+}
+
+type progressiveResponse struct {
+	Header struct {
+		RequestID string `json:"requestId"`
+	} `json:"header"`
+	Directive struct {
+		Type   string `json:"type"`
+		Speech string `json:"speech"`
+	} `json:"directive"`
+}
+
+var (
+	directives DirectiveClient = NewHTTPDirectiveClient(os.Getenv("ALEXA_API_ENDPOINT"))
+	jobs                       = newBackgroundJobs(BackgroundJobTTL)
+)
+
+// NewHTTPDirectiveClient will create a DirectiveClient that uses the apiEndpoint from each request, or
+// baseURL instead if it is not empty.
+func NewHTTPDirectiveClient(baseURL string) *HTTPDirectiveClient {
+	return &HTTPDirectiveClient{
+		Client:  &http.Client{Timeout: 2 * time.Second},
+		BaseURL: baseURL,
+	}
+}
+
+// SetDirectiveClient will replace the client used to send progressive responses.
+func SetDirectiveClient(client DirectiveClient) {
+	directives = client
+}
+
+// SendProgressiveResponse will send a VoicePlayer.Speak directive for the request.
+func (client *HTTPDirectiveClient) SendProgressiveResponse(request *Request, speech string) error {
+
+	endpoint := client.BaseURL
+	if endpoint == "" {
+		endpoint = request.APIEndpoint
+	}
+	if endpoint == "" || request.APIAccessToken == "" {
+		return errors.New("The request does not support progressive responses")
+	}
+
+	body := progressiveResponse{}
+	body.Header.RequestID = request.Request.RequestID
+	body.Directive.Type = "VoicePlayer.Speak"
+	body.Directive.Speech = speech
+
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, _ := http.NewRequest("POST", endpoint+DirectivesPath, bytes.NewReader(jsonBody))
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Authorization", "Bearer "+request.APIAccessToken)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Progressive response failed with status: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// backgroundJob is a long running operation that did not finish before the skill had to respond.
+type backgroundJob struct {
+	description string
+	done        bool
+	response    *skillserver.EchoResponse
+	// expires is when the job is dropped, it is reset when the job finishes
+	expires time.Time
+}
+
+// backgroundJobs holds the background jobs for each Alexa user until the results are reported or
+// the jobs expire.
+type backgroundJobs struct {
+	sync.Mutex
+	ttl    time.Duration
+	byUser map[string][]*backgroundJob
+}
+
+func newBackgroundJobs(ttl time.Duration) *backgroundJobs {
+	return &backgroundJobs{ttl: ttl, byUser: make(map[string][]*backgroundJob)}
+}
+
+func (j *backgroundJobs) add(userID string, job *backgroundJob) {
+	j.Lock()
+	defer j.Unlock()
+
+	now := time.Now()
+	j.removeExpired(now)
+	job.expires = now.Add(j.ttl)
+	j.byUser[userID] = append(j.byUser[userID], job)
+}
+
+func (j *backgroundJobs) finish(job *backgroundJob, response *skillserver.EchoResponse) {
+	j.Lock()
+	defer j.Unlock()
+
+	now := time.Now()
+	j.removeExpired(now)
+	job.done = true
+	job.response = response
+	job.expires = now.Add(j.ttl)
+}
+
+// removeExpired will drop the jobs that expired, along with the users that have no jobs left. The lock
+// must be held by the caller.
+func (j *backgroundJobs) removeExpired(now time.Time) {
+	for userID, userJobs := range j.byUser {
+		remaining := make([]*backgroundJob, 0, len(userJobs))
+		for _, job := range userJobs {
+			if now.Before(job.expires) {
+				remaining = append(remaining, job)
+			}
+		}
+
+		if len(remaining) == 0 {
+			delete(j.byUser, userID)
+		} else {
+			j.byUser[userID] = remaining
+		}
+	}
+}
+
+// clear will drop all of the jobs for the user, the results of jobs that are still running are discarded.
+func (j *backgroundJobs) clear(userID string) {
+	j.Lock()
+	defer j.Unlock()
+
+	delete(j.byUser, userID)
+}
+
+// takeCompleted will remove and return all of the completed jobs for the user.
+func (j *backgroundJobs) takeCompleted(userID string) []*backgroundJob {
+	j.Lock()
+	defer j.Unlock()
+
+	completed := make([]*backgroundJob, 0)
+	pending := make([]*backgroundJob, 0)
+	for _, job := range j.byUser[userID] {
+		if job.done {
+			completed = append(completed, job)
+		} else {
+			pending = append(pending, job)
+		}
+	}
+
+	if len(pending) == 0 {
+		delete(j.byUser, userID)
+	} else {
+		j.byUser[userID] = pending
+	}
+
+	return completed
+}
+
+// runLongOperation will send a progressive response with the provided speech, unless the user prefers brief
+// responses, and then run the operation.
+// The description and speech should already be localized for the request.
+// If the operation does not finish within the BackgroundThreshold, the skill responds right away and the
+// operation continues in the background. The result will be reported in a card on the user's next request.
+func runLongOperation(request *Request, description, progressSpeech string, operation func() *skillserver.EchoResponse) *skillserver.EchoResponse {
+
+	if !request.Preferences().Brief() {
+		client := directives
+		go func() {
+			err := client.SendProgressiveResponse(request, progressSpeech)
+			if err != nil {
+				fmt.Println("Failed to send progressive response: ", err.Error())
+			}
+		}()
+	}
+
+	job := &backgroundJob{description: description}
+	done := make(chan *skillserver.EchoResponse, 1)
+	go func() {
+		// The Recover middleware only covers the handler's goroutine, a panic here would stop the server
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Printf("Recovered from panic running %s: %v\n%s\n", description, r, debug.Stack())
+				response := skillserver.NewEchoResponse()
+				response.OutputSpeech(request.Localizer().Sprintf("error.unexpected"))
+				done <- response
+			}
+		}()
+
+		done <- operation()
+	}()
+
+	select {
+	case response := <-done:
+		return response
+	case <-time.After(request.backgroundThreshold()):
+	}
+
+	// The result is reported on a later request, so it must not change the display of this one
+	request.closeDisplay()
+
+	userID := request.GetUserID()
+	jobs.add(userID, job)
+	go func() {
+		jobs.finish(job, <-done)
+	}()
+
+	l := request.Localizer()
+	response := skillserver.NewEchoResponse()
+	response.OutputSpeech(l.Sprintf("background.still_working", description)).
+		SimpleCard(l.Sprintf("card.still_working.title"), l.Sprintf("card.still_working", description))
+
+	return response
+}
+
+// backgroundThreshold is how long a long running operation for the request can take before it is
+// moved to the background.
+func (request *Request) backgroundThreshold() time.Duration {
+	if request.BackgroundThreshold > 0 {
+		return request.BackgroundThreshold
+	}
+	return BackgroundThreshold
+}
+
+// AttachCompletedJobs will add a card describing any background jobs that finished since the user's
+// last request. The card is only added if the response does not already have one.
+func AttachCompletedJobs(request *Request, response *skillserver.EchoResponse) {
+
+	if response.Response.Card != nil {
+		return
+	}
+
+	completed := jobs.takeCompleted(request.GetUserID())
+	if len(completed) == 0 {
+		return
+	}
+
+	l := request.Localizer()
+	content := bytes.NewBufferString("")
+	for _, job := range completed {
+		content.WriteString(l.Sprintf("card.finished", job.description))
+		if job.response != nil && job.response.Response.OutputSpeech != nil {
+			content.WriteString(": " + job.response.Response.OutputSpeech.Text)
+		}
+		content.WriteString("\n")
+	}
+
+	response.SimpleCard(l.Sprintf("card.finished.title"), content.String())
+}
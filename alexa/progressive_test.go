@@ -0,0 +1,148 @@
+package alexa
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeflynn/go-alexa/skillserver"
+)
+
+const testEnvelope = `{
+	"version": "1.0",
+	"context": {"System": {"apiEndpoint": "https://api.amazonalexa.com", "apiAccessToken": "alexa-token",
+		"device": {"supportedInterfaces": {"Display": {"templateVersion": "1.0", "markupVersion": "1.0"}}}}},
+	"request": {"type": "IntentRequest", "requestId": "request-1", "locale": "en-GB"}
+}`
+
+func TestNewRequestReadsEnvelope(t *testing.T) {
+
+	echoRequest := &skillserver.EchoRequest{}
+	json.Unmarshal([]byte(testEnvelope), echoRequest)
+
+	request := NewRequest(echoRequest, []byte(testEnvelope))
+	if request.Locale != "en-GB" || request.APIEndpoint != "https://api.amazonalexa.com" ||
+		request.APIAccessToken != "alexa-token" || !request.SupportsDisplay {
+		t.Errorf("Unexpected envelope values: %+v", request)
+	}
+}
+
+func TestSendProgressiveResponse(t *testing.T) {
+
+	var received progressiveResponse
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != DirectivesPath {
+			t.Errorf("Unexpected directive path: %s", r.URL.Path)
+		}
+		authorization = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	request := newTestIntentRequest("EquipMaxLight", nil)
+	request.Request.RequestID = "request-1"
+	request.APIEndpoint = server.URL
+	request.APIAccessToken = "alexa-token"
+
+	err := NewHTTPDirectiveClient("").SendProgressiveResponse(request, "Working on it")
+	if err != nil {
+		t.Fatalf("Unexpected error sending progressive response: %s", err.Error())
+	}
+
+	if authorization != "Bearer alexa-token" {
+		t.Errorf("Unexpected authorization header: %s", authorization)
+	}
+	if received.Header.RequestID != "request-1" || received.Directive.Type != "VoicePlayer.Speak" ||
+		received.Directive.Speech != "Working on it" {
+		t.Errorf("Unexpected directive: %+v", received)
+	}
+}
+
+type nopDirectiveClient struct{}
+
+func (nopDirectiveClient) SendProgressiveResponse(*Request, string) error { return nil }
+
+func TestRunLongOperationInBackground(t *testing.T) {
+
+	SetDirectiveClient(nopDirectiveClient{})
+	defer SetDirectiveClient(NewHTTPDirectiveClient(""))
+
+	// The request's threshold is used instead of the much longer package default
+	request := newTestIntentRequest("UnloadEngrams", nil)
+	request.BackgroundThreshold = 10 * time.Millisecond
+	finish := make(chan struct{})
+	finished := make(chan struct{})
+	response := runLongOperation(request, "engram unload", "Working on it",
+		func() *skillserver.EchoResponse {
+			<-finish
+			defer close(finished)
+			return skillserver.NewEchoResponse().OutputSpeech("Moved 3 engrams.")
+		})
+
+	if response.Response.Card == nil || response.Response.Card.Title != "Still working" {
+		t.Fatalf("Expected a still working response, got %+v", response.Response)
+	}
+
+	close(finish)
+	<-finished
+
+	// The background job records the result shortly after the operation returns
+	next := skillserver.NewEchoResponse()
+	for i := 0; i < 100 && next.Response.Card == nil; i++ {
+		time.Sleep(5 * time.Millisecond)
+		AttachCompletedJobs(request, next)
+	}
+	if next.Response.Card == nil || !strings.Contains(next.Response.Card.Content, "Moved 3 engrams.") {
+		t.Errorf("Expected the completed job on the next response, got %+v", next.Response.Card)
+	}
+}
+
+func TestRunLongOperationRecoversFromPanic(t *testing.T) {
+
+	SetDirectiveClient(nopDirectiveClient{})
+	defer SetDirectiveClient(NewHTTPDirectiveClient(""))
+
+	request := newTestIntentRequest("TransferItem", nil)
+	response := runLongOperation(request, "transfer", "Working on it",
+		func() *skillserver.EchoResponse {
+			panic("transfer failed")
+		})
+
+	expected := request.Localizer().Sprintf("error.unexpected")
+	if response.Response.OutputSpeech == nil || response.Response.OutputSpeech.Text != expected {
+		t.Errorf("Expected the unexpected error response, got %+v", response.Response.OutputSpeech)
+	}
+}
+
+func TestBackgroundJobsExpire(t *testing.T) {
+
+	backgroundJobs := newBackgroundJobs(time.Minute)
+	finished := &backgroundJob{description: "max light loadout"}
+	backgroundJobs.add("old-user", finished)
+	backgroundJobs.finish(finished, skillserver.NewEchoResponse())
+	backgroundJobs.add("old-user", &backgroundJob{description: "engram unload"})
+	backgroundJobs.add("current-user", &backgroundJob{description: "transfer"})
+
+	backgroundJobs.removeExpired(time.Now().Add(30 * time.Second))
+	if len(backgroundJobs.byUser["old-user"]) != 2 || len(backgroundJobs.byUser["current-user"]) != 1 {
+		t.Fatalf("Expected jobs to be kept until they expire, got %v", backgroundJobs.byUser)
+	}
+
+	backgroundJobs.removeExpired(time.Now().Add(2 * time.Minute))
+	if len(backgroundJobs.byUser) != 0 {
+		t.Errorf("Expected expired jobs and their users to be removed, got %v", backgroundJobs.byUser)
+	}
+
+	// Adding a job removes the expired ones
+	finished.expires = time.Now().Add(-time.Second)
+	backgroundJobs.byUser["old-user"] = []*backgroundJob{finished}
+	backgroundJobs.add("current-user", &backgroundJob{description: "transfer"})
+	if _, ok := backgroundJobs.byUser["old-user"]; ok || len(backgroundJobs.byUser["current-user"]) != 1 {
+		t.Errorf("Expected the expired job to be removed when a job is added, got %v", backgroundJobs.byUser)
+	}
+}
@@ -0,0 +1,465 @@
+package alexa
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mikeflynn/go-alexa/skillserver"
+	"github.com/rking788/guardian-helper/bungie"
+	"github.com/rking788/guardian-helper/i18n"
+	"github.com/rking788/guardian-helper/trials"
+)
+
+// The render functions turn the results from the bungie and trials packages into the speech and
+// cards of an Alexa response in the language of the Localizer. The display functions build the
+// templates shown for the same results on devices with a screen.
+
+// renderItemCount will describe the quantity of the item on each character, or only the total for
+// brief responses. The card always has the full breakdown.
+func renderItemCount(l *i18n.Localizer, count *bungie.ItemCount, brief bool) *skillserver.EchoResponse {
+
+	response := skillserver.NewEchoResponse()
+	if count.IsFamily() {
+		return renderItemFamily(l, count)
+	} else if len(count.Locations) == 0 {
+		response.OutputSpeech(l.Sprintf("count.none", count.ItemName))
+		return response
+	}
+
+	outputString := ""
+	if brief {
+		outputString = l.Sprintf("count.total", count.Total(), count.ItemName)
+	} else {
+		for _, location := range count.Locations {
+			outputString += l.Sprintf("count.character", localizedClassName(l, location.CharacterClass),
+				location.Quantity, count.ItemName)
+		}
+	}
+
+	response.OutputSpeech(outputString).
+		StandardCard(strings.Title(count.ItemName), inventoryBreakdown(l, count.Locations), count.IconURL, count.IconURL)
+
+	return response
+}
+
+// renderItemFamily will describe the total of each item in the family in a single response.
+func renderItemFamily(l *i18n.Localizer, count *bungie.ItemCount) *skillserver.EchoResponse {
+
+	response := skillserver.NewEchoResponse()
+	if len(count.Family) == 0 {
+		response.OutputSpeech(l.Sprintf("count.family.none", count.ItemName))
+		return response
+	}
+
+	parts := make([]string, 0, len(count.Family))
+	for _, item := range count.Family {
+		parts = append(parts, l.Sprintf("count.family.entry", item.Quantity, item.ItemName))
+	}
+
+	response.OutputSpeech(l.Sprintf("count.family", l.List(parts)))
+	return response
+}
+
+// renderTransfer will describe how many of the item were transferred, along with a card listing
+// where the items were before the transfer.
+func renderTransfer(l *i18n.Localizer, result *bungie.TransferResult) *skillserver.EchoResponse {
+
+	response := skillserver.NewEchoResponse()
+	if len(result.Locations) == 0 {
+		response.OutputSpeech(l.Sprintf("count.none", result.ItemName))
+		return response
+	}
+
+	var output string
+	destinationName := localizedClassName(l, result.DestinationClass)
+	if result.Partial() {
+		output = l.Sprintf("transfer.partial", result.Transferred, result.ItemName, destinationName)
+	} else {
+		output = l.Sprintf("transfer.done", result.Transferred, result.ItemName, destinationName)
+	}
+
+	cardContent := l.Sprintf("card.transfer", result.Transferred, destinationName, inventoryBreakdown(l, result.Locations))
+	response.OutputSpeech(output).
+		StandardCard(l.Sprintf("card.transfer.title", strings.Title(result.ItemName)), cardContent, result.IconURL, result.IconURL)
+
+	return response
+}
+
+// renderLoadout will confirm the max light loadout was equipped, the card lists each item.
+func renderLoadout(l *i18n.Localizer, result *bungie.LoadoutResult) *skillserver.EchoResponse {
+
+	response := skillserver.NewEchoResponse()
+	characterClass := localizedClassName(l, result.CharacterClass)
+	response.OutputSpeech(l.Sprintf("maxlight.done", characterClass)).
+		StandardCard(l.Sprintf("card.maxlight.title", strings.Title(characterClass)), loadoutCardContent(l, result), result.IconURL, result.IconURL)
+
+	return response
+}
+
+// renderUnload will describe the engrams that were moved to the vault and any that did not fit.
+func renderUnload(l *i18n.Localizer, result *bungie.UnloadResult) *skillserver.EchoResponse {
+
+	response := skillserver.NewEchoResponse()
+	if len(result.Moved) == 0 && len(result.NotMoved) == 0 {
+		if result.Filtered {
+			response.OutputSpeech(l.Sprintf("unload.none_filtered"))
+		} else {
+			response.OutputSpeech(l.Sprintf("unload.none"))
+		}
+		return response
+	}
+
+	var output string
+	if len(result.Moved) > 0 {
+		description, total := describeEngramCounts(l, result.Moved)
+		output = l.Plural("unload.moved", total, description)
+	}
+	if len(result.NotMoved) > 0 {
+		description, _ := describeEngramCounts(l, result.NotMoved)
+		output += l.Sprintf("unload.not_moved", description)
+	}
+	output += l.Sprintf("unload.farming")
+
+	response.OutputSpeech(output)
+	return response
+}
+
+// renderCharacters will describe the class, race, light level, and the last time each character was
+// played. Brief responses only describe the most recently played character, the card has all of them.
+func renderCharacters(l *i18n.Localizer, characters []*bungie.CharacterDetails, brief bool, now time.Time) *skillserver.EchoResponse {
+
+	response := skillserver.NewEchoResponse()
+	if len(characters) == 0 {
+		response.OutputSpeech(l.Sprintf("characters.none"))
+		return response
+	}
+
+	speechBuffer := bytes.NewBufferString("")
+	cardBuffer := bytes.NewBufferString("")
+	for i, character := range characters {
+		description := describeCharacter(l, character)
+		lastPlayed := describeLastPlayed(l, character.LastPlayed, now)
+
+		if i == 0 || !brief {
+			speechBuffer.WriteString(l.Sprintf("characters.summary", description, character.Light, lastPlayed))
+		}
+		cardBuffer.WriteString(l.Sprintf("card.character", strings.Title(description), character.Light, lastPlayed))
+	}
+
+	response.OutputSpeech(speechBuffer.String()).
+		SimpleCard(l.Sprintf("card.characters.title"), strings.TrimSpace(cardBuffer.String()))
+
+	return response
+}
+
+// renderTrialsMap will describe the map for the current Trials of Osiris week.
+func renderTrialsMap(l *i18n.Localizer, currentMap *trials.MapSummary) *skillserver.EchoResponse {
+
+	response := skillserver.NewEchoResponse()
+	month := l.Name("month", currentMap.Start.Month().String())
+	response.OutputSpeech(l.Sprintf("trials.map", month, currentMap.Start.Day(), currentMap.Name)).
+		StandardCard(trials.TrialsCardTitle, l.Sprintf("card.trials.map", currentMap.Name, month, currentMap.Start.Day()), "", "")
+
+	return response
+}
+
+// renderTrialsWeek will describe the player's record for the current Trials of Osiris week.
+func renderTrialsWeek(l *i18n.Localizer, week *trials.WeekSummary) *skillserver.EchoResponse {
+
+	response := skillserver.NewEchoResponse()
+	if week.Matches == 0 {
+		response.OutputSpeech(l.Sprintf("trials.week.none"))
+		return response
+	}
+
+	response.OutputSpeech(l.Sprintf("trials.week", week.Matches, week.Wins, week.Losses, week.KD)).
+		StandardCard(trials.TrialsCardTitle, l.Sprintf("card.trials.week", week.Matches, week.Wins, week.Losses, week.KD), "", "")
+
+	return response
+}
+
+// renderPopularWeapons will list the most used weapons with their usage percentages, the card
+// shows the icon of the most popular weapon.
+func renderPopularWeapons(l *i18n.Localizer, weapons []*trials.PopularWeapon) *skillserver.EchoResponse {
+
+	response := skillserver.NewEchoResponse()
+	if len(weapons) == 0 {
+		response.OutputSpeech(l.Sprintf("trials.top_weapons.none"))
+		return response
+	}
+
+	names := make([]string, 0, len(weapons))
+	cardBuffer := bytes.NewBufferString("")
+	for i, weapon := range weapons {
+		names = append(names, l.Sprintf("trials.top_weapons.entry", weapon.Name, weapon.Percentage))
+		cardBuffer.WriteString(l.Sprintf("card.trials.usage", i+1, weapon.Name, weapon.Percentage) + "\n")
+	}
+
+	icon := weapons[0].IconURL
+	response.OutputSpeech(l.Sprintf("trials.top_weapons", l.List(names))).
+		StandardCard(l.Sprintf("card.trials.top_weapons"), strings.TrimSpace(cardBuffer.String()), icon, icon)
+
+	return response
+}
+
+// renderTopWeapons will list the player's most used weapons, the card includes the kills,
+// headshots, and matches for each one.
+func renderTopWeapons(l *i18n.Localizer, weapons []*trials.TopWeapon) *skillserver.EchoResponse {
+
+	response := skillserver.NewEchoResponse()
+	if len(weapons) == 0 {
+		response.OutputSpeech(l.Sprintf("trials.personal.none"))
+		return response
+	}
+
+	names := make([]string, 0, len(weapons))
+	cardBuffer := bytes.NewBufferString("")
+	icon := ""
+	for index, weapon := range weapons {
+		name := weapon.Name
+		if name == "" {
+			name = l.Sprintf("item.unknown")
+		}
+		if icon == "" {
+			icon = weapon.IconURL
+		}
+
+		names = append(names, name)
+		cardBuffer.WriteString(l.Sprintf("card.trials.personal.entry", index+1, name, weapon.Kills, weapon.Headshots, weapon.TotalMatches) + "\n")
+	}
+
+	response.OutputSpeech(l.Sprintf("trials.personal", l.List(names))).
+		StandardCard(l.Sprintf("card.trials.personal"), strings.TrimSpace(cardBuffer.String()), icon, icon)
+
+	return response
+}
+
+// renderWeaponTypes will name the two most popular primary and special weapon types, the card lists
+// the kills for every type.
+func renderWeaponTypes(l *i18n.Localizer, stats *trials.WeaponTypeStats) *skillserver.EchoResponse {
+
+	response := skillserver.NewEchoResponse()
+	cardBuffer := bytes.NewBufferString(l.Sprintf("card.trials.primaries") + "\n")
+	for _, weapon := range stats.Primaries {
+		cardBuffer.WriteString(l.Sprintf("card.trials.weapon_type", weapon.WeaponType, weapon.Kills) + "\n")
+	}
+	cardBuffer.WriteString("\n" + l.Sprintf("card.trials.specials") + "\n")
+	for _, weapon := range stats.Specials {
+		cardBuffer.WriteString(l.Sprintf("card.trials.weapon_type", weapon.WeaponType, weapon.Kills) + "\n")
+	}
+
+	response.OutputSpeech(l.Sprintf("trials.weapon_types", stats.Primaries[0].WeaponType, stats.Primaries[1].WeaponType,
+		stats.Specials[0].WeaponType, stats.Specials[1].WeaponType)).
+		StandardCard(l.Sprintf("card.trials.weapon_types"), strings.TrimSpace(cardBuffer.String()), "", "")
+
+	return response
+}
+
+// itemCountDisplay will list the quantity of the item in each location followed by the total, nil is
+// returned for item families and items the user does not have.
+func itemCountDisplay(l *i18n.Localizer, count *bungie.ItemCount) *RenderTemplate {
+
+	if count.IsFamily() || len(count.Locations) == 0 {
+		return nil
+	}
+
+	return NewListTemplate(strings.Title(count.ItemName), inventoryListItems(l, count.Locations, count.IconURL))
+}
+
+// transferDisplay will list where the items were before the transfer.
+func transferDisplay(l *i18n.Localizer, result *bungie.TransferResult) *RenderTemplate {
+
+	if len(result.Locations) == 0 {
+		return nil
+	}
+
+	title := l.Sprintf("card.transfer.title", strings.Title(result.ItemName))
+	return NewListTemplate(title, inventoryListItems(l, result.Locations, result.IconURL))
+}
+
+// loadoutDisplay will list the item and light in each slot of the loadout followed by the light
+// level of the whole loadout.
+func loadoutDisplay(l *i18n.Localizer, result *bungie.LoadoutResult) *RenderTemplate {
+
+	items := make([]*ListItem, 0, len(result.Slots)+1)
+	for _, slot := range result.Slots {
+		name := slot.ItemName
+		if name == "" {
+			name = l.Sprintf("item.unknown")
+		}
+		items = append(items, newListItem(len(items), l.Name("bucket", slot.Bucket.String()), l.Sprintf("display.slot", name, slot.Light)))
+	}
+	items = append(items, newListItem(len(items), l.Sprintf("card.maxlight.light", result.Light), ""))
+	if result.IconURL != "" {
+		items[0].Image = newDisplayImage(items[0].TextContent.PrimaryText.Text, result.IconURL)
+	}
+
+	title := l.Sprintf("card.maxlight.title", strings.Title(localizedClassName(l, result.CharacterClass)))
+	return NewListTemplate(title, items)
+}
+
+// popularWeaponsDisplay will list the most used weapons with their usage percentage and icon.
+func popularWeaponsDisplay(l *i18n.Localizer, weapons []*trials.PopularWeapon) *RenderTemplate {
+
+	if len(weapons) == 0 {
+		return nil
+	}
+
+	items := make([]*ListItem, 0, len(weapons))
+	for _, weapon := range weapons {
+		item := newListItem(len(items), weapon.Name, l.Sprintf("display.usage", weapon.Percentage))
+		if weapon.IconURL != "" {
+			item.Image = newDisplayImage(weapon.Name, weapon.IconURL)
+		}
+		items = append(items, item)
+	}
+
+	return NewListTemplate(l.Sprintf("card.trials.top_weapons"), items)
+}
+
+// topWeaponsDisplay will list the player's most used weapons with their kills, headshots, and matches.
+func topWeaponsDisplay(l *i18n.Localizer, weapons []*trials.TopWeapon) *RenderTemplate {
+
+	if len(weapons) == 0 {
+		return nil
+	}
+
+	items := make([]*ListItem, 0, len(weapons))
+	for _, weapon := range weapons {
+		name := weapon.Name
+		if name == "" {
+			name = l.Sprintf("item.unknown")
+		}
+		item := newListItem(len(items), name, l.Sprintf("display.top_weapon", weapon.Kills, weapon.Headshots, weapon.TotalMatches))
+		if weapon.IconURL != "" {
+			item.Image = newDisplayImage(name, weapon.IconURL)
+		}
+		items = append(items, item)
+	}
+
+	return NewListTemplate(l.Sprintf("card.trials.personal"), items)
+}
+
+// inventoryListItems will list the quantity of an item in each location followed by the total, the
+// item's icon is shown on the first entry.
+func inventoryListItems(l *i18n.Localizer, locations []*bungie.ItemLocation, iconURL string) []*ListItem {
+
+	items := make([]*ListItem, 0, len(locations)+1)
+	total := uint(0)
+	for _, location := range locations {
+		items = append(items, newListItem(len(items), strings.Title(localizedClassName(l, location.CharacterClass)),
+			strconv.FormatUint(uint64(location.Quantity), 10)))
+		total += location.Quantity
+	}
+	items = append(items, newListItem(len(items), l.Sprintf("card.inventory.total", total), ""))
+	if iconURL != "" {
+		items[0].Image = newDisplayImage(items[0].TextContent.PrimaryText.Text, iconURL)
+	}
+
+	return items
+}
+
+// localizedClassName will translate an English class name, or the vault.
+func localizedClassName(l *i18n.Localizer, className string) string {
+	return l.Name("class", strings.ToLower(className))
+}
+
+// inventoryBreakdown will describe the quantity of an item in each location, one location per line
+// with the total at the end.
+func inventoryBreakdown(l *i18n.Localizer, locations []*bungie.ItemLocation) string {
+
+	buffer := bytes.NewBufferString("")
+	total := uint(0)
+	for _, location := range locations {
+		buffer.WriteString(l.Sprintf("card.inventory.entry", strings.Title(localizedClassName(l, location.CharacterClass)), location.Quantity) + "\n")
+		total += location.Quantity
+	}
+	buffer.WriteString(l.Sprintf("card.inventory.total", total))
+
+	return buffer.String()
+}
+
+// loadoutCardContent will list the item and light for each slot in the loadout followed by the
+// light level of the whole loadout.
+func loadoutCardContent(l *i18n.Localizer, result *bungie.LoadoutResult) string {
+
+	buffer := bytes.NewBufferString("")
+	for _, slot := range result.Slots {
+		name := slot.ItemName
+		if name == "" {
+			name = l.Sprintf("item.unknown")
+		}
+		buffer.WriteString(l.Sprintf("card.maxlight.slot", l.Name("bucket", slot.Bucket.String()), name, slot.Light) + "\n")
+	}
+	buffer.WriteString(l.Sprintf("card.maxlight.light", result.Light))
+
+	return buffer.String()
+}
+
+// describeEngramCounts will describe the number of engrams of each tier along with the total number of
+// engrams, for example: "2 exotic engrams and 1 legendary engram"
+func describeEngramCounts(l *i18n.Localizer, counts []*bungie.EngramCount) (string, uint) {
+
+	total := uint(0)
+	phrases := make([]string, 0, len(counts))
+	for _, count := range counts {
+		total += count.Quantity
+		if count.TierName != "" {
+			phrases = append(phrases, l.Plural("engrams.tier", count.Quantity, count.Quantity, l.Name("tier", count.TierName)))
+		} else {
+			phrases = append(phrases, l.Plural("engrams", count.Quantity, count.Quantity))
+		}
+	}
+
+	return l.List(phrases), total
+}
+
+// describeCharacter will return a short spoken description of the character including the
+// race, gender, and class. For example: "awoken female warlock"
+func describeCharacter(l *i18n.Localizer, character *bungie.CharacterDetails) string {
+
+	if character.Race != "" && character.Gender != "" && character.Class != "" {
+		return l.Sprintf("character.describe", l.Name("race", character.Race), l.Name("gender", character.Gender), l.Name("class", character.Class))
+	}
+
+	parts := make([]string, 0, 3)
+	if character.Race != "" {
+		parts = append(parts, l.Name("race", character.Race))
+	}
+	if character.Gender != "" {
+		parts = append(parts, l.Name("gender", character.Gender))
+	}
+	if character.Class != "" {
+		parts = append(parts, l.Name("class", character.Class))
+	} else {
+		parts = append(parts, l.Name("class", "guardian"))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// describeLastPlayed will return a spoken description of how long ago lastPlayed was
+// relative to now. For example "today", "yesterday", or "3 days ago".
+func describeLastPlayed(l *i18n.Localizer, lastPlayed, now time.Time) string {
+
+	if lastPlayed.IsZero() {
+		return l.Sprintf("lastplayed.unknown")
+	}
+
+	elapsed := now.Sub(lastPlayed)
+	switch {
+	case elapsed < time.Hour:
+		return l.Sprintf("lastplayed.recent")
+	case elapsed < 24*time.Hour:
+		hours := int(elapsed.Hours())
+		return l.Plural("lastplayed.hours", uint(hours), hours)
+	case elapsed < 48*time.Hour:
+		return l.Sprintf("lastplayed.yesterday")
+	case elapsed < 60*24*time.Hour:
+		return l.Sprintf("lastplayed.days", int(elapsed.Hours()/24))
+	}
+
+	return l.Sprintf("lastplayed.months", int(elapsed.Hours()/(24*30)))
+}
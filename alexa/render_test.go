@@ -0,0 +1,262 @@
+package alexa
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rking788/guardian-helper/bungie"
+	"github.com/rking788/guardian-helper/i18n"
+	"github.com/rking788/guardian-helper/trials"
+)
+
+var english = i18n.For(i18n.EnglishUS)
+
+func TestRenderItemCount(t *testing.T) {
+
+	count := &bungie.ItemCount{
+		ItemName:  "strange coins",
+		Locations: []*bungie.ItemLocation{{CharacterClass: "titan", Quantity: 20}, {CharacterClass: "vault", Quantity: 200}},
+	}
+
+	response := renderItemCount(english, count, false)
+	if speech := response.Response.OutputSpeech.Text; speech != "Your titan has 20 strange coins. Your vault has 200 strange coins. " {
+		t.Errorf("Unexpected count speech: %s", speech)
+	}
+	if card := response.Response.Card; card == nil || card.Content != "Titan: 20\nVault: 200\nTotal: 220" {
+		t.Errorf("Unexpected count card: %+v", card)
+	}
+
+	response = renderItemCount(english, count, true)
+	if speech := response.Response.OutputSpeech.Text; speech != "You have 220 strange coins in total." {
+		t.Errorf("Unexpected brief count speech: %s", speech)
+	}
+
+	response = renderItemCount(english, &bungie.ItemCount{ItemName: "motes of light"}, false)
+	if speech := response.Response.OutputSpeech.Text; speech != "You don't have any motes of light on any of your characters." {
+		t.Errorf("Unexpected speech without any items: %s", speech)
+	}
+
+	family := &bungie.ItemCount{
+		ItemName: "planetary materials",
+		Family:   []*bungie.ItemTotal{{ItemName: "helium filaments", Quantity: 40}, {ItemName: "spinmetal", Quantity: 12}},
+	}
+	response = renderItemCount(english, family, false)
+	if speech := response.Response.OutputSpeech.Text; speech != "Across your characters and vault you have 40 helium filaments and 12 spinmetal." {
+		t.Errorf("Unexpected family speech: %s", speech)
+	}
+}
+
+func TestRenderTransfer(t *testing.T) {
+
+	result := &bungie.TransferResult{
+		ItemName:         "strange coins",
+		DestinationClass: "hunter",
+		Requested:        50,
+		Transferred:      30,
+		Locations:        []*bungie.ItemLocation{{CharacterClass: "titan", Quantity: 30}},
+	}
+
+	response := renderTransfer(english, result)
+	expected := "You only had 30 strange coins on other characters, all of it has been transferred to your hunter"
+	if speech := response.Response.OutputSpeech.Text; speech != expected {
+		t.Errorf("Unexpected partial transfer speech: %s", speech)
+	}
+}
+
+func TestItemCountDisplay(t *testing.T) {
+
+	count := &bungie.ItemCount{
+		ItemName:  "strange coins",
+		IconURL:   "https://www.bungie.net/icon.jpg",
+		Locations: []*bungie.ItemLocation{{CharacterClass: "titan", Quantity: 20}, {CharacterClass: "vault", Quantity: 200}},
+	}
+
+	template := itemCountDisplay(english, count).Template
+	if template.Title != "Strange Coins" || len(template.ListItems) != 3 {
+		t.Fatalf("Expected a list with 3 items, got %+v", template)
+	}
+	if item := template.ListItems[1]; item.TextContent.PrimaryText.Text != "Vault" || item.TextContent.SecondaryText.Text != "200" {
+		t.Errorf("Unexpected list item: %+v", item.TextContent)
+	}
+	if item := template.ListItems[2]; item.TextContent.PrimaryText.Text != "Total: 220" || item.TextContent.SecondaryText != nil {
+		t.Errorf("Unexpected total: %+v", item.TextContent)
+	}
+	if template.ListItems[0].Image == nil || template.ListItems[0].Image.Sources[0].URL != count.IconURL {
+		t.Errorf("Expected the item icon on the first item")
+	}
+
+	if display := itemCountDisplay(english, &bungie.ItemCount{ItemName: "motes of light"}); display != nil {
+		t.Errorf("Expected no template without any items, got %+v", display.Template)
+	}
+}
+
+func TestLoadoutDisplay(t *testing.T) {
+
+	result := &bungie.LoadoutResult{
+		CharacterClass: "warlock",
+		Light:          337.9,
+		Slots: []*bungie.LoadoutSlot{
+			{Bucket: bungie.Primary, ItemName: "Hawkmoon", Light: 340},
+			{Bucket: bungie.Heavy, Light: 350},
+		},
+	}
+
+	template := loadoutDisplay(english, result).Template
+	if template.Title != "Max Light Warlock" || len(template.ListItems) != 3 {
+		t.Fatalf("Expected a list with 3 items, got %+v", template)
+	}
+	if item := template.ListItems[0]; item.TextContent.PrimaryText.Text != "Primary" || item.TextContent.SecondaryText.Text != "Hawkmoon (340)" {
+		t.Errorf("Unexpected slot: %+v", item.TextContent)
+	}
+	if item := template.ListItems[1]; item.TextContent.SecondaryText.Text != english.Sprintf("item.unknown")+" (350)" {
+		t.Errorf("Expected the unknown item name for a slot without a name, got %+v", item.TextContent)
+	}
+	if item := template.ListItems[2]; item.TextContent.PrimaryText.Text != "Light: 337.9" {
+		t.Errorf("Unexpected loadout light: %+v", item.TextContent)
+	}
+}
+
+func TestPopularWeaponsDisplay(t *testing.T) {
+
+	weapons := []*trials.PopularWeapon{
+		{Name: "Hawkmoon", Percentage: 12.5, IconURL: "https://www.bungie.net/hawkmoon.jpg"},
+		{Name: "Eyasluna", Percentage: 8},
+	}
+
+	template := popularWeaponsDisplay(english, weapons).Template
+	if len(template.ListItems) != 2 {
+		t.Fatalf("Expected a list with 2 items, got %+v", template)
+	}
+	if item := template.ListItems[0]; item.TextContent.PrimaryText.Text != "Hawkmoon" || item.TextContent.SecondaryText.Text != "12.5%" ||
+		item.Image == nil || item.Image.Sources[0].URL != weapons[0].IconURL {
+		t.Errorf("Unexpected weapon: %+v", item)
+	}
+	if template.ListItems[1].Image != nil {
+		t.Error("Expected no image for a weapon without an icon")
+	}
+}
+
+func TestRenderPopularWeaponsEmpty(t *testing.T) {
+
+	response := renderPopularWeapons(english, []*trials.PopularWeapon{})
+	if text := response.Response.OutputSpeech.Text; text != english.Sprintf("trials.top_weapons.none") {
+		t.Errorf("Unexpected response without any weapons: %s", text)
+	}
+	if response.Response.Card != nil {
+		t.Errorf("Expected no card without any weapons, got %+v", response.Response.Card)
+	}
+	if popularWeaponsDisplay(english, nil) != nil {
+		t.Error("Expected no display without any weapons")
+	}
+}
+
+func TestRenderUnload(t *testing.T) {
+
+	result := &bungie.UnloadResult{
+		Moved:    []*bungie.EngramCount{{Tier: bungie.ExoticTier, TierName: "exotic", Quantity: 1}},
+		NotMoved: []*bungie.EngramCount{{Tier: bungie.RareTier, TierName: "rare", Quantity: 2}},
+	}
+
+	response := renderUnload(english, result)
+	expected := "All set Guardian, 1 exotic engram was moved to your vault. 2 rare engrams could not fit in your vault. Happy farming Guardian!"
+	if speech := response.Response.OutputSpeech.Text; speech != expected {
+		t.Errorf("Unexpected unload speech: %s", speech)
+	}
+
+	response = renderUnload(english, &bungie.UnloadResult{Filtered: true})
+	if speech := response.Response.OutputSpeech.Text; speech != english.Sprintf("unload.none_filtered") {
+		t.Errorf("Unexpected speech without engrams: %s", speech)
+	}
+}
+
+func TestRenderCharacters(t *testing.T) {
+
+	now := time.Date(2017, time.June, 10, 12, 0, 0, 0, time.UTC)
+	characters := []*bungie.CharacterDetails{
+		{Class: "warlock", Race: "awoken", Gender: "female", Light: 335, LastPlayed: now.Add(-10 * time.Minute)},
+		{Class: "titan", Light: 320, LastPlayed: now.Add(-30 * time.Hour)},
+	}
+
+	response := renderCharacters(english, characters, true, now)
+	expected := "Your awoken female warlock has a light level of 335 and was last played within the last hour. "
+	if speech := response.Response.OutputSpeech.Text; speech != expected {
+		t.Errorf("Unexpected brief character speech: %s", speech)
+	}
+	if card := response.Response.Card; card == nil || card.Content == "" {
+		t.Errorf("Expected a card with every character, got %+v", card)
+	}
+}
+
+func TestRenderTrialsWeek(t *testing.T) {
+
+	response := renderTrialsWeek(english, &trials.WeekSummary{})
+	if speech := response.Response.OutputSpeech.Text; speech != english.Sprintf("trials.week.none") {
+		t.Errorf("Unexpected speech without any matches: %s", speech)
+	}
+
+	response = renderTrialsWeek(english, &trials.WeekSummary{Matches: 9, Wins: 7, Losses: 2, KD: "1.5"})
+	if response.Response.Card == nil || response.Response.Card.Title != trials.TrialsCardTitle {
+		t.Errorf("Expected a Trials card for the week, got %+v", response.Response.Card)
+	}
+}
+
+func TestDescribeLastPlayed(t *testing.T) {
+
+	now := time.Date(2017, time.June, 10, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		lastPlayed time.Time
+		expected   string
+	}{
+		{time.Time{}, "at an unknown time"},
+		{now.Add(-10 * time.Minute), "within the last hour"},
+		{now.Add(-1 * time.Hour), "1 hour ago"},
+		{now.Add(-5 * time.Hour), "5 hours ago"},
+		{now.Add(-30 * time.Hour), "yesterday"},
+		{now.Add(-4 * 24 * time.Hour), "4 days ago"},
+		{now.Add(-90 * 24 * time.Hour), "3 months ago"},
+	}
+
+	for _, c := range cases {
+		if result := describeLastPlayed(english, c.lastPlayed, now); result != c.expected {
+			t.Errorf("Expected %s but got %s for %v", c.expected, result, c.lastPlayed)
+		}
+	}
+}
+
+func TestDescribeCharacter(t *testing.T) {
+
+	character := &bungie.CharacterDetails{Race: "awoken", Gender: "female", Class: "warlock"}
+	if result := describeCharacter(english, character); result != "awoken female warlock" {
+		t.Errorf("Unexpected character description: %s", result)
+	}
+	if result := describeCharacter(i18n.For(i18n.German), character); result != "Warlock, Erwachter, weiblich" {
+		t.Errorf("Unexpected German character description: %s", result)
+	}
+
+	character = &bungie.CharacterDetails{Class: "titan"}
+	if result := describeCharacter(english, character); result != "titan" {
+		t.Errorf("Unexpected character description for unknown race and gender: %s", result)
+	}
+}
+
+func TestDescribeEngramCounts(t *testing.T) {
+
+	counts := []*bungie.EngramCount{
+		{Tier: bungie.ExoticTier, TierName: "exotic", Quantity: 1},
+		{Tier: bungie.SuperiorTier, TierName: "legendary", Quantity: 3},
+	}
+	description, total := describeEngramCounts(english, counts)
+	if description != "1 exotic engram and 3 legendary engrams" || total != 4 {
+		t.Errorf("Unexpected engram description: %s (%d)", description, total)
+	}
+
+	description, _ = describeEngramCounts(i18n.For(i18n.German), counts)
+	if description != "1 exotisches Engramm und 3 legendäre Engramme" {
+		t.Errorf("Unexpected German engram description: %s", description)
+	}
+
+	description, total = describeEngramCounts(english, []*bungie.EngramCount{{Tier: bungie.UnknownTier, Quantity: 2}})
+	if description != "2 engrams" || total != 2 {
+		t.Errorf("Unexpected engram description for unknown tier: %s (%d)", description, total)
+	}
+}
@@ -0,0 +1,227 @@
+package alexa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mikeflynn/go-alexa/skillserver"
+	"github.com/rking788/guardian-helper/bungie"
+	"github.com/rking788/guardian-helper/db"
+	"github.com/rking788/guardian-helper/i18n"
+)
+
+type contextKey string
+
+const (
+	requestBodyKey      = contextKey("requestBody")
+	membershipKey       = contextKey("membership")
+	preferencesKey      = contextKey("preferences")
+	preferencesErrorKey = contextKey("preferencesError")
+)
+
+// Request wraps the EchoRequest decoded by skillserver along with the parts of the Alexa
+// request envelope that skillserver does not decode, like the locale and the credentials
+// used to call back into the Alexa service.
+type Request struct {
+	*skillserver.EchoRequest
+	Locale         string
+	APIEndpoint    string
+	APIAccessToken string
+	// SupportsDisplay is true when the device that sent the request has a screen
+	SupportsDisplay bool
+	// BackgroundThreshold overrides the package BackgroundThreshold when it is not zero, for
+	// platforms that time out sooner than Alexa.
+	BackgroundThreshold time.Duration
+
+	ctx         context.Context
+	directives  []interface{}
+	resolutions map[string]*ResolvedValue
+
+	// display is the template for devices with a screen, see SetDisplay
+	displayLock   sync.Mutex
+	display       *RenderTemplate
+	displayClosed bool
+}
+
+// ResolvedValue is the value that Alexa entity resolution matched for a slot. The ID is the
+// ID of the value in the slot type, for items this is the item hash.
+type ResolvedValue struct {
+	ID   string
+	Name string
+}
+
+// ResolutionMatch is the entity resolution status when the slot matched a value or synonym
+const ResolutionMatch = "ER_SUCCESS_MATCH"
+
+// requestEnvelope describes the additional fields read from the raw request body.
+type requestEnvelope struct {
+	Request struct {
+		Locale string `json:"locale"`
+		Intent struct {
+			Slots map[string]struct {
+				Resolutions struct {
+					ResolutionsPerAuthority []struct {
+						Status struct {
+							Code string `json:"code"`
+						} `json:"status"`
+						Values []struct {
+							Value struct {
+								ID   string `json:"id"`
+								Name string `json:"name"`
+							} `json:"value"`
+						} `json:"values"`
+					} `json:"resolutionsPerAuthority"`
+				} `json:"resolutions"`
+			} `json:"slots"`
+		} `json:"intent"`
+	} `json:"request"`
+	Context struct {
+		System struct {
+			APIEndpoint    string `json:"apiEndpoint"`
+			APIAccessToken string `json:"apiAccessToken"`
+			Device         struct {
+				SupportedInterfaces map[string]json.RawMessage `json:"supportedInterfaces"`
+			} `json:"device"`
+		} `json:"System"`
+	} `json:"context"`
+}
+
+// CaptureRequestBody is a middleware that keeps a copy of the raw request body in the request context
+// so the fields skillserver does not decode can be read later with RequestFromHTTP. This needs to run
+// before the skillserver request validation because that will consume the body.
+func CaptureRequestBody(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		http.Error(w, "Bad Request", http.StatusBadRequest)
+		return
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	next(w, r.WithContext(context.WithValue(r.Context(), requestBodyKey, body)))
+}
+
+// RequestFromHTTP will create a Request from the EchoRequest that skillserver decoded and the raw
+// body captured by CaptureRequestBody.
+func RequestFromHTTP(r *http.Request) *Request {
+
+	body, _ := r.Context().Value(requestBodyKey).([]byte)
+	request := NewRequest(skillserver.GetEchoRequest(r), body)
+	request.ctx = r.Context()
+
+	return request
+}
+
+// NewRequest will create a Request from the EchoRequest and the raw JSON body it was decoded from.
+// The body is optional, without it the additional envelope fields will be empty.
+func NewRequest(echoRequest *skillserver.EchoRequest, body []byte) *Request {
+
+	request := &Request{EchoRequest: echoRequest}
+	if len(body) == 0 {
+		return request
+	}
+
+	envelope := requestEnvelope{}
+	err := json.Unmarshal(body, &envelope)
+	if err != nil {
+		fmt.Println("Failed to decode the Alexa request envelope: ", err.Error())
+		return request
+	}
+
+	request.Locale = envelope.Request.Locale
+	request.APIEndpoint = envelope.Context.System.APIEndpoint
+	request.APIAccessToken = envelope.Context.System.APIAccessToken
+	_, request.SupportsDisplay = envelope.Context.System.Device.SupportedInterfaces["Display"]
+
+	for name, slot := range envelope.Request.Intent.Slots {
+		for _, authority := range slot.Resolutions.ResolutionsPerAuthority {
+			if authority.Status.Code == ResolutionMatch && len(authority.Values) > 0 {
+				if request.resolutions == nil {
+					request.resolutions = make(map[string]*ResolvedValue)
+				}
+				value := authority.Values[0].Value
+				request.resolutions[name] = &ResolvedValue{ID: value.ID, Name: value.Name}
+				break
+			}
+		}
+	}
+
+	return request
+}
+
+// AddDirective will add a directive, like Display.RenderTemplate, that will be sent in the response
+// to this request.
+func (request *Request) AddDirective(directive interface{}) {
+	request.directives = append(request.directives, directive)
+}
+
+// Directives are the directives that will be sent in the response to this request.
+func (request *Request) Directives() []interface{} {
+	return request.directives
+}
+
+// Localizer will format responses in the locale of the device that sent the request.
+func (request *Request) Localizer() *i18n.Localizer {
+	return i18n.For(request.Locale)
+}
+
+// Context is the context of the request, it carries the values added by the handler middleware.
+func (request *Request) Context() context.Context {
+	if request.ctx == nil {
+		return context.Background()
+	}
+	return request.ctx
+}
+
+// withValue will add a value to the request's context for the handlers later in the chain.
+func (request *Request) withValue(key contextKey, value interface{}) {
+	request.ctx = context.WithValue(request.Context(), key, value)
+}
+
+// Membership is the Destiny membership of the linked account, it is only available to handlers
+// wrapped with the ResolveMembership middleware.
+func (request *Request) Membership() *bungie.Membership {
+	membership, _ := request.Context().Value(membershipKey).(*bungie.Membership)
+	return membership
+}
+
+// Preferences are the saved preferences of the Alexa user that sent the request. They are loaded the
+// first time they are needed and kept for the rest of the request, the defaults are used if they
+// cannot be loaded.
+func (request *Request) Preferences() *db.UserPreferences {
+
+	if prefs, ok := request.Context().Value(preferencesKey).(*db.UserPreferences); ok {
+		return prefs
+	}
+
+	prefs, err := db.LoadUserPreferences(request.GetUserID())
+	if err != nil {
+		fmt.Println("Failed to load the user preferences: ", err.Error())
+		request.withValue(preferencesErrorKey, err)
+	}
+	request.withValue(preferencesKey, prefs)
+
+	return prefs
+}
+
+// savedPreferences will return the user's preferences along with the error from loading them. The
+// defaults returned after an error must not be saved or they would replace the user's settings.
+func (request *Request) savedPreferences() (*db.UserPreferences, error) {
+	prefs := request.Preferences()
+	err, _ := request.Context().Value(preferencesErrorKey).(error)
+	return prefs, err
+}
+
+// ResolvedSlotValue will return the value that Alexa entity resolution matched for the slot,
+// false is returned if the slot was not matched to a value.
+func (request *Request) ResolvedSlotValue(slotName string) (*ResolvedValue, bool) {
+	value, ok := request.resolutions[slotName]
+	return value, ok
+}
@@ -0,0 +1,267 @@
+package alexa
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/garyburd/redigo/redis"
+)
+
+const (
+	// DefaultSessionTTL is how long a session is kept after it was last saved if SESSION_TTL is not set.
+	// Alexa sessions are short lived so anything older than this is no longer useful.
+	DefaultSessionTTL = 30 * time.Minute
+)
+
+// Session is responsible for storing information related to a specific skill invocation.
+// A session will remain open if the LaunchRequest was received.
+type Session struct {
+	ID                   string
+	Action               string
+	ItemName             string
+	ItemHash             uint
+	DestinationClassHash int
+	SourceClassHash      int
+	Quantity             int
+	Tier                 uint
+	KeepTier             uint
+	// LastSpeech and LastReprompt are kept so the last response can be repeated
+	LastSpeech   string
+	LastReprompt string
+}
+
+// SessionStore is responsible for persisting sessions between requests. Sessions expire after a
+// period of time so abandoned sessions are not kept forever.
+type SessionStore interface {
+	// Get will load the session with the specified ID, nil is returned if the session does not exist.
+	Get(sessionID string) (*Session, error)
+	// Save will persist the session and reset its expiration.
+	Save(session *Session) error
+	// Clear will remove the session with the specified ID.
+	Clear(sessionID string) error
+}
+
+var (
+	sessions = NewSessionStoreFromEnv()
+)
+
+// NewSessionStoreFromEnv will create the SessionStore selected by the SESSION_STORE environment
+// variable, either "redis" or "memory". If it is not set, Redis will be used when REDIS_URL is set
+// and the in-memory store otherwise. SESSION_TTL can be used to override the DefaultSessionTTL.
+func NewSessionStoreFromEnv() SessionStore {
+
+	ttl := DefaultSessionTTL
+	if ttlStr := os.Getenv("SESSION_TTL"); ttlStr != "" {
+		parsed, err := time.ParseDuration(ttlStr)
+		if err != nil {
+			fmt.Printf("Invalid SESSION_TTL(%s), using the default: %s\n", ttlStr, err.Error())
+		} else if parsed < time.Second {
+			// Redis only stores expirations in whole seconds and rejects an expiration of zero
+			fmt.Printf("SESSION_TTL(%s) must be at least 1s, using the default\n", ttlStr)
+		} else {
+			ttl = parsed
+		}
+	}
+
+	storeType := os.Getenv("SESSION_STORE")
+	if storeType == "" && os.Getenv("REDIS_URL") != "" {
+		storeType = "redis"
+	}
+
+	if storeType == "redis" {
+		return NewRedisSessionStore(os.Getenv("REDIS_URL"), ttl)
+	}
+
+	return NewMemorySessionStore(ttl)
+}
+
+// SetSessionStore will replace the store used to persist sessions.
+func SetSessionStore(store SessionStore) {
+	sessions = store
+}
+
+// GetSession will attempt to read a session from the cache, if an existing one is not found, an empty session
+// will be created with the specified sessionID.
+func GetSession(sessionID string) *Session {
+
+	session, err := sessions.Get(sessionID)
+	if err != nil {
+		fmt.Println("Failed to load the session: ", err.Error())
+	}
+	if session == nil {
+		session = &Session{ID: sessionID}
+	}
+
+	return session
+}
+
+// SaveSession will persist the given session to the cache. This will allow support for long running
+// Alexa sessions that continually prompt the user for more information.
+func SaveSession(session *Session) {
+
+	err := sessions.Save(session)
+	if err != nil {
+		fmt.Println("Failed to save session: ", err.Error())
+	}
+}
+
+// ClearSession will remove the specified session from the local cache, this will be done
+// when the user completes a full request session.
+func ClearSession(sessionID string) {
+
+	err := sessions.Clear(sessionID)
+	if err != nil {
+		fmt.Println("Failed to delete the session: ", err.Error())
+	}
+}
+
+// RedisSessionStore is a SessionStore that keeps sessions in Redis as JSON strings. Redis expirations
+// are used to remove sessions that have not been saved within the TTL.
+type RedisSessionStore struct {
+	pool *redis.Pool
+	ttl  time.Duration
+}
+
+// NewRedisSessionStore will create a RedisSessionStore connected to the Redis server at the provided URL.
+func NewRedisSessionStore(addr string, ttl time.Duration) *RedisSessionStore {
+	return &RedisSessionStore{
+		pool: newRedisPool(addr),
+		ttl:  ttl,
+	}
+}
+
+func newRedisPool(addr string) *redis.Pool {
+	// 25 is the maximum number of active connections for the Heroku Redis free tier
+	return &redis.Pool{
+		MaxIdle:     3,
+		MaxActive:   25,
+		IdleTimeout: 240 * time.Second,
+		Dial:        func() (redis.Conn, error) { return redis.DialURL(addr) },
+	}
+}
+
+func sessionKey(sessionID string) string {
+	return fmt.Sprintf("sessions:%s", sessionID)
+}
+
+// Get will load the session from Redis, nil is returned if the key does not exist or has expired.
+func (store *RedisSessionStore) Get(sessionID string) (*Session, error) {
+
+	conn := store.pool.Get()
+	defer conn.Close()
+
+	reply, err := redis.String(conn.Do("GET", sessionKey(sessionID)))
+	if err == redis.ErrNil {
+		// NOTE: This is a normal situation, if the session is not stored in the cache, it will hit this condition.
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	session := &Session{}
+	err = json.Unmarshal([]byte(reply), session)
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// Save will store the session in Redis with an expiration of the store's TTL.
+func (store *RedisSessionStore) Save(session *Session) error {
+
+	conn := store.pool.Get()
+	defer conn.Close()
+
+	sessionBytes, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Do("SET", sessionKey(session.ID), string(sessionBytes), "EX", int(store.ttl.Seconds()))
+	return err
+}
+
+// Clear will delete the session from Redis.
+func (store *RedisSessionStore) Clear(sessionID string) error {
+
+	conn := store.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("DEL", sessionKey(sessionID))
+	return err
+}
+
+// MemorySessionStore is a SessionStore that keeps sessions in memory. This is useful for running the
+// skill locally and in tests, sessions are lost when the process exits.
+type MemorySessionStore struct {
+	sync.Mutex
+	ttl     time.Duration
+	entries map[string]*memorySessionEntry
+	// now is used to read the current time so expiration can be tested
+	now func() time.Time
+}
+
+type memorySessionEntry struct {
+	session Session
+	expires time.Time
+}
+
+// NewMemorySessionStore will create an empty MemorySessionStore.
+func NewMemorySessionStore(ttl time.Duration) *MemorySessionStore {
+	return &MemorySessionStore{
+		ttl:     ttl,
+		entries: make(map[string]*memorySessionEntry),
+		now:     time.Now,
+	}
+}
+
+// Get will return a copy of the stored session, nil is returned if it does not exist or has expired.
+func (store *MemorySessionStore) Get(sessionID string) (*Session, error) {
+	store.Lock()
+	defer store.Unlock()
+
+	entry, ok := store.entries[sessionID]
+	if !ok {
+		return nil, nil
+	} else if !store.now().Before(entry.expires) {
+		delete(store.entries, sessionID)
+		return nil, nil
+	}
+
+	session := entry.session
+	return &session, nil
+}
+
+// Save will store a copy of the session and reset its expiration. Expired sessions are removed
+// whenever a session is saved.
+func (store *MemorySessionStore) Save(session *Session) error {
+	store.Lock()
+	defer store.Unlock()
+
+	now := store.now()
+	for id, entry := range store.entries {
+		if !now.Before(entry.expires) {
+			delete(store.entries, id)
+		}
+	}
+
+	store.entries[session.ID] = &memorySessionEntry{
+		session: *session,
+		expires: now.Add(store.ttl),
+	}
+
+	return nil
+}
+
+// Clear will remove the session from memory.
+func (store *MemorySessionStore) Clear(sessionID string) error {
+	store.Lock()
+	defer store.Unlock()
+
+	delete(store.entries, sessionID)
+	return nil
+}
@@ -0,0 +1,129 @@
+package alexa
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mikeflynn/go-alexa/skillserver"
+	"github.com/rking788/guardian-helper/db"
+)
+
+func TestMemorySessionStore(t *testing.T) {
+
+	now := time.Date(2017, time.June, 10, 12, 0, 0, 0, time.UTC)
+	store := NewMemorySessionStore(time.Minute)
+	store.now = func() time.Time { return now }
+
+	session, err := store.Get("missing")
+	if session != nil || err != nil {
+		t.Fatalf("Expected no session for an unknown ID, got %+v (%v)", session, err)
+	}
+
+	store.Save(&Session{ID: "abc", ItemName: "spinmetal"})
+
+	session, _ = store.Get("abc")
+	if session == nil || session.ItemName != "spinmetal" {
+		t.Fatalf("Expected to load the saved session, got %+v", session)
+	}
+
+	// Modifying the loaded session should not change the stored copy until it is saved
+	session.ItemName = "relic iron"
+	if stored, _ := store.Get("abc"); stored.ItemName != "spinmetal" {
+		t.Errorf("Stored session was modified without being saved: %+v", stored)
+	}
+
+	now = now.Add(59 * time.Second)
+	if stored, _ := store.Get("abc"); stored == nil {
+		t.Error("Session expired before the TTL")
+	}
+
+	now = now.Add(time.Second)
+	if stored, _ := store.Get("abc"); stored != nil {
+		t.Errorf("Expected the session to expire after the TTL, got %+v", stored)
+	}
+
+	store.Save(&Session{ID: "def"})
+	store.Clear("def")
+	if stored, _ := store.Get("def"); stored != nil {
+		t.Errorf("Expected the session to be cleared, got %+v", stored)
+	}
+}
+
+func TestSessionTTLFromEnv(t *testing.T) {
+
+	defer os.Unsetenv("SESSION_TTL")
+	defer os.Unsetenv("SESSION_STORE")
+	os.Setenv("SESSION_STORE", "memory")
+
+	tests := []struct {
+		value string
+		ttl   time.Duration
+	}{
+		{"", DefaultSessionTTL},
+		{"10m", 10 * time.Minute},
+		{"ten minutes", DefaultSessionTTL},
+		{"500ms", DefaultSessionTTL},
+		{"-5m", DefaultSessionTTL},
+	}
+
+	for _, test := range tests {
+		os.Setenv("SESSION_TTL", test.value)
+		store, ok := NewSessionStoreFromEnv().(*MemorySessionStore)
+		if !ok || store.ttl != test.ttl {
+			t.Errorf("Expected a TTL of %s for SESSION_TTL(%s), got %+v", test.ttl, test.value, store)
+		}
+	}
+}
+
+func TestTransferItemPromptsForMissingValues(t *testing.T) {
+
+	SetSessionStore(NewMemorySessionStore(time.Minute))
+
+	request := newTestIntentRequest("TransferItem", map[string]string{"Item": "Motes"})
+	response := TransferItem(request)
+	if response.Response.ShouldEndSession {
+		t.Error("Expected the session to stay open for the destination")
+	}
+	if text := response.Response.OutputSpeech.Text; text != "Which character should get your motes?" {
+		t.Errorf("Unexpected destination prompt: %s", text)
+	}
+
+	session := GetSession(request.GetSessionID())
+	if session.Action != TransferItemAction || session.ItemName != "motes" {
+		t.Errorf("Expected the partial transfer to be kept in the session, got %+v", session)
+	}
+}
+
+func TestCountItemPromptsForItem(t *testing.T) {
+
+	SetSessionStore(NewMemorySessionStore(time.Minute))
+
+	request := newTestIntentRequest("CountItem", nil)
+	response := CountItem(request)
+	if response.Response.ShouldEndSession {
+		t.Error("Expected the session to stay open for the item name")
+	}
+
+	session := GetSession(request.GetSessionID())
+	if session.Action != CountItemAction {
+		t.Errorf("Expected the count action to be saved in the session, got %+v", session)
+	}
+}
+
+func newTestIntentRequest(intent string, slots map[string]string) *Request {
+
+	request := &Request{EchoRequest: &skillserver.EchoRequest{}}
+	request.withValue(preferencesKey, db.DefaultUserPreferences("test-user"))
+	request.Session.SessionID = "test-session"
+	request.Session.User.UserID = "test-user"
+	request.Session.User.AccessToken = "test-token"
+	request.Request.Type = "IntentRequest"
+	request.Request.Intent.Name = intent
+	request.Request.Intent.Slots = make(map[string]skillserver.EchoSlot)
+	for name, value := range slots {
+		request.Request.Intent.Slots[name] = skillserver.EchoSlot{Name: name, Value: value}
+	}
+
+	return request
+}
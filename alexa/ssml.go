@@ -0,0 +1,175 @@
+package alexa
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mikeflynn/go-alexa/skillserver"
+	"github.com/rking788/guardian-helper/db"
+)
+
+const (
+	// ListPause is the strength of the pause added between the entries in a spoken list.
+	ListPause = "medium"
+)
+
+const numberPattern = `\b\d+(?:\.\d+)?\b`
+
+// lexicon holds the phoneme overrides for words Alexa mispronounces, the pattern matches any of the
+// words in the lexicon or a number so the speech can be rewritten in a single pass.
+var lexicon = struct {
+	sync.RWMutex
+	words   map[string]*db.Pronunciation
+	pattern *regexp.Regexp
+}{
+	words:   make(map[string]*db.Pronunciation),
+	pattern: regexp.MustCompile(numberPattern),
+}
+
+// PopulatePronunciations will load the pronunciation lexicon from the database, replacing any
+// pronunciations that were previously loaded.
+func PopulatePronunciations() error {
+
+	pronunciations, err := db.LoadPronunciations()
+	if err != nil {
+		fmt.Println("Error loading pronunciations: ", err.Error())
+		return err
+	}
+
+	setLexicon(pronunciations)
+	fmt.Printf("Loaded %d pronunciations\n", len(pronunciations))
+
+	return nil
+}
+
+func setLexicon(pronunciations []*db.Pronunciation) {
+
+	words := make(map[string]*db.Pronunciation)
+	alternatives := make([]string, 0, len(pronunciations))
+	for _, pronunciation := range pronunciations {
+		word := strings.ToLower(pronunciation.Word)
+		words[word] = pronunciation
+		alternatives = append(alternatives, regexp.QuoteMeta(word))
+	}
+
+	// Longer words first so multi-word entries win over any single words they contain
+	sort.Slice(alternatives, func(i, j int) bool {
+		return len(alternatives[i]) > len(alternatives[j])
+	})
+
+	expr := numberPattern
+	if len(alternatives) > 0 {
+		expr = `(?i)\b(?:` + strings.Join(alternatives, "|") + `)\b|` + numberPattern
+	}
+	pattern := regexp.MustCompile(expr)
+
+	lexicon.Lock()
+	lexicon.words = words
+	lexicon.pattern = pattern
+	lexicon.Unlock()
+}
+
+// SSML is a builder for speech output using the Speech Synthesis Markup Language. Text added to the
+// builder will use the pronunciation lexicon and numbers will be emphasized.
+type SSML struct {
+	buffer bytes.Buffer
+}
+
+// NewSSML creates an empty SSML builder.
+func NewSSML() *SSML {
+	return &SSML{}
+}
+
+// Text will add the text to the speech, replacing words in the lexicon with their phonemes and
+// emphasizing numbers. The text will be escaped so it does not need to be valid SSML.
+func (ssml *SSML) Text(text string) *SSML {
+
+	lexicon.RLock()
+	defer lexicon.RUnlock()
+
+	// Matches are found in the raw text so escaped entities are never mistaken for numbers
+	last := 0
+	for _, match := range lexicon.pattern.FindAllStringIndex(text, -1) {
+		ssml.buffer.WriteString(html.EscapeString(text[last:match[0]]))
+
+		word := text[match[0]:match[1]]
+		if pronunciation, ok := lexicon.words[strings.ToLower(word)]; ok {
+			ssml.buffer.WriteString(fmt.Sprintf(`<phoneme alphabet="%s" ph="%s">%s</phoneme>`,
+				pronunciation.Alphabet, html.EscapeString(pronunciation.Phoneme), html.EscapeString(word)))
+		} else {
+			ssml.buffer.WriteString(`<emphasis level="moderate">` + word + `</emphasis>`)
+		}
+		last = match[1]
+	}
+	ssml.buffer.WriteString(html.EscapeString(text[last:]))
+
+	return ssml
+}
+
+// Pause will add a break with the provided strength (none, x-weak, weak, medium, strong, or x-strong).
+func (ssml *SSML) Pause(strength string) *SSML {
+	ssml.buffer.WriteString(`<break strength="` + strength + `"/>`)
+	return ssml
+}
+
+// List will add each of the entries with a pause between them and "and" before the last entry.
+func (ssml *SSML) List(entries []string) *SSML {
+
+	for i, entry := range entries {
+		if i > 0 {
+			ssml.Pause(ListPause)
+			if i == len(entries)-1 {
+				ssml.buffer.WriteString("and ")
+			}
+		}
+		ssml.Text(entry)
+	}
+
+	return ssml
+}
+
+// String will return the complete speech wrapped in a speak element.
+func (ssml *SSML) String() string {
+	return "<speak>" + ssml.buffer.String() + "</speak>"
+}
+
+// textToSSML will convert plain text speech to SSML. Lists in plain text are separated by commas,
+// so a pause is added after each comma.
+func textToSSML(text string) string {
+
+	ssml := NewSSML()
+	parts := strings.Split(text, ", ")
+	for i, part := range parts {
+		if i > 0 {
+			ssml.buffer.WriteString(",")
+			ssml.Pause(ListPause)
+		}
+		ssml.Text(part)
+	}
+
+	return ssml.String()
+}
+
+// ConvertToSSML will convert any plain text output speech or reprompt in the response to SSML so
+// the pronunciation lexicon is applied to all responses.
+func ConvertToSSML(response *skillserver.EchoResponse) {
+
+	speech := response.Response.OutputSpeech
+	if speech != nil && speech.Type == "PlainText" {
+		response.OutputSpeechSSML(textToSSML(speech.Text))
+	}
+
+	// The reprompt is set directly because the skillserver RepromptSSML puts the SSML in the text field
+	reprompt := response.Response.Reprompt
+	if reprompt != nil && reprompt.OutputSpeech.Type == "PlainText" {
+		reprompt.OutputSpeech = skillserver.EchoRespPayload{
+			Type: "SSML",
+			SSML: textToSSML(reprompt.OutputSpeech.Text),
+		}
+	}
+}
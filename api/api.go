@@ -0,0 +1,531 @@
+// Package api implements a JSON REST API for web and mobile clients. Requests are authenticated with
+// the user's Bungie.net OAuth access token sent as a bearer token, and are answered with the same
+// bungie and trials functions used by the Alexa skill.
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rking788/guardian-helper/bungie"
+	"github.com/rking788/guardian-helper/db"
+	"github.com/rking788/guardian-helper/i18n"
+	"github.com/rking788/guardian-helper/trials"
+)
+
+// accessTokenKey is the key of the Bungie.net access token in the gin context
+const accessTokenKey = "accessToken"
+
+// TransferRequest is the body of a request to transfer an item. Either the item name, in the language
+// of the locale, or the item hash is required. A quantity of zero will transfer all of the item.
+type TransferRequest struct {
+	Item        string `json:"item"`
+	ItemHash    uint   `json:"item_hash"`
+	Quantity    int    `json:"quantity"`
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+}
+
+// SaveLoadoutRequest is the body of a request to save the equipped items as a loadout.
+type SaveLoadoutRequest struct {
+	Name string `json:"name"`
+}
+
+// ErrorResponse is the body of every unsuccessful response.
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// loadMembership will find the Destiny membership for the access token, it is a variable so tests
+// can avoid calling Bungie.net.
+var loadMembership = func(accessToken string, platform uint) (*bungie.Membership, error) {
+	client := bungie.NewClient(accessToken, os.Getenv("BUNGIE_API_KEY"))
+	client.MembershipType = platform
+	return client.GetCurrentMembership()
+}
+
+// NewRouter will create the handler for all of the endpoints under /api/. The Trials of Osiris
+// endpoints that are not specific to a player do not require an access token.
+func NewRouter() *gin.Engine {
+
+	router := gin.New()
+	router.Use(gin.Recovery())
+
+	authenticated := router.Group("/api", RequireAccessToken)
+	authenticated.GET("/inventory", Inventory)
+	authenticated.GET("/characters", Characters)
+	authenticated.GET("/items/:name/count", CountItem)
+	authenticated.POST("/transfers", TransferItem)
+	authenticated.GET("/maxlight", PlanMaxLight)
+	authenticated.POST("/maxlight", EquipMaxLight)
+	authenticated.GET("/engrams", PlanUnloadEngrams)
+	authenticated.POST("/engrams/unload", UnloadEngrams)
+	authenticated.GET("/loadouts", SavedLoadouts)
+	authenticated.POST("/loadouts", SaveLoadout)
+	authenticated.POST("/loadouts/:name/equip", EquipSavedLoadout)
+	authenticated.GET("/trials/week", TrialsWeek)
+	authenticated.GET("/trials/weapons/mine", TrialsPersonalWeapons)
+
+	public := router.Group("/api/trials")
+	public.GET("/map", TrialsMap)
+	public.GET("/weapons", TrialsPopularWeapons)
+	public.GET("/weapon-types", TrialsWeaponTypes)
+
+	return router
+}
+
+// RequireAccessToken will reject requests without a bearer token in the Authorization header. The
+// token is not checked here, Bungie.net will reject it if it is invalid or expired.
+func RequireAccessToken(c *gin.Context) {
+
+	header := c.Request.Header.Get("Authorization")
+	token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	if !strings.HasPrefix(header, "Bearer ") || token == "" {
+		c.Abort()
+		c.JSON(http.StatusUnauthorized, &ErrorResponse{Error: "A Bungie.net access token is required as a bearer token"})
+		return
+	}
+
+	c.Set(accessTokenKey, token)
+	c.Next()
+}
+
+// Inventory will list every item on the user's characters and in the vault.
+func Inventory(c *gin.Context) {
+
+	prefs, ok := preferences(c)
+	if !ok {
+		return
+	}
+
+	inventory, err := bungie.GetInventory(accessToken(c), prefs)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, inventory)
+}
+
+// Characters will list the user's characters, most recently played first.
+func Characters(c *gin.Context) {
+
+	prefs, ok := preferences(c)
+	if !ok {
+		return
+	}
+
+	characters, err := bungie.CharacterSummary(accessToken(c), prefs)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, characters)
+}
+
+// CountItem will count the item on each character and in the vault. The name in the path is in the
+// language of the locale and can also be an item family like "planetary materials".
+func CountItem(c *gin.Context) {
+
+	prefs, ok := preferences(c)
+	if !ok {
+		return
+	}
+
+	itemName := strings.ToLower(c.Param("name"))
+	count, err := bungie.CountItem(itemName, 0, accessToken(c), locale(c), prefs)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, count)
+}
+
+// TransferItem will transfer the item in the TransferRequest body to the destination character or
+// the vault.
+func TransferItem(c *gin.Context) {
+
+	prefs, ok := preferences(c)
+	if !ok {
+		return
+	}
+
+	transfer := &TransferRequest{}
+	err := json.NewDecoder(c.Request.Body).Decode(transfer)
+	if err != nil {
+		writeBadRequest(c, "The body must be a JSON transfer request")
+		return
+	}
+
+	destination := strings.ToLower(transfer.Destination)
+	if destination == "" {
+		destination = prefs.DefaultCharacter
+	}
+	if transfer.Item == "" && transfer.ItemHash == 0 {
+		writeBadRequest(c, "Either item or item_hash is required")
+		return
+	} else if !isCharacterName(destination) {
+		writeBadRequest(c, "destination must be titan, hunter, warlock, or vault")
+		return
+	} else if transfer.Source != "" && !isCharacterName(strings.ToLower(transfer.Source)) {
+		writeBadRequest(c, "source must be titan, hunter, warlock, or vault")
+		return
+	} else if transfer.Quantity < 0 {
+		writeBadRequest(c, "quantity must not be negative")
+		return
+	}
+
+	count := transfer.Quantity
+	if count == 0 {
+		count = -1
+	}
+
+	result, err := bungie.TransferItem(strings.ToLower(transfer.Item), transfer.ItemHash, accessToken(c),
+		strings.ToLower(transfer.Source), destination, count, locale(c), prefs)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// PlanMaxLight will describe the changes needed to equip the max light loadout without moving anything.
+func PlanMaxLight(c *gin.Context) {
+
+	prefs, ok := preferences(c)
+	if !ok {
+		return
+	}
+
+	plan, err := bungie.PlanMaxLight(accessToken(c), locale(c), prefs)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// EquipMaxLight will equip the max light loadout on the character query parameter, or the most
+// recently played character.
+func EquipMaxLight(c *gin.Context) {
+
+	prefs, ok := preferences(c)
+	if !ok {
+		return
+	}
+
+	loadout, err := bungie.EquipMaxLightGear(accessToken(c), locale(c), prefs)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, loadout)
+}
+
+// PlanUnloadEngrams will count the engrams that would be moved to the vault, the tier and keep_tier
+// query parameters work the same as for UnloadEngrams.
+func PlanUnloadEngrams(c *gin.Context) {
+
+	prefs, ok := preferences(c)
+	if !ok {
+		return
+	}
+	onlyTier, keepTier, ok := engramTiers(c)
+	if !ok {
+		return
+	}
+
+	count, err := bungie.PlanUnloadEngrams(accessToken(c), onlyTier, keepTier, prefs)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"count": count})
+}
+
+// UnloadEngrams will move the engrams on all characters to the vault. The tier query parameter will
+// only move engrams of that tier and keep_tier will leave engrams of that tier on the characters.
+func UnloadEngrams(c *gin.Context) {
+
+	prefs, ok := preferences(c)
+	if !ok {
+		return
+	}
+	onlyTier, keepTier, ok := engramTiers(c)
+	if !ok {
+		return
+	}
+
+	result, err := bungie.UnloadEngrams(accessToken(c), onlyTier, keepTier, prefs)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// SavedLoadouts will list the loadouts saved for the user's Destiny account.
+func SavedLoadouts(c *gin.Context) {
+
+	prefs, ok := preferences(c)
+	if !ok {
+		return
+	}
+
+	saved, err := bungie.SavedLoadouts(accessToken(c), prefs)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, saved)
+}
+
+// SaveLoadout will save the items equipped on the character query parameter, or the most recently
+// played character, with the name in the SaveLoadoutRequest body.
+func SaveLoadout(c *gin.Context) {
+
+	prefs, ok := preferences(c)
+	if !ok {
+		return
+	}
+
+	request := &SaveLoadoutRequest{}
+	err := json.NewDecoder(c.Request.Body).Decode(request)
+	if err != nil {
+		writeBadRequest(c, "The body must be a JSON save loadout request")
+		return
+	} else if bungie.LoadoutName(request.Name) == "" {
+		writeBadRequest(c, "name is required")
+		return
+	}
+
+	saved, err := bungie.SaveLoadout(accessToken(c), request.Name, prefs)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, saved)
+}
+
+// EquipSavedLoadout will equip the saved loadout with the name in the path on the character query
+// parameter, or the most recently played character.
+func EquipSavedLoadout(c *gin.Context) {
+
+	prefs, ok := preferences(c)
+	if !ok {
+		return
+	}
+
+	loadout, err := bungie.EquipSavedLoadout(accessToken(c), c.Param("name"), locale(c), prefs)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, loadout)
+}
+
+// TrialsMap will respond with the map for the current Trials of Osiris week.
+func TrialsMap(c *gin.Context) {
+
+	currentMap, err := trials.GetCurrentMap()
+	if err != nil {
+		writeTrialsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, currentMap)
+}
+
+// TrialsWeek will respond with the user's record for the current Trials of Osiris week.
+func TrialsWeek(c *gin.Context) {
+
+	membership, ok := membership(c)
+	if !ok {
+		return
+	}
+
+	week, err := trials.GetCurrentWeek(membership.MembershipID)
+	if err != nil {
+		writeTrialsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, week)
+}
+
+// TrialsPopularWeapons will respond with the most used weapons by all players this week.
+func TrialsPopularWeapons(c *gin.Context) {
+
+	weapons, err := trials.GetWeaponUsagePercentages()
+	if err != nil {
+		writeTrialsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, weapons)
+}
+
+// TrialsPersonalWeapons will respond with the weapons the user has used the most in Trials of Osiris.
+func TrialsPersonalWeapons(c *gin.Context) {
+
+	membership, ok := membership(c)
+	if !ok {
+		return
+	}
+
+	weapons, err := trials.GetPersonalTopWeapons(membership.MembershipID, locale(c))
+	if err != nil {
+		writeTrialsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, weapons)
+}
+
+// TrialsWeaponTypes will respond with the kills for each primary and special weapon type this week.
+func TrialsWeaponTypes(c *gin.Context) {
+
+	stats, err := trials.GetPopularWeaponTypes()
+	if err != nil {
+		writeTrialsError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func accessToken(c *gin.Context) string {
+	return c.MustGet(accessTokenKey).(string)
+}
+
+// locale is the locale query parameter, or the first language in the Accept-Language header. Item
+// names in requests and responses are in the language of the locale.
+func locale(c *gin.Context) string {
+
+	if value := c.Query("locale"); value != "" {
+		return i18n.For(value).Locale()
+	}
+
+	accepted := strings.SplitN(c.Request.Header.Get("Accept-Language"), ",", 2)[0]
+	return i18n.For(strings.TrimSpace(strings.SplitN(accepted, ";", 2)[0])).Locale()
+}
+
+// preferences will build the preferences for the request from the optional platform and character
+// query parameters. API requests are not tied to an Alexa user so nothing is loaded from the
+// database. A bad request response is written if either parameter is unknown.
+func preferences(c *gin.Context) (*db.UserPreferences, bool) {
+
+	prefs := db.DefaultUserPreferences("")
+	if platformName := c.Query("platform"); platformName != "" {
+		platform, ok := bungie.PlatformFromName(platformName)
+		if !ok {
+			writeBadRequest(c, "platform must be xbox or playstation")
+			return nil, false
+		}
+		prefs.Platform = platform
+	}
+
+	if character := strings.ToLower(c.Query("character")); character != "" {
+		if _, ok := bungie.ClassHashFromName(character); !ok {
+			writeBadRequest(c, "character must be titan, hunter, or warlock")
+			return nil, false
+		}
+		prefs.DefaultCharacter = character
+	}
+
+	return prefs, true
+}
+
+// membership will load the Destiny membership for the access token on the requested platform, the
+// error response is written if it cannot be loaded.
+func membership(c *gin.Context) (*bungie.Membership, bool) {
+
+	prefs, ok := preferences(c)
+	if !ok {
+		return nil, false
+	}
+
+	membership, err := loadMembership(accessToken(c), prefs.Platform)
+	if err != nil {
+		writeError(c, err)
+		return nil, false
+	}
+
+	return membership, true
+}
+
+// engramTiers will read the tier and keep_tier query parameters, UnknownTier is used for either one
+// that is missing.
+func engramTiers(c *gin.Context) (uint, uint, bool) {
+
+	tiers := [2]uint{bungie.UnknownTier, bungie.UnknownTier}
+	for i, param := range []string{"tier", "keep_tier"} {
+		name := strings.ToLower(c.Query(param))
+		if name == "" {
+			continue
+		}
+
+		tier, ok := bungie.TierTypeFromName(name)
+		if !ok {
+			writeBadRequest(c, param+" must be uncommon, rare, legendary, or exotic")
+			return 0, 0, false
+		}
+		tiers[i] = tier
+	}
+
+	return tiers[0], tiers[1], true
+}
+
+func isCharacterName(name string) bool {
+	_, ok := bungie.ClassHashFromName(name)
+	return ok || name == "vault"
+}
+
+// errorStatus is the HTTP status for an error returned by the bungie package.
+func errorStatus(err error) int {
+
+	switch err {
+	case bungie.ErrAuthorization:
+		return http.StatusUnauthorized
+	case bungie.ErrNoDestinyAccount, bungie.ErrUnknownItem, bungie.ErrCharacterNotFound,
+		bungie.ErrLoadoutNotFound, bungie.ErrLoadoutItemsMissing:
+		return http.StatusNotFound
+	case bungie.ErrThrottled:
+		return http.StatusTooManyRequests
+	case bungie.ErrUnavailable:
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusBadGateway
+}
+
+func writeError(c *gin.Context, err error) {
+
+	status := errorStatus(err)
+	fmt.Println("API request failed with status "+strconv.Itoa(status)+": ", err.Error())
+	c.JSON(status, &ErrorResponse{Error: err.Error()})
+}
+
+func writeTrialsError(c *gin.Context, err error) {
+	fmt.Println("Failed to load stats from Trials Report: ", err.Error())
+	c.JSON(http.StatusBadGateway, &ErrorResponse{Error: "Trials Report is unavailable"})
+}
+
+func writeBadRequest(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, &ErrorResponse{Error: message})
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rking788/guardian-helper/bungie"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func serve(method, path, token, body string) *httptest.ResponseRecorder {
+
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	recorder := httptest.NewRecorder()
+	NewRouter().ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestRequireAccessToken(t *testing.T) {
+
+	for _, path := range []string{"/api/inventory", "/api/items/strange%20coins/count", "/api/trials/week"} {
+		recorder := serve("GET", path, "", "")
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("Expected %s to require an access token, got %d", path, recorder.Code)
+		}
+
+		errorResponse := ErrorResponse{}
+		if json.Unmarshal(recorder.Body.Bytes(), &errorResponse); errorResponse.Error == "" {
+			t.Errorf("Expected an error message for %s: %s", path, recorder.Body.String())
+		}
+	}
+}
+
+func TestTransferValidation(t *testing.T) {
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/api/transfers", `not json`},
+		{"/api/transfers", `{"destination": "hunter"}`},
+		{"/api/transfers", `{"item": "strange coins"}`},
+		{"/api/transfers", `{"item": "strange coins", "destination": "exo"}`},
+		{"/api/transfers", `{"item": "strange coins", "destination": "vault", "quantity": -2}`},
+		{"/api/transfers?platform=dreamcast", `{"item": "strange coins", "destination": "vault"}`},
+	}
+
+	for _, test := range tests {
+		if recorder := serve("POST", test.path, "access-token", test.body); recorder.Code != http.StatusBadRequest {
+			t.Errorf("Expected a bad request for %s with %s, got %d", test.path, test.body, recorder.Code)
+		}
+	}
+}
+
+func TestSaveLoadoutValidation(t *testing.T) {
+
+	for _, body := range []string{`not json`, `{}`, `{"name": "  "}`} {
+		if recorder := serve("POST", "/api/loadouts", "access-token", body); recorder.Code != http.StatusBadRequest {
+			t.Errorf("Expected a bad request for the loadout %s, got %d", body, recorder.Code)
+		}
+	}
+}
+
+func TestEngramTierValidation(t *testing.T) {
+
+	if recorder := serve("POST", "/api/engrams/unload?keep_tier=shiny", "access-token", ""); recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected an unknown tier to be rejected, got %d", recorder.Code)
+	}
+}
+
+func TestTrialsWeekMembershipError(t *testing.T) {
+
+	original := loadMembership
+	defer func() { loadMembership = original }()
+
+	platforms := make([]uint, 0, 1)
+	loadMembership = func(accessToken string, platform uint) (*bungie.Membership, error) {
+		platforms = append(platforms, platform)
+		return nil, bungie.ErrNoDestinyAccount
+	}
+
+	recorder := serve("GET", "/api/trials/week?platform=ps4", "access-token", "")
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected not found without a Destiny account, got %d", recorder.Code)
+	}
+	if len(platforms) != 1 || platforms[0] != bungie.PSN {
+		t.Errorf("Expected the membership to be loaded for the PlayStation, got %v", platforms)
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+
+	tests := map[error]int{
+		bungie.ErrAuthorization:     http.StatusUnauthorized,
+		bungie.ErrUnknownItem:       http.StatusNotFound,
+		bungie.ErrCharacterNotFound: http.StatusNotFound,
+		bungie.ErrLoadoutNotFound:   http.StatusNotFound,
+		bungie.ErrThrottled:         http.StatusTooManyRequests,
+		bungie.ErrUnavailable:       http.StatusServiceUnavailable,
+		errors.New("Unexpected"):    http.StatusBadGateway,
+	}
+
+	for err, expected := range tests {
+		if status := errorStatus(err); status != expected {
+			t.Errorf("Expected %d for %v, got %d", expected, err, status)
+		}
+	}
+}
+
+func TestLocale(t *testing.T) {
+
+	request := httptest.NewRequest("GET", "/api/inventory", nil)
+	request.Header.Set("Accept-Language", "de-AT;q=0.9, en;q=0.5")
+	c := &gin.Context{Request: request}
+	if result := locale(c); result != "de-DE" {
+		t.Errorf("Expected the German locale from the Accept-Language header, got %s", result)
+	}
+}
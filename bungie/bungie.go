@@ -8,17 +8,18 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/mikeflynn/go-alexa/skillserver"
 	"github.com/rking788/guardian-helper/db"
+	"github.com/rking788/guardian-helper/i18n"
 )
 
-const (
-	// TransferDelay will be the artificial between transfer requests to try and avoid throttling
-	TransferDelay = 750 * time.Millisecond
-)
+// TransferDelay will be the artificial between transfer requests to try and avoid throttling, it is
+// a variable so it can be shortened when using a fake Bungie.net server.
+var TransferDelay = 750 * time.Millisecond
 
 // BaseResponse represents the data returned as part of all of the Bungie API
 // requests.
@@ -30,6 +31,53 @@ type BaseResponse struct {
 	MessageData     interface{} `json:"MessageData"`
 }
 
+// Bungie.net PlatformErrorCodes values that need to be handled specifically
+const (
+	SuccessErrorCode                    = 1
+	SystemDisabledErrorCode             = 5
+	WebAuthRequiredErrorCode            = 99
+	AccessTokenHasExpiredErrorCode      = 2111
+	DestinyNoRoomInDestinationErrorCode = 1642
+)
+
+// Errors returned by the Bungie.net client that callers need to handle specifically
+var (
+	// ErrNoRoomInDestination is returned when an item cannot be transferred because the destination
+	// character or vault is full.
+	ErrNoRoomInDestination = errors.New("No room in the destination for the item")
+	// ErrAuthorization is returned when Bungie.net rejects the access token, usually because it
+	// expired or was revoked. The user needs to link their account again.
+	ErrAuthorization = errors.New("The Bungie.net access token is invalid or expired")
+	// ErrThrottled is returned when Bungie.net is throttling requests.
+	ErrThrottled = errors.New("Bungie.net is throttling requests")
+	// ErrUnavailable is returned when Bungie.net is down or disabled for maintenance.
+	ErrUnavailable = errors.New("Bungie.net is unavailable")
+	// ErrNoDestinyAccount is returned when the Bungie.net account does not have a Destiny account.
+	ErrNoDestinyAccount = errors.New("No linked Destiny account found on Bungie.net")
+)
+
+// asError will convert an unsuccessful response into an error, nil is returned if the response
+// indicates the request was successful.
+func (response *BaseResponse) asError() error {
+	switch {
+	case response.ErrorCode == SuccessErrorCode:
+		return nil
+	case response.ErrorCode == DestinyNoRoomInDestinationErrorCode ||
+		response.ErrorStatus == "DestinyNoRoomInDestination":
+		return ErrNoRoomInDestination
+	case response.ErrorCode == WebAuthRequiredErrorCode || response.ErrorCode == AccessTokenHasExpiredErrorCode ||
+		response.ErrorStatus == "WebAuthRequired" || strings.HasPrefix(response.ErrorStatus, "AccessToken") ||
+		strings.HasPrefix(response.ErrorStatus, "AuthorizationRecord") || strings.HasPrefix(response.ErrorStatus, "RefreshToken"):
+		return ErrAuthorization
+	case strings.HasPrefix(response.ErrorStatus, "ThrottleLimitExceeded") || strings.HasSuffix(response.ErrorStatus, "ThrottleExceeded"):
+		return ErrThrottled
+	case response.ErrorCode == SystemDisabledErrorCode || response.ErrorStatus == "SystemDisabled":
+		return ErrUnavailable
+	}
+
+	return fmt.Errorf("Bungie.net error(%d) %s: %s", response.ErrorCode, response.ErrorStatus, response.Message)
+}
+
 // GetAccountResponse is the response from a get current account API call
 // this information needs to be used in all of the character/user specific endpoints.
 type GetAccountResponse struct {
@@ -43,6 +91,30 @@ type GetAccountResponse struct {
 	Base *BaseResponse
 }
 
+// DestinyMembership will find the linked Destiny account on the platform with the membership type. The
+// first account is returned if the membership type is zero or there is no account on that platform,
+// nil is returned if there are no linked Destiny accounts.
+func (account *GetAccountResponse) DestinyMembership(membershipType uint) *Membership {
+
+	if account.Response == nil || len(account.Response.DestinyMemberships) == 0 {
+		return nil
+	}
+
+	membership := account.Response.DestinyMemberships[0]
+	for _, m := range account.Response.DestinyMemberships {
+		if m.MembershipType == membershipType {
+			membership = m
+			break
+		}
+	}
+
+	return &Membership{
+		MembershipType: membership.MembershipType,
+		MembershipID:   membership.MembershipID,
+		DisplayName:    membership.DisplayName,
+	}
+}
+
 // MembershipIDLookUpResponse represents the response to a Destiny membership ID lookup call
 type MembershipIDLookUpResponse struct {
 	Response        []*MembershipData `json:"Response"`
@@ -91,6 +163,24 @@ func (bucket EquipmentBucket) String() string {
 	return ""
 }
 
+// MarshalText will use the name of the bucket, like "Primary", when the bucket is encoded as JSON.
+func (bucket EquipmentBucket) MarshalText() ([]byte, error) {
+	return []byte(bucket.String()), nil
+}
+
+// UnmarshalText will read the bucket from its name, an error is returned for an unknown name.
+func (bucket *EquipmentBucket) UnmarshalText(text []byte) error {
+
+	for b := Primary; b <= Artifact; b++ {
+		if b.String() == string(text) {
+			*bucket = b
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Unknown equipment bucket: %s", text)
+}
+
 // Equipment bucket type definitions
 const (
 	Primary EquipmentBucket = iota
@@ -138,7 +228,7 @@ func PopulateItemMetadata() error {
 	for rows.Next() {
 		var hash uint
 		itemMeta := ItemMetadata{}
-		rows.Scan(&hash, &itemMeta.TierType, &itemMeta.ClassType)
+		rows.Scan(&hash, &itemMeta.TierType, &itemMeta.ClassType, &itemMeta.Icon)
 
 		itemMetadata[hash] = &itemMeta
 	}
@@ -179,8 +269,8 @@ func PopulateBucketHashLookup() error {
 // TODO: This may no longer be needed as the GetCurrentAccount endpoint should fix all this.
 func MembershipIDFromDisplayName(displayName string) string {
 
-	endpoint := fmt.Sprintf(MembershipIDFromDisplayNameFormat, XBOX, displayName)
 	client := NewClient("", os.Getenv("BUNGIE_API_KEY"))
+	endpoint := client.BaseURL + fmt.Sprintf(MembershipIDFromDisplayNameFormat, XBOX, displayName)
 	request, _ := http.NewRequest("GET", endpoint, nil)
 	request.Header.Add("X-Api-Key", client.APIToken)
 
@@ -200,134 +290,169 @@ func MembershipIDFromDisplayName(displayName string) string {
 	return jsonResponse.Response[0].MembershipID
 }
 
-// CountItem will count the number of the specified item and return an EchoResponse
-// that can be serialized and sent back to the Alexa skill.
-func CountItem(itemName, accessToken string) (*skillserver.EchoResponse, error) {
+// CountItem will count the number of the specified item on all characters and in the vault. The item
+// name is in the language of the locale, which is also used for the names of the items in a family.
+// If the itemHash is not zero, the item was already resolved by Alexa and the name is only used in the
+// result. ErrUnknownItem is returned if the name does not match an item.
+func CountItem(itemName string, itemHash uint, accessToken, locale string, prefs *db.UserPreferences) (*ItemCount, error) {
 
-	response := skillserver.NewEchoResponse()
+	l := i18n.For(locale)
 
-	client := NewClient(accessToken, os.Getenv("BUNGIE_API_KEY"))
+	client := newUserClient(accessToken, prefs)
 
 	// Load all items on all characters
 	itemsChannel := make(chan *AllItemsMsg)
 	go GetAllItemsForCurrentUser(client, itemsChannel)
 
-	// Check common misinterpretations from Alexa
-	if translation, ok := commonAlexaItemTranslations[itemName]; ok {
-		itemName = translation
-	}
+	hash := itemHash
+	if hash == 0 {
+		// Check common misinterpretations from Alexa
+		itemName = translateItemName(itemName)
 
-	hash, err := db.GetItemHashFromName(itemName)
-	if err != nil {
-		outputStr := fmt.Sprintf("Sorry Guardian, I could not find any items named %s in your inventory.", itemName)
-		response.OutputSpeech(outputStr)
-		return response, nil
+		// Item families like "planetary materials" are counted per item instead of a single hash
+		family, err := db.GetItemFamily(itemName)
+		if err == nil && len(family) > 0 {
+			return countItemFamily(l, itemName, family, itemsChannel)
+		}
+
+		hash, err = db.GetLocalizedItemHash(itemName, l.Language())
+		if err != nil {
+			return nil, ErrUnknownItem
+		}
 	}
 
 	itemsJSON, _ := <-itemsChannel
 	if itemsJSON.error != nil {
-		response.
-			OutputSpeech("Sorry Guardian, I could not load your items from Destiny, you may need to re-link your account in the Alexa app.").
-			LinkAccountCard()
-		return response, nil
+		fmt.Println("Failed to read the Items response from Bungie!: ", itemsJSON.error.Error())
+		return nil, itemsJSON.error
 	}
 	itemsData := itemsJSON.ItemsEndpointResponse.Response.Data
 	matchingItems := itemsData.Items.FilterItems(itemHashFilter, hash)
 	fmt.Printf("Found %d items entries in characters inventory.\n", len(matchingItems))
 
-	if len(matchingItems) == 0 {
-		outputStr := fmt.Sprintf("You don't have any %s on any of your characters.", itemName)
-		response.OutputSpeech(outputStr)
-		return response, nil
+	return &ItemCount{
+		ItemName:  itemName,
+		ItemHash:  hash,
+		IconURL:   ItemIconURL(hash),
+		Locations: itemLocations(matchingItems, itemsData),
+	}, nil
+}
+
+// countItemFamily will total each of the items in the family across all characters and the vault.
+// The totals are sorted by the largest quantity first so the most plentiful items are listed first.
+func countItemFamily(l *i18n.Localizer, familyName string, family map[uint]string, itemsChannel chan *AllItemsMsg) (*ItemCount, error) {
+
+	itemsJSON := <-itemsChannel
+	if itemsJSON.error != nil {
+		fmt.Println("Failed to read the Items response from Bungie!: ", itemsJSON.error.Error())
+		return nil, itemsJSON.error
 	}
 
-	outputString := ""
+	hashes := make([]uint, 0, len(family))
+	for hash := range family {
+		hashes = append(hashes, hash)
+	}
+
+	itemsData := itemsJSON.ItemsEndpointResponse.Response.Data
+	matchingItems := itemsData.Items.FilterItems(itemHashesFilter, hashes)
+	fmt.Printf("Found %d item entries in the %s family.\n", len(matchingItems), familyName)
+
+	totals := make(map[string]uint)
 	for _, item := range matchingItems {
-		outputString += fmt.Sprintf("Your %s has %d %s. ", itemsData.characterClassNameAtIndex(item.CharacterIndex), item.Quantity, itemName)
+		name, err := db.GetLocalizedItemName(item.ItemHash, l.Language())
+		if err != nil {
+			name = family[item.ItemHash]
+		}
+		totals[name] += item.Quantity
 	}
-	response = response.OutputSpeech(outputString)
 
-	return response, nil
+	count := &ItemCount{ItemName: familyName, Family: make([]*ItemTotal, 0, len(totals))}
+	for name, quantity := range totals {
+		count.Family = append(count.Family, &ItemTotal{ItemName: name, Quantity: quantity})
+	}
+	sort.Slice(count.Family, func(i, j int) bool {
+		if count.Family[i].Quantity == count.Family[j].Quantity {
+			return count.Family[i].ItemName < count.Family[j].ItemName
+		}
+		return count.Family[i].Quantity > count.Family[j].Quantity
+	})
+
+	return count, nil
 }
 
 // TransferItem is responsible for calling the necessary Bungie.net APIs to
 // transfer the specified item to the specified character. The quantity is optional
 // as well as the source class. If no quantity is specified, all of the specific
-// items will be transfered to the particular character.
-func TransferItem(itemName, accessToken, sourceClass, destinationClass string, count int) (*skillserver.EchoResponse, error) {
-	response := skillserver.NewEchoResponse()
+// items will be transfered to the particular character. The item name is in the language of the
+// locale while the class names are always in English. If the itemHash is not zero, the item was
+// already resolved by Alexa and the name is only used in the result. ErrUnknownItem is returned if
+// the name does not match an item and ErrCharacterNotFound if the account does not have a character
+// of the destination class.
+func TransferItem(itemName string, itemHash uint, accessToken, sourceClass, destinationClass string, count int, locale string, prefs *db.UserPreferences) (*TransferResult, error) {
+	l := i18n.For(locale)
 
-	client := NewClient(accessToken, os.Getenv("BUNGIE_API_KEY"))
+	client := newUserClient(accessToken, prefs)
 
 	itemsChannel := make(chan *AllItemsMsg)
 	go GetAllItemsForCurrentUser(client, itemsChannel)
 
 	// Check common misinterpretations from Alexa
-	if translation, ok := commonAlexaItemTranslations[itemName]; ok {
-		itemName = translation
-	}
-	if translation, ok := commonAlexaClassNameTrnaslations[destinationClass]; ok {
-		destinationClass = translation
-	}
-	if translation, ok := commonAlexaClassNameTrnaslations[sourceClass]; ok {
-		sourceClass = translation
-	}
+	destinationClass = TranslateClassName(destinationClass)
+	sourceClass = TranslateClassName(sourceClass)
 
-	hash, err := db.GetItemHashFromName(itemName)
-	if err != nil {
-		outputStr := fmt.Sprintf("Sorry Guardian, I could not find any items named %s in your inventory.", itemName)
-		response.OutputSpeech(outputStr)
-		return response, nil
+	hash := itemHash
+	if hash == 0 {
+		itemName = translateItemName(itemName)
+
+		var err error
+		hash, err = db.GetLocalizedItemHash(itemName, l.Language())
+		if err != nil {
+			return nil, ErrUnknownItem
+		}
 	}
 
 	itemsJSON := <-itemsChannel
 	if itemsJSON.error != nil {
-		fmt.Println("Failed to read the Items response from Bungie!: ", err.Error())
-		return nil, err
+		fmt.Println("Failed to read the Items response from Bungie!: ", itemsJSON.error.Error())
+		return nil, itemsJSON.error
 	}
 
 	itemsData := itemsJSON.ItemsEndpointResponse.Response.Data
 	matchingItems := itemsData.Items.FilterItems(itemHashFilter, hash)
 	fmt.Printf("Found %d items entries in characters inventory.\n", len(matchingItems))
 
+	result := &TransferResult{
+		ItemName:         itemName,
+		ItemHash:         hash,
+		IconURL:          ItemIconURL(hash),
+		DestinationClass: destinationClass,
+		Requested:        count,
+		Locations:        itemLocations(matchingItems, itemsData),
+	}
 	if len(matchingItems) == 0 {
-		outputStr := fmt.Sprintf("You don't have any %s on any of your characters.", itemName)
-		response.OutputSpeech(outputStr)
-		return response, nil
+		return result, nil
 	}
 
 	allChars := itemsJSON.ItemsEndpointResponse.Response.Data.Characters
 	destCharacter, err := findDestinationCharacter(allChars, destinationClass)
 	if err != nil {
-		output := fmt.Sprintf("Sorry Guardian, I could not transfer your %s because you do not have any %s characters in Destiny.", itemName, destinationClass)
-		fmt.Println(output)
-		response.OutputSpeech(output)
-
+		fmt.Printf("Could not transfer %s, no %s character found\n", itemName, destinationClass)
 		db.InsertUnknownValueIntoTable(destinationClass, db.UnknownClassTable)
-		return response, nil
+		return nil, ErrCharacterNotFound
 	}
 
-	actualQuantity := transferItem(matchingItems, allChars, destCharacter,
-		itemsJSON.GetAccountResponse.Response.DestinyMemberships[0].MembershipType,
+	result.Transferred, _ = transferItem(matchingItems, allChars, destCharacter,
+		itemsJSON.Membership.MembershipType,
 		count, client)
 
-	var output string
-	if count != -1 && actualQuantity < uint(count) {
-		output = fmt.Sprintf("You only had %d %s on other characters, all of it has been transferred to your %s", actualQuantity, itemName, destinationClass)
-	} else {
-		output = fmt.Sprintf("All set Guardian, %d %s have been transferred to your %s", actualQuantity, itemName, destinationClass)
-	}
-
-	response.OutputSpeech(output)
-
-	return response, nil
+	return result, nil
 }
 
-// EquipMaxLightGear will equip all items that are required to have the maximum light on a character
-func EquipMaxLightGear(accessToken string) (*skillserver.EchoResponse, error) {
-	response := skillserver.NewEchoResponse()
+// EquipMaxLightGear will equip all items that are required to have the maximum light on a character.
+// Item names in the result are in the language of the locale.
+func EquipMaxLightGear(accessToken, locale string, prefs *db.UserPreferences) (*LoadoutResult, error) {
 
-	client := NewClient(accessToken, os.Getenv("BUNGIE_API_KEY"))
+	client := newUserClient(accessToken, prefs)
 
 	itemsChannel := make(chan *AllItemsMsg)
 	go GetAllItemsForCurrentUser(client, itemsChannel)
@@ -338,9 +463,9 @@ func EquipMaxLightGear(accessToken string) (*skillserver.EchoResponse, error) {
 		return nil, itemsJSON.error
 	}
 
-	// Transfer to the most recent character on the most recent platform
-	destinationIndex := 0
-	membershipType := itemsJSON.GetAccountResponse.Response.DestinyMemberships[0].MembershipType
+	// Transfer to the default character, or the most recent character, on the preferred platform
+	destinationIndex := preferredCharacterIndex(itemsJSON.ItemsEndpointResponse.Response.Data.Characters, prefs)
+	membershipType := itemsJSON.Membership.MembershipType
 
 	loadout := findMaxLightLoadout(itemsJSON.ItemsEndpointResponse, destinationIndex)
 
@@ -353,16 +478,16 @@ func EquipMaxLightGear(accessToken string) (*skillserver.EchoResponse, error) {
 		return nil, err
 	}
 
-	characterClass := itemsJSON.ItemsEndpointResponse.Response.Data.characterClassNameAtIndex(0)
-	response.OutputSpeech(fmt.Sprintf("Max light equipped to your %s Guardian. You are a force to be wreckoned with.", characterClass))
-	return response, nil
+	characterClass := itemsJSON.ItemsEndpointResponse.Response.Data.characterClassNameAtIndex(destinationIndex)
+	return loadoutResult(loadout, characterClass, i18n.For(locale)), nil
 }
 
-// UnloadEngrams is responsible for transferring all engrams off of a character and
-func UnloadEngrams(accessToken string) (*skillserver.EchoResponse, error) {
-	response := skillserver.NewEchoResponse()
+// PlanMaxLight will find the max light loadout for the current character without moving or equipping
+// anything. The plan describes the changes that EquipMaxLightGear would make so they can be confirmed first.
+// Item names in the plan are in the language of the locale.
+func PlanMaxLight(accessToken, locale string, prefs *db.UserPreferences) (*MaxLightPlan, error) {
 
-	client := NewClient(accessToken, os.Getenv("BUNGIE_API_KEY"))
+	client := newUserClient(accessToken, prefs)
 
 	itemsChannel := make(chan *AllItemsMsg)
 	go GetAllItemsForCurrentUser(client, itemsChannel)
@@ -373,11 +498,78 @@ func UnloadEngrams(accessToken string) (*skillserver.EchoResponse, error) {
 		return nil, itemsJSON.error
 	}
 
-	matchingItems := itemsJSON.ItemsEndpointResponse.Response.Data.Items.FilterItems(itemIsEngramFilter, true)
+	// Transfer to the default character, or the most recent character, on the preferred platform
+	destinationIndex := preferredCharacterIndex(itemsJSON.ItemsEndpointResponse.Response.Data.Characters, prefs)
+	loadout := findMaxLightLoadout(itemsJSON.ItemsEndpointResponse, destinationIndex)
+
+	return planLoadout(loadout, destinationIndex, itemsJSON.ItemsEndpointResponse.Response.Data, i18n.For(locale)), nil
+}
+
+// PlanUnloadEngrams will count the number of engrams that UnloadEngrams would move to the vault with
+// the same tier filters, without moving anything.
+func PlanUnloadEngrams(accessToken string, onlyTier, keepTier uint, prefs *db.UserPreferences) (uint, error) {
+
+	client := newUserClient(accessToken, prefs)
+
+	itemsChannel := make(chan *AllItemsMsg)
+	go GetAllItemsForCurrentUser(client, itemsChannel)
+
+	itemsJSON := <-itemsChannel
+	if itemsJSON.error != nil {
+		fmt.Println("Failed to read the Items response from Bungie!: ", itemsJSON.error.Error())
+		return 0, itemsJSON.error
+	}
+
+	count := uint(0)
+	for _, item := range findEngramsToUnload(itemsJSON.ItemsEndpointResponse.Response.Data.Items, onlyTier, keepTier) {
+		count += item.Quantity
+	}
+
+	return count, nil
+}
+
+// findEngramsToUnload will filter the items down to the engrams on characters that should be moved to the vault.
+func findEngramsToUnload(items ItemList, onlyTier, keepTier uint) ItemList {
+
+	// Engrams already in the vault do not need to be moved
+	matchingItems := items.
+		FilterItems(itemIsEngramFilter, true).
+		FilterItems(itemNotCharacterIndexFilter, -1)
+	if onlyTier != UnknownTier {
+		matchingItems = matchingItems.FilterItems(itemTierTypeFilter, onlyTier)
+	}
+	if keepTier != UnknownTier {
+		matchingItems = matchingItems.FilterItems(itemNotTierTypeFilter, keepTier)
+	}
+
+	return matchingItems
+}
+
+// UnloadEngrams is responsible for transferring all engrams off of all characters and into the vault.
+// If onlyTier is provided, only engrams of that tier will be moved. If keepTier is provided, engrams of
+// that tier will be left on the characters. Use UnknownTier to skip either of the filters.
+func UnloadEngrams(accessToken string, onlyTier, keepTier uint, prefs *db.UserPreferences) (*UnloadResult, error) {
+
+	client := newUserClient(accessToken, prefs)
+
+	itemsChannel := make(chan *AllItemsMsg)
+	go GetAllItemsForCurrentUser(client, itemsChannel)
+
+	itemsJSON := <-itemsChannel
+	if itemsJSON.error != nil {
+		fmt.Println("Failed to read the Items response from Bungie!: ", itemsJSON.error.Error())
+		return nil, itemsJSON.error
+	}
+
+	result := &UnloadResult{
+		Filtered: onlyTier != UnknownTier || keepTier != UnknownTier,
+		Moved:    make([]*EngramCount, 0),
+		NotMoved: make([]*EngramCount, 0),
+	}
+
+	matchingItems := findEngramsToUnload(itemsJSON.ItemsEndpointResponse.Response.Data.Items, onlyTier, keepTier)
 	if len(matchingItems) == 0 {
-		outputStr := fmt.Sprintf("You don't have any engrams on your current character. Happy farming Guardian!")
-		response.OutputSpeech(outputStr)
-		return response, nil
+		return result, nil
 	}
 
 	foundCount := uint(0)
@@ -389,16 +581,95 @@ func UnloadEngrams(accessToken string) (*skillserver.EchoResponse, error) {
 
 	allChars := itemsJSON.ItemsEndpointResponse.Response.Data.Characters
 
-	_ = transferItem(matchingItems, allChars, nil,
-		itemsJSON.GetAccountResponse.Response.DestinyMemberships[0].MembershipType,
+	_, failed := transferItem(matchingItems, allChars, nil,
+		itemsJSON.Membership.MembershipType,
 		-1, client)
 
-	var output string
-	output = fmt.Sprintf("All set Guardian, your engrams have been transferred to your vault. Happy farming Guardian")
+	failedIDs := make(map[string]bool)
+	for _, item := range failed {
+		failedIDs[item.ItemID] = true
+	}
+
+	moved := make(map[uint]uint)
+	notMoved := make(map[uint]uint)
+	for _, item := range matchingItems {
+		if failedIDs[item.ItemID] {
+			notMoved[itemTierType(item)] += item.Quantity
+		} else {
+			moved[itemTierType(item)] += item.Quantity
+		}
+	}
+	result.Moved = engramCounts(moved)
+	result.NotMoved = engramCounts(notMoved)
+
+	return result, nil
+}
+
+type uintSlice []uint
+
+func (s uintSlice) Len() int           { return len(s) }
+func (s uintSlice) Less(i, j int) bool { return s[i] < s[j] }
+func (s uintSlice) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// CharacterSummary will load all of the current user's characters with the class, race, light level,
+// and the last time each one was played. The most recently played characters are first.
+func CharacterSummary(accessToken string, prefs *db.UserPreferences) ([]*CharacterDetails, error) {
+
+	client := newUserClient(accessToken, prefs)
+
+	itemsChannel := make(chan *AllItemsMsg)
+	go GetAllItemsForCurrentUser(client, itemsChannel)
+
+	itemsJSON := <-itemsChannel
+	if itemsJSON.error != nil {
+		fmt.Println("Failed to read the Items response from Bungie!: ", itemsJSON.error.Error())
+		return nil, itemsJSON.error
+	}
+
+	characters := itemsJSON.ItemsEndpointResponse.Response.Data.Characters
+
+	// Most recently played characters first
+	sorted := make(CharacterList, len(characters))
+	copy(sorted, characters)
+	sort.Sort(sort.Reverse(LastPlayedSort(sorted)))
+
+	details := make([]*CharacterDetails, 0, len(sorted))
+	for _, char := range sorted {
+		details = append(details, char.CharacterBase.details())
+	}
+
+	return details, nil
+}
+
+// GetInventory will load all of the items on the current user's characters and in the vault along
+// with the characters themselves, characters are in the order returned by Bungie.net.
+func GetInventory(accessToken string, prefs *db.UserPreferences) (*Inventory, error) {
+
+	client := newUserClient(accessToken, prefs)
+
+	itemsChannel := make(chan *AllItemsMsg)
+	go GetAllItemsForCurrentUser(client, itemsChannel)
+
+	itemsJSON := <-itemsChannel
+	if itemsJSON.error != nil {
+		fmt.Println("Failed to read the Items response from Bungie!: ", itemsJSON.error.Error())
+		return nil, itemsJSON.error
+	}
 
-	response.OutputSpeech(output)
+	data := itemsJSON.ItemsEndpointResponse.Response.Data
+	inventory := &Inventory{
+		Membership: itemsJSON.Membership,
+		Characters: make([]*CharacterDetails, 0, len(data.Characters)),
+		Items:      make([]*InventoryItem, 0, len(data.Items)),
+	}
+	for _, char := range data.Characters {
+		inventory.Characters = append(inventory.Characters, char.CharacterBase.details())
+	}
+	for _, item := range data.Items {
+		inventory.Items = append(inventory.Items, inventoryItem(item, data))
+	}
 
-	return response, nil
+	return inventory, nil
 }
 
 // GetOutboundIP gets preferred outbound ip of this machine
@@ -417,12 +688,22 @@ func GetOutboundIP() net.IP {
 // transferItem is a generic transfer method that will handle a full transfer of a specific item to the specified
 // character. This requires a full trip from the source, to the vault, and then to the destination character.
 // By providing a nil destCharacter, the items will be transferred to the vault and left there.
-func transferItem(itemSet []*Item, fullCharList []*Character, destCharacter *Character, membershipType uint, count int, client *Client) uint {
+// The items that could not be transferred, for example because the destination was full,
+// are returned along with the total quantity that was attempted.
+func transferItem(itemSet []*Item, fullCharList []*Character, destCharacter *Character, membershipType uint, count int, client *Client) (uint, ItemList) {
 
 	// TODO: This should probably take the transferStatus field into account,
 	// if the item is NotTransferrable, don't bother trying.
 	var totalCount uint
 	var wg sync.WaitGroup
+	var failedLock sync.Mutex
+	failed := make(ItemList, 0)
+	addFailure := func(item *Item, err error) {
+		fmt.Printf("Failed to transfer item(%s): %s\n", item, err.Error())
+		failedLock.Lock()
+		failed = append(failed, item)
+		failedLock.Unlock()
+	}
 
 	for _, item := range itemSet {
 
@@ -463,8 +744,12 @@ func transferItem(itemSet []*Item, fullCharList []*Character, destCharacter *Cha
 					"membershipType":    membershipType,
 				}
 
-				client.PostTransferItem(requestBody)
+				err := client.PostTransferItem(requestBody)
 				time.Sleep(TransferDelay)
+				if err != nil {
+					addFailure(item, err)
+					return
+				}
 			}
 
 			// TODO: This could possibly be handled more efficiently if we know the items are uniform,
@@ -485,8 +770,11 @@ func transferItem(itemSet []*Item, fullCharList []*Character, destCharacter *Cha
 				"membershipType":    membershipType,
 			}
 
-			client.PostTransferItem(vaultToCharRequestBody)
+			err := client.PostTransferItem(vaultToCharRequestBody)
 			time.Sleep(TransferDelay)
+			if err != nil {
+				addFailure(item, err)
+			}
 
 		}(item, fullCharList, &wg)
 
@@ -497,13 +785,16 @@ func transferItem(itemSet []*Item, fullCharList []*Character, destCharacter *Cha
 
 	wg.Wait()
 
-	return totalCount
+	return totalCount, failed
 }
 
 // equipItems is a generic equip method that will handle a equipping a specific item on a specific character.
-func equipItems(itemSet []*Item, characterIndex int, characters []*Character, membershipType uint, client *Client) {
+// The first error from Bungie.net is returned if any of the items could not be equipped.
+func equipItems(itemSet []*Item, characterIndex int, characters []*Character, membershipType uint, client *Client) error {
 
 	var wg sync.WaitGroup
+	var errLock sync.Mutex
+	var firstErr error
 
 	for _, item := range itemSet {
 
@@ -519,19 +810,28 @@ func equipItems(itemSet []*Item, characterIndex int, characters []*Character, me
 		go func(item *Item, character *Character, membershipType uint, wait *sync.WaitGroup) {
 
 			defer wg.Done()
-			equipItem(item, character, membershipType, client)
+			err := equipItem(item, character, membershipType, client)
+			if err != nil {
+				errLock.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				errLock.Unlock()
+			}
 
 		}(item, characters[characterIndex], membershipType, &wg)
 	}
 
 	wg.Wait()
+
+	return firstErr
 }
 
 // TODO: All of these equip/transfer/etc. action should take a single struct with all the parameters required
 // to perform the action, as well as probably a *Client reference.
 
 // equipItem will take the specified item and equip it on the provided character
-func equipItem(item *Item, character *Character, membershipType uint, client *Client) {
+func equipItem(item *Item, character *Character, membershipType uint, client *Client) error {
 	fmt.Printf("Equipping item(%d)...\n", item.ItemHash)
 
 	equipRequestBody := map[string]interface{}{
@@ -540,7 +840,12 @@ func equipItem(item *Item, character *Character, membershipType uint, client *Cl
 		"membershipType": membershipType,
 	}
 
-	client.PostEquipItem(equipRequestBody)
+	err := client.PostEquipItem(equipRequestBody)
+	if err != nil {
+		fmt.Printf("Failed to equip item(%d): %s\n", item.ItemHash, err.Error())
+	}
+
+	return err
 }
 
 // AllItemsMsg is a type used by channels that need to communicate back from a
@@ -548,39 +853,70 @@ func equipItem(item *Item, character *Character, membershipType uint, client *Cl
 type AllItemsMsg struct {
 	*ItemsEndpointResponse
 	*GetAccountResponse
+	// Membership is the Destiny account the items were loaded for
+	Membership *Membership
 	error
 }
 
+// newUserClient will create a Client for the linked account that loads the Destiny account on the
+// user's preferred platform.
+func newUserClient(accessToken string, prefs *db.UserPreferences) *Client {
+
+	client := NewClient(accessToken, os.Getenv("BUNGIE_API_KEY"))
+	client.MembershipType = prefs.Platform
+
+	return client
+}
+
+// preferredCharacterIndex will find the index of the user's default character, if there is no default
+// or the account does not have that class, the most recently played character is used.
+func preferredCharacterIndex(characters CharacterList, prefs *db.UserPreferences) int {
+
+	if prefs.DefaultCharacter == "" {
+		return 0
+	}
+
+	index, err := findDestinationCharacterIndex(characters, prefs.DefaultCharacter)
+	if err != nil || index < 0 {
+		fmt.Printf("Default character(%s) not found, using the most recent character\n", prefs.DefaultCharacter)
+		return 0
+	}
+
+	return index
+}
+
 // GetAllItemsForCurrentUser will perform a lookup of the current user based on
 // the OAuth credentials provided by Alexa. Then it will make a request to get
 // all of the items for that user on all characters.
 func GetAllItemsForCurrentUser(client *Client, responseChan chan *AllItemsMsg) {
 
-	// TODO: check error
-	currentAccount, _ := client.GetCurrentAccount()
-
-	if currentAccount == nil {
-		fmt.Println("Failed to load current account with the specified access token!")
+	var membership *Membership
+	currentAccount, err := client.GetCurrentAccount()
+	if err == nil {
+		membership = currentAccount.DestinyMembership(client.MembershipType)
+		if membership == nil {
+			err = ErrNoDestinyAccount
+		}
+	}
+	if err != nil {
+		fmt.Println("Failed to load current account with the specified access token: ", err.Error())
 		responseChan <- &AllItemsMsg{
 			ItemsEndpointResponse: nil,
 			GetAccountResponse:    nil,
-			error:                 errors.New("Couldn't load current user information"),
+			error:                 err,
 		}
 
 		return
 	}
 
-	// TODO: Figure out how to support multiple accounts, meaning PSN and XBOX,
-	// maybe require it to be specified in the Alexa voice command.
-	membership := currentAccount.Response.DestinyMemberships[0]
-
 	items, err := client.GetUserItems(membership.MembershipType, membership.MembershipID)
 	if err != nil {
 		fmt.Println("Failed to read the Items response from Bungie!: ", err.Error())
 		responseChan <- &AllItemsMsg{
 			ItemsEndpointResponse: nil,
 			GetAccountResponse:    currentAccount,
-			error:                 errors.New("Failed to read current user's items: " + err.Error()),
+			Membership:            membership,
+			error:                 err,
 		}
 		return
 	}
@@ -592,6 +928,7 @@ func GetAllItemsForCurrentUser(client *Client, responseChan chan *AllItemsMsg) {
 	responseChan <- &AllItemsMsg{
 		ItemsEndpointResponse: items,
 		GetAccountResponse:    currentAccount,
+		Membership:            membership,
 		error:                 nil,
 	}
 }
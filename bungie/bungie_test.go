@@ -2,14 +2,15 @@ package bungie
 
 import (
 	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"os"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"github.com/rking788/guardian-helper/db"
 )
 
-// NOTE: Never run this while using the bungie.net URLs in bungie/constants.go
-// those should be changed to a localhost webserver that returns static results.
+// NOTE: Never run this against Bungie.net, use the fake server in bungietest instead
+// like the tests in fake_test.go.
 func BenchmarkSomething(b *testing.B) {
 	b.ReportAllocs()
 	b.ResetTimer()
@@ -18,57 +19,290 @@ func BenchmarkSomething(b *testing.B) {
 	}
 }
 
-func BenchmarkFiltering(b *testing.B) {
-	PopulateItemMetadata()
-	items, err := loadItemEndpointResponse()
-	if err != nil {
-		b.Fail()
-		return
+func TestItemHashesFilter(t *testing.T) {
+
+	items := ItemList{
+		&Item{ItemHash: 1},
+		&Item{ItemHash: 2},
+		&Item{ItemHash: 3},
 	}
 
-	b.ReportAllocs()
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		_ = items.Response.Data.Items.FilterItems(itemTierTypeFilter, ExoticTier)
+	result := items.FilterItems(itemHashesFilter, []uint{2, 3})
+	if len(result) != 2 || result[0].ItemHash != 2 || result[1].ItemHash != 3 {
+		t.Errorf("Unexpected filter result: %v", result)
 	}
 }
 
-func BenchmarkMaxLight(b *testing.B) {
-	PopulateItemMetadata()
-	PopulateBucketHashLookup()
-	itemsResponse, err := loadItemEndpointResponse()
-	if err != nil {
-		b.Fail()
-		return
+func TestBaseResponseAsError(t *testing.T) {
+
+	if err := (&BaseResponse{ErrorCode: SuccessErrorCode}).asError(); err != nil {
+		t.Errorf("Expected no error for a successful response: %s", err.Error())
 	}
 
-	b.ReportAllocs()
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		findMaxLightLoadout(itemsResponse, 0)
+	if err := (&BaseResponse{ErrorCode: DestinyNoRoomInDestinationErrorCode}).asError(); err != ErrNoRoomInDestination {
+		t.Errorf("Expected no room error, got %v", err)
+	}
+
+	if err := (&BaseResponse{ErrorCode: 5, ErrorStatus: "SystemDisabled"}).asError(); err != ErrUnavailable {
+		t.Errorf("Expected unavailable error, got %v", err)
+	}
+
+	if err := (&BaseResponse{ErrorCode: AccessTokenHasExpiredErrorCode, ErrorStatus: "AccessTokenHasExpired"}).asError(); err != ErrAuthorization {
+		t.Errorf("Expected authorization error for an expired token, got %v", err)
+	}
+
+	if err := (&BaseResponse{ErrorCode: 99, ErrorStatus: "WebAuthRequired"}).asError(); err != ErrAuthorization {
+		t.Errorf("Expected authorization error when auth is required, got %v", err)
+	}
+
+	if err := (&BaseResponse{ErrorCode: 36, ErrorStatus: "ThrottleLimitExceededMomentarily"}).asError(); err != ErrThrottled {
+		t.Errorf("Expected throttled error, got %v", err)
+	}
+
+	err := (&BaseResponse{ErrorCode: 1623, ErrorStatus: "DestinyItemNotFound"}).asError()
+	if err == nil || err == ErrAuthorization || err == ErrThrottled || err == ErrUnavailable {
+		t.Errorf("Expected a generic error for an unknown failure, got %v", err)
+	}
+}
+
+func TestDecodeResponse(t *testing.T) {
+
+	tests := []struct {
+		status   int
+		body     string
+		expected error
+	}{
+		{http.StatusOK, `{"ErrorCode":1,"ErrorStatus":"Success","Response":{"destinyMemberships":[]}}`, nil},
+		{http.StatusUnauthorized, `{"ErrorCode":99,"ErrorStatus":"WebAuthRequired"}`, ErrAuthorization},
+		{http.StatusUnauthorized, ``, ErrAuthorization},
+		{http.StatusOK, `{"ErrorCode":2111,"ErrorStatus":"AccessTokenHasExpired"}`, ErrAuthorization},
+		{http.StatusTooManyRequests, ``, ErrThrottled},
+		{http.StatusServiceUnavailable, `<html>Maintenance</html>`, ErrUnavailable},
+		{http.StatusServiceUnavailable, `{"ErrorCode":5,"ErrorStatus":"SystemDisabled"}`, ErrUnavailable},
+	}
+
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		recorder.WriteHeader(test.status)
+		recorder.WriteString(test.body)
+
+		result := GetAccountResponse{}
+		err := decodeResponse(recorder.Result(), &result)
+		if err != test.expected {
+			t.Errorf("Expected %v for a %d response with body %s, got %v", test.expected, test.status, test.body, err)
+		}
+	}
+}
+
+func TestClientBaseURL(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != GetCurrentAccountEndpoint || r.Header.Get("Authorization") != "Bearer access-token" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"ErrorCode":1,"ErrorStatus":"Success","Response":{"destinyMemberships":[{"membershipType":2,"displayName":"guardian","membershipId":"4611"}]}}`))
+	}))
+	defer server.Close()
+
+	defer func(baseURL string) { BaseURL = baseURL }(BaseURL)
+	BaseURL = server.URL
+
+	membership, err := NewClient("access-token", "api-key").GetCurrentMembership()
+	if err != nil || membership.MembershipID != "4611" {
+		t.Errorf("Expected the membership from the base URL, got %+v (%v)", membership, err)
+	}
+}
+
+func TestClientNetworkError(t *testing.T) {
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	client := NewClient("access-token", "api-key")
+	client.BaseURL = server.URL
+
+	if _, err := client.GetCurrentAccount(); err != ErrUnavailable {
+		t.Errorf("Expected ErrUnavailable when Bungie.net cannot be reached, got %v", err)
+	}
+	if _, err := client.GetUserItems(XBOX, "4611"); err != ErrUnavailable {
+		t.Errorf("Expected ErrUnavailable loading items when Bungie.net cannot be reached, got %v", err)
+	}
+	if err := client.PostTransferItem(map[string]interface{}{}); err != ErrUnavailable {
+		t.Errorf("Expected ErrUnavailable transferring when Bungie.net cannot be reached, got %v", err)
+	}
+	if err := client.PostEquipItem(map[string]interface{}{}); err != ErrUnavailable {
+		t.Errorf("Expected ErrUnavailable equipping when Bungie.net cannot be reached, got %v", err)
 	}
 }
 
-func loadItemEndpointResponse() (*ItemsEndpointResponse, error) {
+func TestPostActionStatus(t *testing.T) {
 
-	f, err := os.Open("../local_tools/samples/get_all_items_summary-latest.json")
-	if err != nil {
-		fmt.Println("Cannot read local all items response!!: ", err.Error())
-		return nil, err
+	tests := []struct {
+		status   int
+		body     string
+		expected error
+	}{
+		{http.StatusOK, `{"ErrorCode":1,"ErrorStatus":"Success","Response":0}`, nil},
+		{http.StatusUnauthorized, `Unauthorized`, ErrAuthorization},
+		{http.StatusBadGateway, `<html>Bad Gateway</html>`, ErrUnavailable},
+		{http.StatusOK, `{"ErrorCode":1642,"ErrorStatus":"DestinyNoRoomInDestination"}`, ErrNoRoomInDestination},
 	}
 
-	b, err := ioutil.ReadAll(f)
-	if err != nil {
-		fmt.Println("Failed to read local all items response!!: ", err.Error())
-		return nil, err
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.status)
+			w.Write([]byte(test.body))
+		}))
+
+		client := NewClient("access-token", "api-key")
+		client.BaseURL = server.URL
+
+		if err := client.PostTransferItem(map[string]interface{}{}); err != test.expected {
+			t.Errorf("Expected %v transferring with a %d response, got %v", test.expected, test.status, err)
+		}
+
+		character := &Character{CharacterBase: &CharacterBase{CharacterID: "2305843009200000002"}}
+		err := equipItems([]*Item{{ItemHash: 1274330687, ItemID: "6917529000000000115"}}, 0, []*Character{character}, XBOX, client)
+		if err != test.expected {
+			t.Errorf("Expected %v equipping with a %d response, got %v", test.expected, test.status, err)
+		}
+
+		server.Close()
 	}
+}
+
+func TestItemLocations(t *testing.T) {
 
-	var response ItemsEndpointResponse
-	err = json.Unmarshal(b, &response)
-	if err != nil {
-		fmt.Println("Error unmarshal-ing the all items response!!: ", err.Error())
-		return nil, err
+	data := &ItemsData{
+		Characters: CharacterList{
+			&Character{CharacterBase: &CharacterBase{ClassHash: TITAN}},
+			&Character{CharacterBase: &CharacterBase{ClassHash: HUNTER}},
+		},
+	}
+	items := ItemList{
+		&Item{ItemHash: 1, Quantity: 200, CharacterIndex: -1},
+		&Item{ItemHash: 1, Quantity: 15, CharacterIndex: 1},
+		&Item{ItemHash: 1, Quantity: 20, CharacterIndex: 0},
+		&Item{ItemHash: 1, Quantity: 5, CharacterIndex: 1},
 	}
 
-	return &response, nil
+	locations := itemLocations(items, data)
+	expected := []ItemLocation{{"titan", 20}, {"hunter", 20}, {"vault", 200}}
+	if len(locations) != len(expected) {
+		t.Fatalf("Unexpected number of locations: %d", len(locations))
+	}
+	for i, location := range locations {
+		if *location != expected[i] {
+			t.Errorf("Expected %+v at %d but got %+v", expected[i], i, location)
+		}
+	}
+
+	count := &ItemCount{Locations: locations}
+	if count.Total() != 240 || count.IsFamily() {
+		t.Errorf("Unexpected total for the locations: %d", count.Total())
+	}
+}
+
+func TestEngramCounts(t *testing.T) {
+
+	counts := engramCounts(map[uint]uint{SuperiorTier: 3, UnknownTier: 2, ExoticTier: 1})
+	expected := []EngramCount{{ExoticTier, "exotic", 1}, {SuperiorTier, "legendary", 3}, {UnknownTier, "", 2}}
+	if len(counts) != len(expected) {
+		t.Fatalf("Unexpected number of engram counts: %d", len(counts))
+	}
+	for i, count := range counts {
+		if *count != expected[i] {
+			t.Errorf("Expected %+v at %d but got %+v", expected[i], i, count)
+		}
+	}
+}
+
+func TestTransferResultPartial(t *testing.T) {
+
+	if (&TransferResult{Requested: -1, Transferred: 5}).Partial() {
+		t.Error("Expected transferring all of an item to never be partial")
+	}
+	if !(&TransferResult{Requested: 10, Transferred: 5}).Partial() {
+		t.Error("Expected transferring fewer items than requested to be partial")
+	}
+	if (&TransferResult{Requested: 5, Transferred: 5}).Partial() {
+		t.Error("Expected transferring the requested quantity to not be partial")
+	}
+}
+
+func TestItemIconURL(t *testing.T) {
+
+	itemMetadata = map[uint]*ItemMetadata{
+		1274330687: {Icon: "/common/destiny_content/icons/gjallarhorn.jpg"},
+		1:          {},
+	}
+	defer func() { itemMetadata = nil }()
+
+	if url := ItemIconURL(1274330687); url != "https://www.bungie.net/common/destiny_content/icons/gjallarhorn.jpg" {
+		t.Errorf("Unexpected icon URL: %s", url)
+	}
+	if url := ItemIconURL(1); url != "" {
+		t.Errorf("Expected no icon for an item without one, got: %s", url)
+	}
+	if url := ItemIconURL(2); url != "" {
+		t.Errorf("Expected no icon for an unknown item, got: %s", url)
+	}
+}
+
+func TestDestinyMembership(t *testing.T) {
+
+	account := GetAccountResponse{}
+	json.Unmarshal([]byte(`{"Response": {"destinyMemberships": [
+		{"membershipType": 1, "membershipId": "1111", "displayName": "xbox-guardian"},
+		{"membershipType": 2, "membershipId": "2222", "displayName": "psn-guardian"}]}}`), &account)
+
+	if membership := account.DestinyMembership(0); membership.MembershipID != "1111" {
+		t.Errorf("Expected the first membership without a platform, got %+v", membership)
+	}
+	if membership := account.DestinyMembership(PSN); membership.MembershipID != "2222" {
+		t.Errorf("Expected the PlayStation membership, got %+v", membership)
+	}
+	if membership := account.DestinyMembership(BLIZZARD); membership.MembershipID != "1111" {
+		t.Errorf("Expected the first membership for a missing platform, got %+v", membership)
+	}
+	if membership := (&GetAccountResponse{}).DestinyMembership(XBOX); membership != nil {
+		t.Errorf("Expected no membership without linked accounts, got %+v", membership)
+	}
+}
+
+func TestPlatformFromName(t *testing.T) {
+
+	if platform, ok := PlatformFromName("PS4"); !ok || platform != PSN {
+		t.Errorf("Expected PS4 to be PlayStation, got %d", platform)
+	}
+	if platform, ok := PlatformFromName("xbox"); !ok || PlatformName(platform) != "xbox" {
+		t.Errorf("Expected the xbox platform, got %d", platform)
+	}
+	if _, ok := PlatformFromName("dreamcast"); ok {
+		t.Error("Expected an unknown platform to not be found")
+	}
+}
+
+func TestPreferredCharacterIndex(t *testing.T) {
+
+	characters := CharacterList{
+		&Character{CharacterBase: &CharacterBase{ClassHash: TITAN}},
+		&Character{CharacterBase: &CharacterBase{ClassHash: HUNTER}},
+	}
+
+	prefs := db.DefaultUserPreferences("test-user")
+	if index := preferredCharacterIndex(characters, prefs); index != 0 {
+		t.Errorf("Expected the most recent character without a default, got %d", index)
+	}
+
+	prefs.DefaultCharacter = "hunter"
+	if index := preferredCharacterIndex(characters, prefs); index != 1 {
+		t.Errorf("Expected the default character, got %d", index)
+	}
+
+	prefs.DefaultCharacter = "warlock"
+	if index := preferredCharacterIndex(characters, prefs); index != 0 {
+		t.Errorf("Expected the most recent character when the default is missing, got %d", index)
+	}
 }
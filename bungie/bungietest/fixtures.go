@@ -0,0 +1,222 @@
+package bungietest
+
+import (
+	"time"
+
+	"github.com/rking788/guardian-helper/bungie"
+)
+
+// Access tokens of the fixture accounts
+const (
+	// GuardianToken is an account with a warlock, titan, and hunter on Xbox and a hunter on PlayStation.
+	// The warlock was played most recently and can reach a higher light level with items from the
+	// vault and the Gjallarhorn equipped on the titan.
+	GuardianToken = "guardian"
+	// FullVaultToken is an account with a warlock on PlayStation whose vault is full, so engrams cannot
+	// be unloaded.
+	FullVaultToken = "full-vault"
+	// NoDestinyToken is a Bungie.net account without a Destiny account.
+	NoDestinyToken = "no-destiny"
+)
+
+// Membership IDs of the Destiny accounts in the fixtures
+const (
+	GuardianXboxMembershipID = "4611686018400000001"
+	GuardianPSNMembershipID  = "4611686018400000002"
+	FullVaultMembershipID    = "4611686018400000003"
+)
+
+// Hashes of the items in the fixtures. Currencies, materials, and Gjallarhorn use their Destiny
+// hashes so they can be looked up by name in the database. The engrams and the rest of the gear use
+// made up hashes, their tier and class are described by ItemMetadata.
+const (
+	StrangeCoinHash = 1738186005
+	MoteOfLightHash = 937555249
+	SpinmetalHash   = 2882093969
+	GjallarhornHash = 1274330687
+
+	RareEngramHash      = 900001
+	LegendaryEngramHash = 900002
+	ExoticEngramHash    = 900003
+
+	AutoRifleHash       = 900101
+	ScoutRifleHash      = 900102
+	SniperRifleHash     = 900103
+	RocketHash          = 900104
+	MachineGunHash      = 900105
+	GhostHash           = 900106
+	WarlockHoodHash     = 900201
+	WarlockGlovesHash   = 900202
+	WarlockRobesHash    = 900203
+	WarlockRobes2Hash   = 900204
+	WarlockBootsHash    = 900205
+	WarlockBondHash     = 900206
+	WarlockArtifactHash = 900207
+	TitanHelmetHash     = 900301
+)
+
+// Bucket hashes of the equipment slots, see bungie.PopulateBucketHashLookup
+const (
+	primaryBucket    = 1498876634
+	specialBucket    = 2465295065
+	heavyBucket      = 953998645
+	ghostBucket      = 4023194814
+	helmetBucket     = 3448274439
+	armsBucket       = 3551918588
+	chestBucket      = 14239492
+	legsBucket       = 20886954
+	classArmorBucket = 1585787867
+	artifactBucket   = 434908299
+)
+
+// ItemMetadata is the tier and class of the items in the fixtures, it is used in place of the item
+// metadata from the database in tests.
+func ItemMetadata() map[uint]*bungie.ItemMetadata {
+
+	anyClass := bungie.UnknownClassEnum
+	return map[uint]*bungie.ItemMetadata{
+		StrangeCoinHash:     {TierType: bungie.CurrencyTier, ClassType: anyClass},
+		MoteOfLightHash:     {TierType: bungie.CurrencyTier, ClassType: anyClass},
+		SpinmetalHash:       {TierType: bungie.CommonTier, ClassType: anyClass},
+		RareEngramHash:      {TierType: bungie.RareTier, ClassType: anyClass},
+		LegendaryEngramHash: {TierType: bungie.SuperiorTier, ClassType: anyClass},
+		ExoticEngramHash:    {TierType: bungie.ExoticTier, ClassType: anyClass},
+		AutoRifleHash:       {TierType: bungie.SuperiorTier, ClassType: anyClass},
+		ScoutRifleHash:      {TierType: bungie.SuperiorTier, ClassType: anyClass},
+		SniperRifleHash:     {TierType: bungie.SuperiorTier, ClassType: anyClass},
+		RocketHash:          {TierType: bungie.SuperiorTier, ClassType: anyClass},
+		MachineGunHash:      {TierType: bungie.SuperiorTier, ClassType: anyClass},
+		GjallarhornHash:     {TierType: bungie.ExoticTier, ClassType: anyClass, Icon: "/common/destiny_content/icons/gjallarhorn.jpg"},
+		GhostHash:           {TierType: bungie.SuperiorTier, ClassType: anyClass},
+		WarlockHoodHash:     {TierType: bungie.SuperiorTier, ClassType: bungie.WarlockEnum},
+		WarlockGlovesHash:   {TierType: bungie.SuperiorTier, ClassType: bungie.WarlockEnum},
+		WarlockRobesHash:    {TierType: bungie.SuperiorTier, ClassType: bungie.WarlockEnum},
+		WarlockRobes2Hash:   {TierType: bungie.SuperiorTier, ClassType: bungie.WarlockEnum},
+		WarlockBootsHash:    {TierType: bungie.SuperiorTier, ClassType: bungie.WarlockEnum},
+		WarlockBondHash:     {TierType: bungie.SuperiorTier, ClassType: bungie.WarlockEnum},
+		WarlockArtifactHash: {TierType: bungie.SuperiorTier, ClassType: bungie.WarlockEnum},
+		TitanHelmetHash:     {TierType: bungie.SuperiorTier, ClassType: bungie.TitanEnum},
+	}
+}
+
+// EngramHashes are the hashes of the engrams in the fixtures, like the engram hashes loaded from the
+// database.
+func EngramHashes() map[uint]bool {
+	return map[uint]bool{RareEngramHash: true, LegendaryEngramHash: true, ExoticEngramHash: true}
+}
+
+// Fixtures will create a fresh copy of the fixture accounts, see GuardianToken, FullVaultToken, and
+// NoDestinyToken.
+func Fixtures() []*Account {
+
+	lastPlayed := time.Date(2017, time.September, 1, 20, 0, 0, 0, time.UTC)
+
+	guardianXbox := &DestinyAccount{
+		Membership: bungie.Membership{MembershipType: bungie.XBOX, MembershipID: GuardianXboxMembershipID, DisplayName: "Fake Guardian"},
+		Characters: bungie.CharacterList{
+			character(GuardianXboxMembershipID, bungie.XBOX, "2305843009200000001", bungie.WARLOCK, bungie.WarlockEnum,
+				bungie.AWOKEN, bungie.FEMALE, 335, lastPlayed),
+			character(GuardianXboxMembershipID, bungie.XBOX, "2305843009200000002", bungie.TITAN, bungie.TitanEnum,
+				bungie.EXO, bungie.MALE, 320, lastPlayed.Add(-26*time.Hour)),
+			character(GuardianXboxMembershipID, bungie.XBOX, "2305843009200000003", bungie.HUNTER, bungie.HunterEnum,
+				bungie.HUMAN, bungie.FEMALE, 310, lastPlayed.Add(-10*24*time.Hour)),
+		},
+		Items: bungie.ItemList{
+			stack(StrangeCoinHash, 20, 1),
+			stack(StrangeCoinHash, 150, -1),
+			stack(MoteOfLightHash, 5, 0),
+			stack(MoteOfLightHash, 40, -1),
+			stack(SpinmetalHash, 12, 2),
+
+			instance(LegendaryEngramHash, "6917529000000000001", 0, 0, 0, false),
+			instance(LegendaryEngramHash, "6917529000000000002", 0, 0, 0, false),
+			instance(ExoticEngramHash, "6917529000000000003", 0, 0, 1, false),
+			instance(RareEngramHash, "6917529000000000004", 0, 0, 2, false),
+			instance(LegendaryEngramHash, "6917529000000000005", 0, 0, -1, false),
+
+			// The warlock's equipped gear
+			instance(AutoRifleHash, "6917529000000000101", primaryBucket, 335, 0, true),
+			instance(SniperRifleHash, "6917529000000000102", specialBucket, 335, 0, true),
+			instance(RocketHash, "6917529000000000103", heavyBucket, 330, 0, true),
+			instance(GhostHash, "6917529000000000104", ghostBucket, 335, 0, true),
+			instance(WarlockHoodHash, "6917529000000000105", helmetBucket, 335, 0, true),
+			instance(WarlockGlovesHash, "6917529000000000106", armsBucket, 335, 0, true),
+			instance(WarlockRobesHash, "6917529000000000107", chestBucket, 335, 0, true),
+			instance(WarlockBootsHash, "6917529000000000108", legsBucket, 335, 0, true),
+			instance(WarlockBondHash, "6917529000000000109", classArmorBucket, 335, 0, true),
+			instance(WarlockArtifactHash, "6917529000000000110", artifactBucket, 335, 0, true),
+
+			// Higher light gear for the warlock in the vault and on the titan
+			instance(ScoutRifleHash, "6917529000000000111", primaryBucket, 340, -1, false),
+			instance(WarlockRobes2Hash, "6917529000000000112", chestBucket, 340, -1, false),
+			instance(GjallarhornHash, "6917529000000000113", heavyBucket, 350, 1, true),
+			instance(MachineGunHash, "6917529000000000114", heavyBucket, 320, 1, false),
+			instance(RocketHash, "6917529000000000115", heavyBucket, 310, 1, false),
+			instance(TitanHelmetHash, "6917529000000000116", helmetBucket, 345, -1, false),
+		},
+	}
+
+	guardianPSN := &DestinyAccount{
+		Membership: bungie.Membership{MembershipType: bungie.PSN, MembershipID: GuardianPSNMembershipID, DisplayName: "Fake-Guardian"},
+		Characters: bungie.CharacterList{
+			character(GuardianPSNMembershipID, bungie.PSN, "2305843009200000004", bungie.HUNTER, bungie.HunterEnum,
+				bungie.HUMAN, bungie.MALE, 300, lastPlayed.Add(-48*time.Hour)),
+		},
+		Items: bungie.ItemList{
+			stack(StrangeCoinHash, 7, 0),
+		},
+	}
+
+	fullVault := &DestinyAccount{
+		Membership: bungie.Membership{MembershipType: bungie.PSN, MembershipID: FullVaultMembershipID, DisplayName: "Vault Hoarder"},
+		Characters: bungie.CharacterList{
+			character(FullVaultMembershipID, bungie.PSN, "2305843009200000005", bungie.WARLOCK, bungie.WarlockEnum,
+				bungie.HUMAN, bungie.MALE, 330, lastPlayed),
+		},
+		Items: bungie.ItemList{
+			stack(StrangeCoinHash, 500, -1),
+			instance(LegendaryEngramHash, "6917529000000000201", 0, 0, 0, false),
+			instance(ExoticEngramHash, "6917529000000000202", 0, 0, 0, false),
+		},
+		VaultCapacity: 1,
+	}
+
+	return []*Account{
+		{AccessToken: GuardianToken, Destiny: []*DestinyAccount{guardianXbox, guardianPSN}},
+		{AccessToken: FullVaultToken, Destiny: []*DestinyAccount{fullVault}},
+		{AccessToken: NoDestinyToken, Destiny: []*DestinyAccount{}},
+	}
+}
+
+func character(membershipID string, membershipType uint, characterID string, classHash, classType, raceHash, genderHash, light uint, lastPlayed time.Time) *bungie.Character {
+	return &bungie.Character{
+		CharacterBase: &bungie.CharacterBase{
+			MembershipID:   membershipID,
+			MembershipType: membershipType,
+			CharacterID:    characterID,
+			DateLastPlayed: lastPlayed,
+			PowerLevel:     light,
+			RaceHash:       raceHash,
+			GenderHash:     genderHash,
+			ClassHash:      classHash,
+			ClassType:      classType,
+		},
+	}
+}
+
+// stack is a stack of a stackable item like a material or currency.
+func stack(itemHash, quantity uint, characterIndex int) *bungie.Item {
+	return &bungie.Item{ItemHash: itemHash, ItemID: "0", Quantity: quantity, CharacterIndex: characterIndex}
+}
+
+// instance is a single instanced item like an engram or a piece of gear, gear has a bucket and light.
+func instance(itemHash uint, itemID string, bucketHash, light uint, characterIndex int, equipped bool) *bungie.Item {
+
+	item := &bungie.Item{ItemHash: itemHash, ItemID: itemID, Quantity: 1, CharacterIndex: characterIndex, BucketHash: bucketHash}
+	item.PrimaryStat.Value = light
+	if equipped {
+		item.TransferStatus = bungie.ItemIsEquipped
+	}
+
+	return item
+}
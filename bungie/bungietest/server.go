@@ -0,0 +1,395 @@
+// Package bungietest implements a fake Bungie.net server for local development and tests. It
+// implements the endpoints used by the bungie package: loading the current account, loading the
+// items of a Destiny account, and transferring and equipping items. The inventory of every account
+// is kept in memory and is changed by transfers and equips, so the result of an action can be checked
+// by loading the items again.
+//
+// The bungie package is pointed at the fake with BUNGIE_BASE_URL, or by setting bungie.BaseURL in
+// tests:
+//
+//	server := httptest.NewServer(bungietest.NewServer(bungietest.Fixtures()...))
+//	defer server.Close()
+//	bungie.BaseURL = server.URL
+package bungietest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rking788/guardian-helper/bungie"
+)
+
+// Bungie.net PlatformErrorCodes returned by the fake in addition to the ones defined by the bungie
+// package.
+const (
+	ParameterParseFailureErrorCode                    = 7
+	DestinyAccountNotFoundErrorCode                   = 1601
+	DestinyCharacterNotFoundErrorCode                 = 1620
+	DestinyItemNotFoundErrorCode                      = 1623
+	DestinyCannotPerformActionOnEquippedItemErrorCode = 1634
+)
+
+// Account is a Bungie.net account identified by the access token used for it, it can have a Destiny
+// account on each platform.
+type Account struct {
+	AccessToken string
+	Destiny     []*DestinyAccount
+}
+
+// DestinyAccount is a Destiny account on a single platform with its characters and inventory.
+type DestinyAccount struct {
+	bungie.Membership
+	Characters bungie.CharacterList
+	// Items are the items on the characters and in the vault, the CharacterIndex of each item is the
+	// index of the character holding it or -1 for the vault. Stackable items like materials have an
+	// ItemID of "0" and are kept in a single stack per character.
+	Items bungie.ItemList
+	// VaultCapacity is the number of stacks of items that fit in the vault, zero for no limit
+	VaultCapacity int
+}
+
+// Server is the fake Bungie.net server, it is safe to use from multiple goroutines.
+type Server struct {
+	sync.Mutex
+	// accounts are the Bungie.net accounts by access token
+	accounts map[string]*Account
+}
+
+// NewServer will create a fake Bungie.net server with the provided accounts. The accounts are changed
+// by the requests to the server, use Fixtures for a fresh copy of the fixture accounts.
+func NewServer(accounts ...*Account) *Server {
+
+	server := &Server{accounts: make(map[string]*Account)}
+	for _, account := range accounts {
+		server.accounts[account.AccessToken] = account
+	}
+
+	return server
+}
+
+// Items will return a copy of the items in the Destiny account with the membership ID, nil is returned
+// if there is no Destiny account with that ID.
+func (s *Server) Items(membershipID string) bungie.ItemList {
+
+	s.Lock()
+	defer s.Unlock()
+
+	destiny := s.findDestinyAccount(membershipID)
+	if destiny == nil {
+		return nil
+	}
+
+	return copyItems(destiny.Items)
+}
+
+// ServeHTTP will answer a request to one of the Bungie.net endpoints, every request needs the access
+// token of one of the accounts as a bearer token.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	s.Lock()
+	defer s.Unlock()
+
+	account, ok := s.accounts[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	if !ok {
+		writeResponse(w, http.StatusUnauthorized, bungie.WebAuthRequiredErrorCode, "WebAuthRequired",
+			"Please sign-in to continue.", nil)
+		return
+	}
+
+	switch {
+	case r.Method == "GET" && r.URL.Path == bungie.GetCurrentAccountEndpoint:
+		currentAccount(w, account)
+	case r.Method == "GET" && strings.HasSuffix(r.URL.Path, "/Items"):
+		items(w, r, account)
+	case r.Method == "POST" && r.URL.Path == bungie.TransferItemEndpointURL:
+		transferItem(w, r, account)
+	case r.Method == "POST" && r.URL.Path == bungie.EquipItemEndpointURL:
+		equipItem(w, r, account)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) findDestinyAccount(membershipID string) *DestinyAccount {
+	for _, account := range s.accounts {
+		for _, destiny := range account.Destiny {
+			if destiny.MembershipID == membershipID {
+				return destiny
+			}
+		}
+	}
+	return nil
+}
+
+func currentAccount(w http.ResponseWriter, account *Account) {
+
+	memberships := make([]map[string]interface{}, 0, len(account.Destiny))
+	for _, destiny := range account.Destiny {
+		memberships = append(memberships, map[string]interface{}{
+			"membershipType": destiny.MembershipType,
+			"membershipId":   destiny.MembershipID,
+			"displayName":    destiny.DisplayName,
+		})
+	}
+
+	writeSuccess(w, map[string]interface{}{"destinyMemberships": memberships})
+}
+
+// items will list the items of the Destiny account in a path like
+// /d1/Platform/Destiny/{membershipType}/Account/{membershipId}/Items.
+func items(w http.ResponseWriter, r *http.Request, account *Account) {
+
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/d1/Platform/Destiny/"), "/")
+	if len(parts) != 4 || parts[1] != "Account" {
+		http.NotFound(w, r)
+		return
+	}
+
+	membershipType, _ := strconv.ParseUint(parts[0], 10, 32)
+	destiny := account.destinyAccount(uint(membershipType), parts[2])
+	if destiny == nil {
+		writeError(w, DestinyAccountNotFoundErrorCode, "DestinyAccountNotFound",
+			"We were unable to find your Destiny account information.")
+		return
+	}
+
+	writeSuccess(w, &bungie.ItemsResponse{
+		Data: &bungie.ItemsData{Items: copyItems(destiny.Items), Characters: destiny.Characters},
+	})
+}
+
+// transferRequest is the body of a TransferItem request, see bungie.transferItem.
+type transferRequest struct {
+	ItemReferenceHash uint   `json:"itemReferenceHash"`
+	StackSize         uint   `json:"stackSize"`
+	TransferToVault   bool   `json:"transferToVault"`
+	ItemID            string `json:"itemId"`
+	CharacterID       string `json:"characterId"`
+	MembershipType    uint   `json:"membershipType"`
+}
+
+// transferItem will move an item between a character and the vault. Like Bungie.net, items can only
+// be moved between a character and the vault and equipped items cannot be moved.
+func transferItem(w http.ResponseWriter, r *http.Request, account *Account) {
+
+	request := &transferRequest{}
+	err := json.NewDecoder(r.Body).Decode(request)
+	if err != nil {
+		writeError(w, ParameterParseFailureErrorCode, "ParameterParseFailure", "Unable to parse the request body.")
+		return
+	}
+
+	destiny, characterIndex, ok := findCharacter(w, account, request.MembershipType, request.CharacterID)
+	if !ok {
+		return
+	}
+
+	sourceIndex, destinationIndex := -1, characterIndex
+	if request.TransferToVault {
+		sourceIndex, destinationIndex = characterIndex, -1
+	}
+
+	item := destiny.findItem(request.ItemReferenceHash, request.ItemID, sourceIndex)
+	if item == nil || item.Quantity < request.StackSize || request.StackSize == 0 {
+		writeError(w, DestinyItemNotFoundErrorCode, "DestinyItemNotFound",
+			"The item requested was not found.")
+		return
+	} else if item.TransferStatus == bungie.ItemIsEquipped {
+		writeError(w, DestinyCannotPerformActionOnEquippedItemErrorCode, "DestinyCannotPerformActionOnEquippedItem",
+			"You cannot perform this action on an equipped item.")
+		return
+	}
+
+	stack := destiny.findItem(item.ItemHash, "0", destinationIndex)
+	if !isStack(item) {
+		stack = nil
+	}
+	if destinationIndex == -1 && stack == nil && destiny.vaultIsFull() {
+		writeError(w, bungie.DestinyNoRoomInDestinationErrorCode, "DestinyNoRoomInDestination",
+			"There are no item slots available to transfer this item.")
+		return
+	}
+
+	switch {
+	case !isStack(item):
+		item.CharacterIndex = destinationIndex
+	case stack != nil:
+		stack.Quantity += request.StackSize
+		item.Quantity -= request.StackSize
+	default:
+		moved := *item
+		moved.Quantity = request.StackSize
+		moved.CharacterIndex = destinationIndex
+		destiny.Items = append(destiny.Items, &moved)
+		item.Quantity -= request.StackSize
+	}
+	if item.Quantity == 0 {
+		destiny.removeItem(item)
+	}
+
+	writeSuccess(w, 0)
+}
+
+// equipRequest is the body of an EquipItem request, see bungie.equipItem.
+type equipRequest struct {
+	ItemID         string `json:"itemId"`
+	CharacterID    string `json:"characterId"`
+	MembershipType uint   `json:"membershipType"`
+}
+
+// equipItem will equip an item that is on the character, the item equipped in the same bucket is
+// unequipped.
+func equipItem(w http.ResponseWriter, r *http.Request, account *Account) {
+
+	request := &equipRequest{}
+	err := json.NewDecoder(r.Body).Decode(request)
+	if err != nil {
+		writeError(w, ParameterParseFailureErrorCode, "ParameterParseFailure", "Unable to parse the request body.")
+		return
+	}
+
+	destiny, characterIndex, ok := findCharacter(w, account, request.MembershipType, request.CharacterID)
+	if !ok {
+		return
+	}
+
+	var item *bungie.Item
+	for _, candidate := range destiny.Items {
+		if candidate.ItemID == request.ItemID && candidate.CharacterIndex == characterIndex && !isStack(candidate) {
+			item = candidate
+		}
+	}
+	if item == nil {
+		writeError(w, DestinyItemNotFoundErrorCode, "DestinyItemNotFound",
+			"The item requested was not found.")
+		return
+	}
+
+	for _, other := range destiny.Items {
+		if other.CharacterIndex == characterIndex && other.BucketHash == item.BucketHash &&
+			other.TransferStatus == bungie.ItemIsEquipped {
+			other.TransferStatus = bungie.CanTransfer
+		}
+	}
+	item.TransferStatus = bungie.ItemIsEquipped
+
+	writeSuccess(w, 0)
+}
+
+// findCharacter will find the Destiny account on the platform and the index of the character in it,
+// the error response is written if either of them is not found.
+func findCharacter(w http.ResponseWriter, account *Account, membershipType uint, characterID string) (*DestinyAccount, int, bool) {
+
+	for _, destiny := range account.Destiny {
+		if destiny.MembershipType != membershipType {
+			continue
+		}
+
+		for index, character := range destiny.Characters {
+			if character.CharacterBase.CharacterID == characterID {
+				return destiny, index, true
+			}
+		}
+	}
+
+	writeError(w, DestinyCharacterNotFoundErrorCode, "DestinyCharacterNotFound",
+		"We were unable to find the requested Destiny character.")
+	return nil, 0, false
+}
+
+func (account *Account) destinyAccount(membershipType uint, membershipID string) *DestinyAccount {
+	for _, destiny := range account.Destiny {
+		if destiny.MembershipType == membershipType && destiny.MembershipID == membershipID {
+			return destiny
+		}
+	}
+	return nil
+}
+
+// findItem will find the item at the character index, or in the vault for -1. Instanced items are
+// found by their ID and stackable items by their hash.
+func (destiny *DestinyAccount) findItem(itemHash uint, itemID string, characterIndex int) *bungie.Item {
+
+	for _, item := range destiny.Items {
+		if item.CharacterIndex != characterIndex || item.ItemHash != itemHash {
+			continue
+		}
+		if itemID == "" || itemID == "0" || item.ItemID == itemID {
+			return item
+		}
+	}
+
+	return nil
+}
+
+func (destiny *DestinyAccount) removeItem(removed *bungie.Item) {
+
+	items := make(bungie.ItemList, 0, len(destiny.Items))
+	for _, item := range destiny.Items {
+		if item != removed {
+			items = append(items, item)
+		}
+	}
+	destiny.Items = items
+}
+
+func (destiny *DestinyAccount) vaultIsFull() bool {
+
+	if destiny.VaultCapacity == 0 {
+		return false
+	}
+
+	stacks := 0
+	for _, item := range destiny.Items {
+		if item.CharacterIndex == -1 {
+			stacks++
+		}
+	}
+
+	return stacks >= destiny.VaultCapacity
+}
+
+func isStack(item *bungie.Item) bool {
+	return item.ItemID == "0"
+}
+
+func copyItems(items bungie.ItemList) bungie.ItemList {
+
+	copied := make(bungie.ItemList, 0, len(items))
+	for _, item := range items {
+		itemCopy := *item
+		copied = append(copied, &itemCopy)
+	}
+
+	return copied
+}
+
+func writeSuccess(w http.ResponseWriter, response interface{}) {
+	writeResponse(w, http.StatusOK, bungie.SuccessErrorCode, "Success", "Ok", response)
+}
+
+func writeError(w http.ResponseWriter, errorCode int, errorStatus, message string) {
+	writeResponse(w, http.StatusOK, errorCode, errorStatus, message, nil)
+}
+
+// writeResponse will write the response in the envelope used by every Bungie.net endpoint.
+func writeResponse(w http.ResponseWriter, status, errorCode int, errorStatus, message string, response interface{}) {
+
+	body := map[string]interface{}{
+		"ErrorCode":       errorCode,
+		"ThrottleSeconds": 0,
+		"ErrorStatus":     errorStatus,
+		"Message":         message,
+		"MessageData":     map[string]string{},
+	}
+	if response != nil {
+		body["Response"] = response
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,118 @@
+package bungietest
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rking788/guardian-helper/bungie"
+)
+
+func newTestClient(accessToken string) (*bungie.Client, *Server, func()) {
+
+	fake := NewServer(Fixtures()...)
+	server := httptest.NewServer(fake)
+
+	client := bungie.NewClient(accessToken, "api-key")
+	client.BaseURL = server.URL
+
+	return client, fake, server.Close
+}
+
+func TestTransferRules(t *testing.T) {
+
+	client, fake, stop := newTestClient(GuardianToken)
+	defer stop()
+
+	warlock := "2305843009200000001"
+	tests := []struct {
+		body     map[string]interface{}
+		expected string
+	}{
+		{map[string]interface{}{"itemReferenceHash": AutoRifleHash, "stackSize": 1, "transferToVault": true,
+			"itemId": "6917529000000000101", "characterId": warlock, "membershipType": bungie.XBOX}, "DestinyCannotPerformActionOnEquippedItem"},
+		{map[string]interface{}{"itemReferenceHash": MoteOfLightHash, "stackSize": 6, "transferToVault": true,
+			"itemId": "0", "characterId": warlock, "membershipType": bungie.XBOX}, "DestinyItemNotFound"},
+		{map[string]interface{}{"itemReferenceHash": MoteOfLightHash, "stackSize": 1, "transferToVault": true,
+			"itemId": "0", "characterId": "1", "membershipType": bungie.XBOX}, "DestinyCharacterNotFound"},
+		{map[string]interface{}{"itemReferenceHash": MoteOfLightHash, "stackSize": 1, "transferToVault": true,
+			"itemId": "0", "characterId": warlock, "membershipType": bungie.PSN}, "DestinyCharacterNotFound"},
+	}
+
+	for _, test := range tests {
+		err := client.PostTransferItem(test.body)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("Expected %s for the transfer %v, got %v", test.expected, test.body, err)
+		}
+	}
+
+	err := client.PostTransferItem(map[string]interface{}{"itemReferenceHash": MoteOfLightHash, "stackSize": 5,
+		"transferToVault": true, "itemId": "0", "characterId": warlock, "membershipType": bungie.XBOX})
+	if err != nil {
+		t.Fatalf("Failed to transfer the motes of light: %s", err.Error())
+	}
+
+	motes := 0
+	for _, item := range fake.Items(GuardianXboxMembershipID) {
+		if item.ItemHash == MoteOfLightHash {
+			motes++
+			if item.CharacterIndex != -1 || item.Quantity != 45 {
+				t.Errorf("Expected all of the motes of light in a single stack in the vault, got %s", item)
+			}
+		}
+	}
+	if motes != 1 {
+		t.Errorf("Expected one stack of motes of light, got %d", motes)
+	}
+}
+
+func TestTransferToFullVault(t *testing.T) {
+
+	client, _, stop := newTestClient(FullVaultToken)
+	defer stop()
+
+	err := client.PostTransferItem(map[string]interface{}{"itemReferenceHash": ExoticEngramHash, "stackSize": 1,
+		"transferToVault": true, "itemId": "6917529000000000202", "characterId": "2305843009200000005", "membershipType": bungie.PSN})
+	if err != bungie.ErrNoRoomInDestination {
+		t.Errorf("Expected ErrNoRoomInDestination, got %v", err)
+	}
+}
+
+func TestEquipReplacesEquippedItem(t *testing.T) {
+
+	client, fake, stop := newTestClient(GuardianToken)
+	defer stop()
+
+	err := client.PostEquipItem(map[string]interface{}{"itemId": "6917529000000000115",
+		"characterId": "2305843009200000002", "membershipType": bungie.XBOX})
+	if err != nil {
+		t.Fatalf("Unexpected error equipping the rocket launcher: %v", err)
+	}
+
+	for _, item := range fake.Items(GuardianXboxMembershipID) {
+		equipped := item.TransferStatus == bungie.ItemIsEquipped
+		switch item.ItemID {
+		case "6917529000000000115":
+			if !equipped {
+				t.Error("Expected the rocket launcher to be equipped on the titan")
+			}
+		case "6917529000000000113":
+			if equipped {
+				t.Error("Expected Gjallarhorn to be unequipped")
+			}
+		}
+	}
+}
+
+func TestItemsAreCopies(t *testing.T) {
+
+	fake := NewServer(Fixtures()...)
+	fake.Items(GuardianXboxMembershipID)[0].Quantity = 1000
+
+	if quantity := fake.Items(GuardianXboxMembershipID)[0].Quantity; quantity == 1000 {
+		t.Error("Expected changes to the returned items to not change the inventory")
+	}
+	if items := fake.Items("unknown"); items != nil {
+		t.Errorf("Expected no items for an unknown membership, got %v", items)
+	}
+}
@@ -42,6 +42,19 @@ func (characters LastPlayedSort) Less(i, j int) bool {
 	return characters[i].CharacterBase.DateLastPlayed.Before(characters[j].CharacterBase.DateLastPlayed)
 }
 
+// ClassHashFromName will find the classHash value for the provided class name after applying
+// any of the known Alexa translations. The vault is not a class so false will be returned for it.
+func ClassHashFromName(name string) (uint, bool) {
+	hash, ok := classNameToHash[TranslateClassName(name)]
+	return hash, ok
+}
+
+// ClassNameFromHash will return the name of the class with the provided classHash value,
+// an empty string is returned if the hash is unknown.
+func ClassNameFromHash(hash uint) string {
+	return classHashToName[hash]
+}
+
 // findDestinationCharacter will find the first character matching the provided class name
 // or an error if the account doesn't have a class of the specified type.
 func findDestinationCharacter(characters CharacterList, class string) (*Character, error) {
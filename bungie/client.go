@@ -3,6 +3,7 @@ package bungie
 import (
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"strings"
 	"time"
@@ -14,6 +15,11 @@ type Client struct {
 	*http.Client
 	AccessToken string
 	APIToken    string
+	// BaseURL is the Bungie.net server the requests are sent to, new clients use the package BaseURL
+	BaseURL string
+	// MembershipType is the platform of the Destiny account to use when the Bungie.net account has
+	// more than one, zero will use the first account.
+	MembershipType uint
 }
 
 // NewClient is a convenience function for creating a new Bungie.net Client that
@@ -24,6 +30,7 @@ func NewClient(accessToken, apiToken string) *Client {
 		Client:      http.DefaultClient,
 		AccessToken: accessToken,
 		APIToken:    apiToken,
+		BaseURL:     BaseURL,
 	}
 }
 
@@ -44,15 +51,28 @@ func (c *Client) AuthenticationHeaders() map[string]string {
 	}
 }
 
+// send will send the request to Bungie.net. Network errors, like a refused connection or a timeout,
+// are returned as ErrUnavailable so they are reported the same way as a Bungie.net outage.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+
+	resp, err := c.Do(req)
+	if err != nil {
+		fmt.Println("Failed to send the request to Bungie.net: ", err.Error())
+		return nil, ErrUnavailable
+	}
+
+	return resp, nil
+}
+
 // GetCurrentAccount will request the user info for the current user
 // based on the OAuth token provided as part of the request.
 func (c *Client) GetCurrentAccount() (*GetAccountResponse, error) {
 
-	req, _ := http.NewRequest("GET", GetCurrentAccountEndpoint, nil)
+	req, _ := http.NewRequest("GET", c.BaseURL+GetCurrentAccountEndpoint, nil)
 	req.Header.Add("Content-Type", "application/json")
 	c.AddAuthHeaders(req)
 
-	itemsResponse, err := c.Do(req)
+	itemsResponse, err := c.send(req)
 	if err != nil {
 		fmt.Println("Failed to read the Items response from Bungie!: ", err.Error())
 		return nil, err
@@ -60,104 +80,134 @@ func (c *Client) GetCurrentAccount() (*GetAccountResponse, error) {
 	defer itemsResponse.Body.Close()
 
 	accountResponse := GetAccountResponse{}
-	json.NewDecoder(itemsResponse.Body).Decode(&accountResponse)
+	err = decodeResponse(itemsResponse, &accountResponse)
+	if err != nil {
+		return nil, err
+	}
 
 	return &accountResponse, nil
 }
 
+// Membership identifies the Destiny account linked to the current user's Bungie.net account.
+type Membership struct {
+	MembershipType uint   `json:"membership_type"`
+	MembershipID   string `json:"membership_id"`
+	DisplayName    string `json:"display_name"`
+}
+
+// GetCurrentMembership will find the Destiny membership for the current user on the client's platform,
+// see GetAccountResponse.DestinyMembership. An error is returned
+// if the Bungie.net account does not have a linked Destiny account.
+func (c *Client) GetCurrentMembership() (*Membership, error) {
+
+	currentAccount, err := c.GetCurrentAccount()
+	if err != nil {
+		return nil, err
+	}
+
+	membership := currentAccount.DestinyMembership(c.MembershipType)
+	if membership == nil {
+		return nil, ErrNoDestinyAccount
+	}
+
+	return membership, nil
+}
+
 // GetUserItems will make a request to the bungie API and retrieve all of the
 // items for a specific Destiny membership ID. This includes all of their characters
 // as well as the vault. The vault with have a character index of -1.
 func (c *Client) GetUserItems(membershipType uint, membershipID string) (*ItemsEndpointResponse, error) {
-	endpoint := fmt.Sprintf(ItemsEndpointFormat, membershipType, membershipID)
+	endpoint := c.BaseURL + fmt.Sprintf(ItemsEndpointFormat, membershipType, membershipID)
 
 	req, _ := http.NewRequest("GET", endpoint, nil)
 	req.Header.Add("Content-Type", "application/json")
 	c.AddAuthHeaders(req)
 
-	itemsResponse, err := c.Client.Do(req)
+	itemsResponse, err := c.send(req)
 	if err != nil {
 		return nil, err
 	}
 	defer itemsResponse.Body.Close()
 
 	itemsJSON := &ItemsEndpointResponse{}
-	json.NewDecoder(itemsResponse.Body).Decode(&itemsJSON)
+	err = decodeResponse(itemsResponse, itemsJSON)
+	if err != nil {
+		return nil, err
+	}
 
 	return itemsJSON, nil
 }
 
-// PostTransferItem is responsible for calling the Bungie.net API to transfer
-// an item from a source to a destination. This could be either a user's character
-// or the vault.
-func (c *Client) PostTransferItem(body map[string]interface{}) {
-
-	// TODO: This retry logic should probably be added to a middleware type function
-	retry := true
-	attempts := 0
-	for {
-		retry = false
-		jsonBody, _ := json.Marshal(body)
+// decodeResponse will decode the body of a Bungie.net response into the result. Unsuccessful responses
+// are returned as errors, ErrAuthorization is returned when the access token is rejected so it can be
+// told apart from outages (ErrUnavailable) and throttling (ErrThrottled).
+func decodeResponse(resp *http.Response, result interface{}) error {
 
-		req, _ := http.NewRequest("POST", TransferItemEndpointURL, strings.NewReader(string(jsonBody)))
-		req.Header.Add("Content-Type", "application/json")
-		c.AddAuthHeaders(req)
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
 
-		resp, err := c.Do(req)
-		if err != nil {
-			fmt.Println("Error transferring item: ", err.Error())
-			return
+	base := BaseResponse{}
+	json.Unmarshal(body, &base)
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return ErrAuthorization
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return ErrThrottled
+	case base.ErrorCode != 0:
+		if err := base.asError(); err != nil {
+			fmt.Println("Bungie.net request failed: ", err.Error())
+			return err
 		}
-		defer resp.Body.Close()
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return ErrUnavailable
+	}
 
-		var response BaseResponse
-		json.NewDecoder(resp.Body).Decode(&response)
-		if response.ErrorCode == 36 || response.ErrorStatus == "ThrottleLimitExceededMomentarily" {
-			time.Sleep(1 * time.Second)
-			retry = true
-		}
+	return json.Unmarshal(body, result)
+}
 
-		fmt.Printf("Response for transfer request: %+v\n", response)
-		attempts++
-		if retry == false || attempts >= 5 {
-			break
-		}
-	}
+// PostTransferItem is responsible for calling the Bungie.net API to transfer
+// an item from a source to a destination. This could be either a user's character
+// or the vault. ErrNoRoomInDestination will be returned if the destination is full.
+func (c *Client) PostTransferItem(body map[string]interface{}) error {
+	return c.postAction(TransferItemEndpointURL, body)
 }
 
 // PostEquipItem is responsible for calling the Bungie.net API to equip
 // an item on a specific character.
-func (c *Client) PostEquipItem(body map[string]interface{}) {
+func (c *Client) PostEquipItem(body map[string]interface{}) error {
+	return c.postAction(EquipItemEndpointURL, body)
+}
+
+// postAction will post an inventory action, like a transfer or equip, to the endpoint. The response is
+// checked by decodeResponse and the request is retried a few times if Bungie.net is throttling requests.
+func (c *Client) postAction(endpoint string, body map[string]interface{}) error {
+
+	jsonBody, _ := json.Marshal(body)
 
 	// TODO: This retry logic should probably be added to a middleware type function
-	retry := true
 	attempts := 0
 	for {
-		retry = false
-		jsonBody, _ := json.Marshal(body)
-
-		req, _ := http.NewRequest("POST", EquipItemEndpointURL, strings.NewReader(string(jsonBody)))
+		req, _ := http.NewRequest("POST", c.BaseURL+endpoint, strings.NewReader(string(jsonBody)))
 		req.Header.Add("Content-Type", "application/json")
 		c.AddAuthHeaders(req)
 
-		resp, err := c.Do(req)
+		resp, err := c.send(req)
 		if err != nil {
-			fmt.Println("Error equipping item: ", err.Error())
-			return
+			fmt.Printf("Error posting to %s: %s\n", endpoint, err.Error())
+			return err
 		}
-		defer resp.Body.Close()
 
-		var response BaseResponse
-		json.NewDecoder(resp.Body).Decode(&response)
-		if response.ErrorCode == 36 || response.ErrorStatus == "ThrottleLimitExceededMomentarily" {
-			time.Sleep(1 * time.Second)
-			retry = true
-		}
+		response := BaseResponse{}
+		err = decodeResponse(resp, &response)
+		resp.Body.Close()
 
-		fmt.Printf("Response for equip request: %+v\n", response)
 		attempts++
-		if retry == false || attempts >= 5 {
-			break
+		if err != ErrThrottled || attempts >= 5 {
+			fmt.Printf("Response for %s request: %+v\n", endpoint, response)
+			return err
 		}
+		time.Sleep(1 * time.Second)
 	}
 }
@@ -1,19 +1,35 @@
 package bungie
 
-// Constant API endpoints
+import (
+	"os"
+	"strings"
+)
+
+// Constant API endpoint paths, these are relative to the BaseURL of the Client making the request
 const (
-	//GetCurrentAccountEndpoint = "http://localhost:8000/account.json"
-	//ItemsEndpointFormat       = "http://localhost:8000/%d/%s/items.json"
-	//TransferItemEndpointURL           = "http://localhost:8000/d1/Platform/Destiny/TransferItem/"
-	//EquipItemEndpointURL              = "http://localhost:8000/d1/Platform/Destiny/EquipItem/"
-	GetCurrentAccountEndpoint         = "https://www.bungie.net/Platform/User/GetCurrentBungieAccount/"
-	ItemsEndpointFormat               = "https://www.bungie.net/d1/Platform/Destiny/%d/Account/%s/Items"
-	MembershipIDFromDisplayNameFormat = "https://www.bungie.net/d1/Platform/Destiny/SearchDestinyPlayer/%d/%s/"
-	TransferItemEndpointURL           = "https://www.bungie.net/d1/Platform/Destiny/TransferItem/"
-	EquipItemEndpointURL              = "https://www.bungie.net/d1/Platform/Destiny/EquipItem/"
+	GetCurrentAccountEndpoint         = "/Platform/User/GetCurrentBungieAccount/"
+	ItemsEndpointFormat               = "/d1/Platform/Destiny/%d/Account/%s/Items"
+	MembershipIDFromDisplayNameFormat = "/d1/Platform/Destiny/SearchDestinyPlayer/%d/%s/"
+	TransferItemEndpointURL           = "/d1/Platform/Destiny/TransferItem/"
+	EquipItemEndpointURL              = "/d1/Platform/Destiny/EquipItem/"
 	TrialsCurrentEndpoint             = "https://api.destinytrialsreport.com/currentMap"
+	// BungieNetBaseURL is the base for the relative paths of images like item icons, and the
+	// default base for API requests
+	BungieNetBaseURL = "https://www.bungie.net"
 )
 
+// BaseURL is the server that new Clients send API requests to. It can be changed with the
+// BUNGIE_BASE_URL environment variable to use a local stand-in for Bungie.net during development,
+// item icons are always loaded from BungieNetBaseURL.
+var BaseURL = baseURLFromEnvironment()
+
+func baseURLFromEnvironment() string {
+	if baseURL := os.Getenv("BUNGIE_BASE_URL"); baseURL != "" {
+		return strings.TrimSuffix(baseURL, "/")
+	}
+	return BungieNetBaseURL
+}
+
 // Destiny.TierType
 const (
 	UnknownTier  = uint(0)
@@ -25,6 +41,59 @@ const (
 	ExoticTier   = uint(6)
 )
 
+// Names used for the tiers of engrams and other items
+var tierTypeToName = map[uint]string{
+	CommonTier:   "uncommon",
+	RareTier:     "rare",
+	SuperiorTier: "legendary",
+	ExoticTier:   "exotic",
+}
+
+var tierNameToType = map[string]uint{
+	"uncommon":  CommonTier,
+	"common":    CommonTier,
+	"green":     CommonTier,
+	"rare":      RareTier,
+	"blue":      RareTier,
+	"legendary": SuperiorTier,
+	"purple":    SuperiorTier,
+	"exotic":    ExoticTier,
+	"yellow":    ExoticTier,
+}
+
+// TierTypeFromName will find the Destiny.TierType value for a tier name like "legendary" or "exotic".
+func TierTypeFromName(name string) (uint, bool) {
+	tier, ok := tierNameToType[name]
+	return tier, ok
+}
+
+// Names used for the platforms, these are the names used by PlatformName
+var platformNameToType = map[string]uint{
+	"xbox":         XBOX,
+	"xbox one":     XBOX,
+	"playstation":  PSN,
+	"play station": PSN,
+	"psn":          PSN,
+	"ps4":          PSN,
+}
+
+var membershipTypeToPlatformName = map[uint]string{
+	XBOX: "xbox",
+	PSN:  "playstation",
+}
+
+// PlatformFromName will find the BungieMembershipType for a platform name like "xbox" or "playstation".
+func PlatformFromName(name string) (uint, bool) {
+	membershipType, ok := platformNameToType[strings.ToLower(strings.TrimSpace(name))]
+	return membershipType, ok
+}
+
+// PlatformName will return the name of the platform for the BungieMembershipType, an empty string is
+// returned if the membership type is unknown.
+func PlatformName(membershipType uint) string {
+	return membershipTypeToPlatformName[membershipType]
+}
+
 // Destiny.TansferStatuses
 const (
 	CanTransfer         = 0
@@ -67,12 +136,23 @@ const (
 	EXO    = 898834093
 )
 
+var raceHashToName = map[uint]string{
+	AWOKEN: "awoken",
+	HUMAN:  "human",
+	EXO:    "exo",
+}
+
 // Hash values for Gender 'genderHash' JSON key
 const (
 	MALE   = 3111576190
 	FEMALE = 2204441813
 )
 
+var genderHashToName = map[uint]string{
+	MALE:   "male",
+	FEMALE: "female",
+}
+
 // Gender Enum values used in some of the Bungie API responses
 const (
 	MaleEnum          = 0
@@ -87,25 +167,3 @@ const (
 	BLIZZARD = uint(4)
 	DEMON    = uint(10)
 )
-
-// Alexa doesn't understand some of the dsetiny items or splits them into separate words
-// This will allow us to translate to the correct name before doing the lookup.
-var commonAlexaItemTranslations = map[string]string{
-	"spin metal":     "spinmetal",
-	"spin mental":    "spinmetal",
-	"passage coins":  "passage coin",
-	"strange coins":  "strange coin",
-	"exotic shards":  "exotic shard",
-	"worm spore":     "wormspore",
-	"3 of coins":     "three of coins",
-	"worms for":      "wormspore",
-	"worm for":       "wormspore",
-	"motes":          "mote of light",
-	"motes of light": "mote of light",
-	"spin middle":    "spinmetal",
-}
-
-var commonAlexaClassNameTrnaslations = map[string]string{
-	"fault": "vault",
-	"tatum": "titan",
-}
@@ -0,0 +1,14 @@
+package bungie
+
+// UseItemMetadata will replace the item metadata and engram hashes that are loaded from the database,
+// this lets the tests in the bungie_test package use the fixtures in bungietest without a database.
+func UseItemMetadata(metadata map[uint]*ItemMetadata, engrams map[uint]bool) {
+	itemMetadata = metadata
+	engramHashes = engrams
+}
+
+// Unexported functions used by the benchmarks in the bungie_test package
+var (
+	FindMaxLightLoadout = findMaxLightLoadout
+	ItemTierTypeFilter  = itemTierTypeFilter
+)
@@ -0,0 +1,268 @@
+package bungie_test
+
+import (
+	"math"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rking788/guardian-helper/bungie"
+	"github.com/rking788/guardian-helper/bungie/bungietest"
+	"github.com/rking788/guardian-helper/db"
+)
+
+// startFakeServer will start the fake Bungie.net server with the fixture accounts and send the
+// requests of new clients to it, the returned function stops the server.
+func startFakeServer() (*bungietest.Server, func()) {
+
+	fake := bungietest.NewServer(bungietest.Fixtures()...)
+	server := httptest.NewServer(fake)
+
+	baseURL, transferDelay := bungie.BaseURL, bungie.TransferDelay
+	bungie.BaseURL = server.URL
+	bungie.TransferDelay = 0
+	bungie.UseItemMetadata(bungietest.ItemMetadata(), bungietest.EngramHashes())
+	bungie.PopulateBucketHashLookup()
+
+	return fake, func() {
+		server.Close()
+		bungie.BaseURL, bungie.TransferDelay = baseURL, transferDelay
+		bungie.UseItemMetadata(nil, nil)
+	}
+}
+
+// fixtureItems is the items response for the fixture account on Xbox.
+func fixtureItems() *bungie.ItemsEndpointResponse {
+
+	destiny := bungietest.Fixtures()[0].Destiny[0]
+	return &bungie.ItemsEndpointResponse{
+		Response: &bungie.ItemsResponse{Data: &bungie.ItemsData{Items: destiny.Items, Characters: destiny.Characters}},
+	}
+}
+
+// quantities will total the quantity of the item at each character index, and the vault at -1.
+func quantities(items bungie.ItemList, itemHash uint) map[int]uint {
+
+	result := make(map[int]uint)
+	for _, item := range items {
+		if item.ItemHash == itemHash {
+			result[item.CharacterIndex] += item.Quantity
+		}
+	}
+
+	return result
+}
+
+// equipped will find the hashes of the items equipped on the character.
+func equipped(items bungie.ItemList, characterIndex int) map[uint]bool {
+
+	result := make(map[uint]bool)
+	for _, item := range items {
+		if item.CharacterIndex == characterIndex && item.TransferStatus == bungie.ItemIsEquipped {
+			result[item.ItemHash] = true
+		}
+	}
+
+	return result
+}
+
+func TestCountItemWithFakeServer(t *testing.T) {
+
+	_, stop := startFakeServer()
+	defer stop()
+
+	count, err := bungie.CountItem("strange coins", bungietest.StrangeCoinHash, bungietest.GuardianToken,
+		"en-US", db.DefaultUserPreferences(""))
+	if err != nil {
+		t.Fatalf("Failed to count the items: %s", err.Error())
+	}
+	if count.Total() != 170 || len(count.Locations) != 2 || count.Locations[0].CharacterClass != "titan" {
+		t.Errorf("Unexpected strange coin count: %+v", count.Locations)
+	}
+
+	prefs := db.DefaultUserPreferences("")
+	prefs.Platform = bungie.PSN
+	count, err = bungie.CountItem("strange coins", bungietest.StrangeCoinHash, bungietest.GuardianToken, "en-US", prefs)
+	if err != nil || count.Total() != 7 {
+		t.Errorf("Expected the strange coins on PlayStation, got %+v (%v)", count, err)
+	}
+}
+
+func TestTransferItemWithFakeServer(t *testing.T) {
+
+	fake, stop := startFakeServer()
+	defer stop()
+
+	result, err := bungie.TransferItem("strange coins", bungietest.StrangeCoinHash, bungietest.GuardianToken,
+		"", "hunter", 30, "en-US", db.DefaultUserPreferences(""))
+	if err != nil || result.Transferred != 30 {
+		t.Fatalf("Expected 30 strange coins to be transferred, got %+v (%v)", result, err)
+	}
+
+	coins := quantities(fake.Items(bungietest.GuardianXboxMembershipID), bungietest.StrangeCoinHash)
+	if coins[2] != 30 || coins[1] != 0 || coins[-1] != 140 {
+		t.Errorf("Unexpected strange coins after the transfer: %v", coins)
+	}
+}
+
+func TestEquipMaxLightGearWithFakeServer(t *testing.T) {
+
+	fake, stop := startFakeServer()
+	defer stop()
+
+	plan, err := bungie.PlanMaxLight(bungietest.GuardianToken, "en-US", db.DefaultUserPreferences(""))
+	if err != nil || plan.TransferCount != 3 || len(plan.Unequipped) != 1 {
+		t.Fatalf("Unexpected max light plan: %+v (%v)", plan, err)
+	}
+
+	result, err := bungie.EquipMaxLightGear(bungietest.GuardianToken, "en-US", db.DefaultUserPreferences(""))
+	if err != nil {
+		t.Fatalf("Failed to equip max light: %s", err.Error())
+	}
+	if result.CharacterClass != "warlock" || math.Abs(result.Light-337.9) > 0.001 {
+		t.Errorf("Unexpected max light loadout: %+v", result)
+	}
+
+	items := fake.Items(bungietest.GuardianXboxMembershipID)
+	warlock := equipped(items, 0)
+	for _, hash := range []uint{bungietest.ScoutRifleHash, bungietest.GjallarhornHash, bungietest.WarlockRobes2Hash} {
+		if !warlock[hash] {
+			t.Errorf("Expected item %d to be equipped on the warlock, equipped: %v", hash, warlock)
+		}
+	}
+	if titan := equipped(items, 1); !titan[bungietest.RocketHash] || titan[bungietest.GjallarhornHash] {
+		t.Errorf("Expected the titan's lowest light heavy to be equipped in place of Gjallarhorn: %v", titan)
+	}
+}
+
+func TestUnloadEngramsWithFakeServer(t *testing.T) {
+
+	fake, stop := startFakeServer()
+	defer stop()
+
+	count, err := bungie.PlanUnloadEngrams(bungietest.GuardianToken, bungie.UnknownTier, bungie.ExoticTier, db.DefaultUserPreferences(""))
+	if err != nil || count != 3 {
+		t.Errorf("Expected 3 engrams to unload while keeping exotics, got %d (%v)", count, err)
+	}
+
+	result, err := bungie.UnloadEngrams(bungietest.GuardianToken, bungie.UnknownTier, bungie.UnknownTier, db.DefaultUserPreferences(""))
+	if err != nil || len(result.Moved) != 3 || len(result.NotMoved) != 0 {
+		t.Fatalf("Unexpected unload result: %+v (%v)", result, err)
+	}
+	if result.Moved[0].TierName != "exotic" || result.Moved[1].Quantity != 2 {
+		t.Errorf("Unexpected engrams moved: %+v, %+v", result.Moved[0], result.Moved[1])
+	}
+
+	engrams := quantities(fake.Items(bungietest.GuardianXboxMembershipID), bungietest.LegendaryEngramHash)
+	if engrams[0] != 0 || engrams[-1] != 3 {
+		t.Errorf("Expected the legendary engrams in the vault: %v", engrams)
+	}
+
+	result, err = bungie.UnloadEngrams(bungietest.FullVaultToken, bungie.UnknownTier, bungie.UnknownTier, db.DefaultUserPreferences(""))
+	if err != nil || len(result.Moved) != 0 || len(result.NotMoved) != 2 {
+		t.Errorf("Expected no engrams to fit in a full vault, got %+v (%v)", result, err)
+	}
+}
+
+// memoryLoadoutStore keeps saved loadouts in memory so the tests do not need a database.
+type memoryLoadoutStore map[string]map[string][]byte
+
+func (store memoryLoadoutStore) Save(membershipID, name string, loadout []byte) error {
+	if store[membershipID] == nil {
+		store[membershipID] = make(map[string][]byte)
+	}
+	store[membershipID][name] = loadout
+	return nil
+}
+
+func (store memoryLoadoutStore) Load(membershipID, name string) ([]byte, error) {
+	return store[membershipID][name], nil
+}
+
+func (store memoryLoadoutStore) LoadAll(membershipID string) (map[string][]byte, error) {
+	return store[membershipID], nil
+}
+
+func TestSavedLoadoutWithFakeServer(t *testing.T) {
+
+	fake, stop := startFakeServer()
+	defer stop()
+	bungie.SetLoadoutStore(make(memoryLoadoutStore))
+
+	saved, err := bungie.SaveLoadout(bungietest.GuardianToken, " Raid ", db.DefaultUserPreferences(""))
+	if err != nil || saved.Name != "raid" || saved.CharacterClass != "warlock" || len(saved.Items) != 10 {
+		t.Fatalf("Unexpected saved loadout: %+v (%v)", saved, err)
+	}
+
+	if _, err = bungie.EquipMaxLightGear(bungietest.GuardianToken, "en-US", db.DefaultUserPreferences("")); err != nil {
+		t.Fatalf("Failed to equip max light: %s", err.Error())
+	}
+	if warlock := equipped(fake.Items(bungietest.GuardianXboxMembershipID), 0); warlock[bungietest.AutoRifleHash] {
+		t.Fatal("Expected max light to replace the saved primary weapon")
+	}
+
+	result, err := bungie.EquipSavedLoadout(bungietest.GuardianToken, "RAID", "en-US", db.DefaultUserPreferences(""))
+	if err != nil || len(result.Slots) != 10 {
+		t.Fatalf("Unexpected result equipping the saved loadout: %+v (%v)", result, err)
+	}
+	warlock := equipped(fake.Items(bungietest.GuardianXboxMembershipID), 0)
+	for _, hash := range []uint{bungietest.AutoRifleHash, bungietest.RocketHash, bungietest.WarlockRobesHash} {
+		if !warlock[hash] {
+			t.Errorf("Expected item %d from the saved loadout to be equipped, equipped: %v", hash, warlock)
+		}
+	}
+
+	list, err := bungie.SavedLoadouts(bungietest.GuardianToken, db.DefaultUserPreferences(""))
+	if err != nil || len(list) != 1 || list[0].Items[0].Bucket != bungie.Primary {
+		t.Errorf("Unexpected saved loadouts: %+v (%v)", list, err)
+	}
+
+	if _, err = bungie.EquipSavedLoadout(bungietest.GuardianToken, "crucible", "en-US", db.DefaultUserPreferences("")); err != bungie.ErrLoadoutNotFound {
+		t.Errorf("Expected ErrLoadoutNotFound for a loadout that was not saved, got %v", err)
+	}
+}
+
+func TestFakeServerAccountErrors(t *testing.T) {
+
+	_, stop := startFakeServer()
+	defer stop()
+
+	characters, err := bungie.CharacterSummary(bungietest.GuardianToken, db.DefaultUserPreferences(""))
+	if err != nil || len(characters) != 3 || !characters[0].LastPlayed.After(characters[1].LastPlayed.Add(time.Hour)) {
+		t.Errorf("Unexpected characters: %+v (%v)", characters, err)
+	}
+
+	if _, err = bungie.CharacterSummary(bungietest.NoDestinyToken, db.DefaultUserPreferences("")); err != bungie.ErrNoDestinyAccount {
+		t.Errorf("Expected ErrNoDestinyAccount without a Destiny account, got %v", err)
+	}
+	if _, err = bungie.CharacterSummary("expired", db.DefaultUserPreferences("")); err != bungie.ErrAuthorization {
+		t.Errorf("Expected ErrAuthorization for an unknown token, got %v", err)
+	}
+}
+
+func BenchmarkFiltering(b *testing.B) {
+
+	bungie.UseItemMetadata(bungietest.ItemMetadata(), bungietest.EngramHashes())
+	defer bungie.UseItemMetadata(nil, nil)
+	items := fixtureItems()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = items.Response.Data.Items.FilterItems(bungie.ItemTierTypeFilter, bungie.ExoticTier)
+	}
+}
+
+func BenchmarkMaxLight(b *testing.B) {
+
+	bungie.UseItemMetadata(bungietest.ItemMetadata(), bungietest.EngramHashes())
+	defer bungie.UseItemMetadata(nil, nil)
+	bungie.PopulateBucketHashLookup()
+	itemsResponse := fixtureItems()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bungie.FindMaxLightLoadout(itemsResponse, 0)
+	}
+}
@@ -45,6 +45,8 @@ type Item struct {
 type ItemMetadata struct {
 	TierType  uint
 	ClassType uint
+	// Icon is the path to the item's icon relative to BungieNetBaseURL
+	Icon string
 }
 
 func (i *Item) String() string {
@@ -99,7 +101,9 @@ func itemHashFilter(item *Item, itemHash interface{}) bool {
 // otherwise false.
 func itemHashesFilter(item *Item, hashList interface{}) bool {
 	for _, hash := range hashList.([]uint) {
-		return itemHashFilter(item, hash)
+		if itemHashFilter(item, hash) {
+			return true
+		}
 	}
 
 	return false
@@ -115,6 +119,11 @@ func itemCharacterIndexFilter(item *Item, characterIndex interface{}) bool {
 	return item.CharacterIndex == characterIndex.(int)
 }
 
+// itemNotCharacterIndexFilter will filter out all items at the specified character index
+func itemNotCharacterIndexFilter(item *Item, characterIndex interface{}) bool {
+	return item.CharacterIndex != characterIndex.(int)
+}
+
 // itemIsEngramFilter will return true if the item represents an engram; otherwise false.
 func itemIsEngramFilter(item *Item, wantEngram interface{}) bool {
 	isEngram := false
@@ -130,10 +139,21 @@ func itemTierTypeFilter(item *Item, tierType interface{}) bool {
 	return itemMetadata[item.ItemHash].TierType == tierType.(uint)
 }
 
+// itemNotTierTypeFilter is a filter that will filter out items that are of the specified tier.
 func itemNotTierTypeFilter(item *Item, tierType interface{}) bool {
 	return itemMetadata[item.ItemHash].TierType != tierType.(uint)
 }
 
+// itemTierType will return the tier of the item from the item metadata or UnknownTier
+// if there is no metadata for the item.
+func itemTierType(item *Item) uint {
+	if metadata, ok := itemMetadata[item.ItemHash]; ok {
+		return metadata.TierType
+	}
+
+	return UnknownTier
+}
+
 // itemClassTypeFilter will filter out all items that are not equippable by the specified class
 func itemClassTypeFilter(item *Item, classType interface{}) bool {
 	// TODO: Is this correct? 3 is UNKNOWN class type, that seems to be what is used for class agnostic items.
@@ -150,3 +170,15 @@ func (data *ItemsData) characterClassNameAtIndex(index int) string {
 		return classHashToName[data.Characters[index].CharacterBase.ClassHash]
 	}
 }
+
+// ItemIconURL will return the full URL of the icon for the item with the provided hash, or an
+// empty string if the item does not have an icon.
+func ItemIconURL(itemHash uint) string {
+
+	metadata, ok := itemMetadata[itemHash]
+	if !ok || metadata.Icon == "" {
+		return ""
+	}
+
+	return BungieNetBaseURL + metadata.Icon
+}
@@ -3,6 +3,9 @@ package bungie
 import (
 	"fmt"
 	"sort"
+
+	"github.com/rking788/guardian-helper/db"
+	"github.com/rking788/guardian-helper/i18n"
 )
 
 // Loadout will hold all items for a unique set of weapons, armor, ghost, class item, and artifact
@@ -12,31 +15,91 @@ func (l Loadout) calculateLightLevel() float64 {
 
 	light := 0.0
 
-	light += float64(l[Primary].PrimaryStat.Value) * 0.12
-	light += float64(l[Special].PrimaryStat.Value) * 0.12
-	light += float64(l[Heavy].PrimaryStat.Value) * 0.12
-	light += float64(l[Ghost].PrimaryStat.Value) * 0.08
+	light += l.bucketLight(Primary) * 0.12
+	light += l.bucketLight(Special) * 0.12
+	light += l.bucketLight(Heavy) * 0.12
+	light += l.bucketLight(Ghost) * 0.08
 
-	light += float64(l[Helmet].PrimaryStat.Value) * 0.10
-	light += float64(l[Arms].PrimaryStat.Value) * 0.10
-	light += float64(l[Chest].PrimaryStat.Value) * 0.10
-	light += float64(l[Legs].PrimaryStat.Value) * 0.10
-	light += float64(l[ClassArmor].PrimaryStat.Value) * 0.08
-	light += float64(l[Artifact].PrimaryStat.Value) * 0.08
+	light += l.bucketLight(Helmet) * 0.10
+	light += l.bucketLight(Arms) * 0.10
+	light += l.bucketLight(Chest) * 0.10
+	light += l.bucketLight(Legs) * 0.10
+	light += l.bucketLight(ClassArmor) * 0.08
+	light += l.bucketLight(Artifact) * 0.08
 
 	return light
 }
 
+// bucketLight is the light of the item in the bucket, zero if the loadout has no item in that bucket.
+func (l Loadout) bucketLight(bucket EquipmentBucket) float64 {
+
+	if item := l[bucket]; item != nil {
+		return float64(item.PrimaryStat.Value)
+	}
+
+	return 0
+}
+
+// toSlice will list the items in the loadout in bucket order, empty buckets are left out.
 func (l Loadout) toSlice() []*Item {
 
 	result := make([]*Item, 0, Artifact-Primary)
 	for i := Primary; i <= Artifact; i++ {
-		result = append(result, l[i])
+		if l[i] != nil {
+			result = append(result, l[i])
+		}
 	}
 
 	return result
 }
 
+// MaxLightPlan describes the changes required to equip a loadout on a character.
+type MaxLightPlan struct {
+	CharacterClass string  `json:"character_class"`
+	Light          float64 `json:"light"`
+	// TransferCount is the number of items that need to be moved to the character
+	TransferCount int `json:"transfer_count"`
+	// Unequipped are the items that will be unequipped from other characters
+	Unequipped []*UnequippedItem `json:"unequipped"`
+}
+
+// UnequippedItem is an item that must be unequipped from a different character before it can be moved.
+type UnequippedItem struct {
+	ItemName       string `json:"item_name"`
+	CharacterClass string `json:"character_class"`
+}
+
+// planLoadout will describe the transfers and swaps that equipLoadout will perform for the provided loadout.
+func planLoadout(loadout Loadout, destinationIndex int, data *ItemsData, l *i18n.Localizer) *MaxLightPlan {
+
+	plan := &MaxLightPlan{
+		CharacterClass: data.characterClassNameAtIndex(destinationIndex),
+		Light:          loadout.calculateLightLevel(),
+		Unequipped:     make([]*UnequippedItem, 0),
+	}
+
+	for _, item := range loadout.toSlice() {
+		if item == nil || item.CharacterIndex == destinationIndex {
+			continue
+		}
+
+		plan.TransferCount++
+		if item.TransferStatus == ItemIsEquipped {
+			name, err := db.GetLocalizedItemName(item.ItemHash, l.Language())
+			if err != nil {
+				name = l.Sprintf("item.gear")
+			}
+
+			plan.Unequipped = append(plan.Unequipped, &UnequippedItem{
+				ItemName:       name,
+				CharacterClass: data.characterClassNameAtIndex(item.CharacterIndex),
+			})
+		}
+	}
+
+	return plan
+}
+
 func findMaxLightLoadout(itemsResponse *ItemsEndpointResponse, destinationIndex int) Loadout {
 	// Start by filtering all items that are NOT exotics
 	destinationClassType := itemsResponse.Response.Data.Characters[destinationIndex].CharacterBase.ClassType
@@ -107,8 +170,11 @@ func equipLoadout(loadout Loadout, destinationIndex int, itemsResponse *ItemsEnd
 	// TODO: This should swap any items that are currently equipped on other characters
 	// to prepare them to be transferred
 	for bucket, item := range loadout {
-		if item.TransferStatus == ItemIsEquipped && item.CharacterIndex != destinationIndex {
-			swapEquippedItem(item, itemsResponse, bucket, membershipType, client)
+		if item != nil && item.TransferStatus == ItemIsEquipped && item.CharacterIndex != destinationIndex {
+			err := swapEquippedItem(item, itemsResponse, bucket, membershipType, client)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -120,14 +186,12 @@ func equipLoadout(loadout Loadout, destinationIndex int, itemsResponse *ItemsEnd
 	}
 
 	// Equip all items that were just transferred
-	equipItems(loadout.toSlice(), destinationIndex, characters, membershipType, client)
-
-	return nil
+	return equipItems(loadout.toSlice(), destinationIndex, characters, membershipType, client)
 }
 
 // swapEquippedItem is responsible for equipping a new item on a character that is not the destination
 // of a transfer. This way it free up the item to be equipped by the desired character.
-func swapEquippedItem(item *Item, itemsResponse *ItemsEndpointResponse, bucket EquipmentBucket, membershipType uint, client *Client) {
+func swapEquippedItem(item *Item, itemsResponse *ItemsEndpointResponse, bucket EquipmentBucket, membershipType uint, client *Client) error {
 
 	// TODO: Currently filtering out exotics to make it easier
 	// This should be more robust. There is no guarantee the character already has an exotic
@@ -141,7 +205,7 @@ func swapEquippedItem(item *Item, itemsResponse *ItemsEndpointResponse, bucket E
 		// TODO: If there are no other items from the specified character, then we need to figure out
 		// an item to be transferred from the vault
 		fmt.Println("No other items on the specified character, not currently setup to transfer new choices from the vault...")
-		return
+		return nil
 	}
 
 	// Lowest light to highest
@@ -151,12 +215,16 @@ func swapEquippedItem(item *Item, itemsResponse *ItemsEndpointResponse, bucket E
 	// the highest light item will be the last item in the slice.
 	itemToEquip := reverseLightSortedItems[0]
 	character := itemsResponse.Response.Data.Characters[item.CharacterIndex]
-	equipItem(itemToEquip, character, membershipType, client)
+	return equipItem(itemToEquip, character, membershipType, client)
 }
 
 func moveLoadoutToCharacter(loadout Loadout, destinationIndex int, characters []*Character, membershipType uint, client *Client) error {
 
-	transferItem(loadout.toSlice(), characters, characters[destinationIndex], membershipType, -1, client)
+	_, failed := transferItem(loadout.toSlice(), characters, characters[destinationIndex], membershipType, -1, client)
+	if len(failed) > 0 {
+		// The remaining items can still be equipped, so this is not treated as a failure
+		fmt.Printf("Failed to move %d items to the destination character\n", len(failed))
+	}
 
 	return nil
 }
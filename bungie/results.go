@@ -0,0 +1,269 @@
+package bungie
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rking788/guardian-helper/db"
+	"github.com/rking788/guardian-helper/i18n"
+)
+
+// Errors returned by the inventory actions when the request cannot be completed
+var (
+	// ErrUnknownItem is returned when the item name does not match any Destiny item.
+	ErrUnknownItem = errors.New("No item found with the provided name")
+	// ErrCharacterNotFound is returned when the account does not have a character of the
+	// requested class.
+	ErrCharacterNotFound = errors.New("No character of the requested class on this account")
+)
+
+// ItemLocation is the quantity of an item on a single character or in the vault.
+type ItemLocation struct {
+	// CharacterClass is the English class name of the character, or "vault"
+	CharacterClass string `json:"character_class"`
+	Quantity       uint   `json:"quantity"`
+}
+
+// ItemCount is the result of counting an item on all characters and in the vault.
+type ItemCount struct {
+	// ItemName is in the language of the locale used to count the item
+	ItemName string `json:"item_name"`
+	ItemHash uint   `json:"item_hash"`
+	IconURL  string `json:"icon_url"`
+	// Locations are the quantities on each character in the order returned by Bungie.net followed
+	// by the vault, locations without any of the item are left out.
+	Locations []*ItemLocation `json:"locations"`
+	// Family holds the total for each item when an item family like "planetary materials" was
+	// counted, the Locations are empty in that case.
+	Family []*ItemTotal `json:"family,omitempty"`
+}
+
+// ItemTotal is the total quantity of a single item in a family across all characters and the vault.
+type ItemTotal struct {
+	ItemName string `json:"item_name"`
+	Quantity uint   `json:"quantity"`
+}
+
+// Total is the quantity of the item in all locations, or of all of the items in a family.
+func (count *ItemCount) Total() uint {
+
+	total := uint(0)
+	for _, location := range count.Locations {
+		total += location.Quantity
+	}
+	for _, item := range count.Family {
+		total += item.Quantity
+	}
+
+	return total
+}
+
+// IsFamily is true if an item family was counted instead of a single item.
+func (count *ItemCount) IsFamily() bool {
+	return count.Family != nil
+}
+
+// TransferResult describes the outcome of transferring an item to a character or the vault.
+type TransferResult struct {
+	// ItemName is in the language of the locale used for the transfer
+	ItemName string `json:"item_name"`
+	ItemHash uint   `json:"item_hash"`
+	IconURL  string `json:"icon_url"`
+	// DestinationClass is the English class name of the destination character, or "vault"
+	DestinationClass string `json:"destination_class"`
+	// Requested is the quantity that was asked for, -1 if all of the item should be transferred
+	Requested int `json:"requested"`
+	// Transferred is the quantity that was moved to the destination
+	Transferred uint `json:"transferred"`
+	// Locations are the quantities on each character and in the vault before the transfer, if it is
+	// empty the user does not have any of the item and nothing was transferred.
+	Locations []*ItemLocation `json:"locations"`
+}
+
+// Partial is true if fewer items were transferred than requested because the other characters and
+// the vault did not have enough.
+func (result *TransferResult) Partial() bool {
+	return result.Requested != -1 && result.Transferred < uint(result.Requested)
+}
+
+// LoadoutResult describes the max light loadout that was equipped on a character.
+type LoadoutResult struct {
+	// CharacterClass is the English class name of the character the loadout was equipped on
+	CharacterClass string  `json:"character_class"`
+	Light          float64 `json:"light"`
+	// Slots are the equipped items in equipment bucket order
+	Slots []*LoadoutSlot `json:"slots"`
+	// IconURL is the icon of the primary weapon
+	IconURL string `json:"icon_url"`
+}
+
+// LoadoutSlot is the item equipped in a single equipment bucket.
+type LoadoutSlot struct {
+	Bucket   EquipmentBucket `json:"bucket"`
+	ItemHash uint            `json:"item_hash"`
+	// ItemName is in the language of the locale, empty if the item is unknown
+	ItemName string `json:"item_name"`
+	Light    uint   `json:"light"`
+}
+
+// UnloadResult describes the engrams that were moved to the vault.
+type UnloadResult struct {
+	// Filtered is true if only some tiers of engrams were considered
+	Filtered bool `json:"filtered"`
+	// Moved are the engrams that were moved to the vault by tier
+	Moved []*EngramCount `json:"moved"`
+	// NotMoved are the engrams that could not be moved, usually because the vault is full
+	NotMoved []*EngramCount `json:"not_moved"`
+}
+
+// EngramCount is the number of engrams of a single tier.
+type EngramCount struct {
+	Tier uint `json:"tier"`
+	// TierName is the English name of the tier, like "legendary", empty if the tier is unknown
+	TierName string `json:"tier_name"`
+	Quantity uint   `json:"quantity"`
+}
+
+// CharacterDetails describes one of the user's characters. The race, gender, and class are English
+// names and are empty if they are unknown.
+type CharacterDetails struct {
+	Class      string    `json:"class"`
+	Race       string    `json:"race"`
+	Gender     string    `json:"gender"`
+	Light      uint      `json:"light"`
+	LastPlayed time.Time `json:"last_played"`
+}
+
+// Inventory is every item on the user's characters and in the vault.
+type Inventory struct {
+	Membership *Membership         `json:"membership"`
+	Characters []*CharacterDetails `json:"characters"`
+	Items      []*InventoryItem    `json:"items"`
+}
+
+// InventoryItem is a single item, or stack of items, in the user's inventory.
+type InventoryItem struct {
+	ItemHash uint   `json:"item_hash"`
+	ItemID   string `json:"item_id"`
+	Quantity uint   `json:"quantity"`
+	// Light is the primary stat of the item, zero for items without one
+	Light uint `json:"light"`
+	Tier  uint `json:"tier"`
+	// CharacterClass is the English class name of the character holding the item, or "vault"
+	CharacterClass string `json:"character_class"`
+	Equipped       bool   `json:"equipped"`
+	IconURL        string `json:"icon_url"`
+}
+
+// itemLocations will total the quantity of the items on each character and in the vault. Characters
+// are listed in the order they are returned by Bungie and the vault is always last.
+func itemLocations(items ItemList, data *ItemsData) []*ItemLocation {
+
+	quantities := make(map[int]uint)
+	for _, item := range items {
+		quantities[item.CharacterIndex] += item.Quantity
+	}
+
+	indices := make([]int, 0, len(quantities))
+	for index := range quantities {
+		indices = append(indices, index)
+	}
+	sort.Slice(indices, func(i, j int) bool {
+		// The vault has index -1 and should be after all of the characters
+		if indices[i] == -1 || indices[j] == -1 {
+			return indices[j] == -1 && indices[i] != -1
+		}
+		return indices[i] < indices[j]
+	})
+
+	locations := make([]*ItemLocation, 0, len(indices))
+	for _, index := range indices {
+		locations = append(locations, &ItemLocation{
+			CharacterClass: strings.ToLower(data.characterClassNameAtIndex(index)),
+			Quantity:       quantities[index],
+		})
+	}
+
+	return locations
+}
+
+// engramCounts will convert the number of engrams of each tier into a list with the highest
+// tiers first.
+func engramCounts(countsByTier map[uint]uint) []*EngramCount {
+
+	tiers := make([]uint, 0, len(countsByTier))
+	for tier := range countsByTier {
+		tiers = append(tiers, tier)
+	}
+	sort.Sort(sort.Reverse(uintSlice(tiers)))
+
+	counts := make([]*EngramCount, 0, len(tiers))
+	for _, tier := range tiers {
+		counts = append(counts, &EngramCount{
+			Tier:     tier,
+			TierName: tierTypeToName[tier],
+			Quantity: countsByTier[tier],
+		})
+	}
+
+	return counts
+}
+
+// loadoutResult will list the items in the loadout with their names in the Localizer's language.
+func loadoutResult(loadout Loadout, characterClass string, l *i18n.Localizer) *LoadoutResult {
+
+	result := &LoadoutResult{
+		CharacterClass: strings.ToLower(characterClass),
+		Light:          loadout.calculateLightLevel(),
+		Slots:          make([]*LoadoutSlot, 0, len(loadout)),
+	}
+	for bucket := Primary; bucket <= Artifact; bucket++ {
+		item, ok := loadout[bucket]
+		if !ok || item == nil {
+			continue
+		}
+
+		name, err := db.GetLocalizedItemName(item.ItemHash, l.Language())
+		if err != nil {
+			name = ""
+		}
+		result.Slots = append(result.Slots, &LoadoutSlot{
+			Bucket:   bucket,
+			ItemHash: item.ItemHash,
+			ItemName: name,
+			Light:    item.PrimaryStat.Value,
+		})
+	}
+	if primary, ok := loadout[Primary]; ok && primary != nil {
+		result.IconURL = ItemIconURL(primary.ItemHash)
+	}
+
+	return result
+}
+
+// details will describe the character with the English names of the race, gender, and class.
+func (base *CharacterBase) details() *CharacterDetails {
+	return &CharacterDetails{
+		Class:      classHashToName[base.ClassHash],
+		Race:       raceHashToName[base.RaceHash],
+		Gender:     genderHashToName[base.GenderHash],
+		Light:      base.PowerLevel,
+		LastPlayed: base.DateLastPlayed,
+	}
+}
+
+// inventoryItem will describe an item from the items endpoint.
+func inventoryItem(item *Item, data *ItemsData) *InventoryItem {
+	return &InventoryItem{
+		ItemHash:       item.ItemHash,
+		ItemID:         item.ItemID,
+		Quantity:       item.Quantity,
+		Light:          item.PrimaryStat.Value,
+		Tier:           itemTierType(item),
+		CharacterClass: strings.ToLower(data.characterClassNameAtIndex(item.CharacterIndex)),
+		Equipped:       item.TransferStatus == ItemIsEquipped,
+		IconURL:        ItemIconURL(item.ItemHash),
+	}
+}
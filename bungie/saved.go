@@ -0,0 +1,243 @@
+package bungie
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rking788/guardian-helper/db"
+	"github.com/rking788/guardian-helper/i18n"
+)
+
+// Errors returned for saved loadouts
+var (
+	// ErrLoadoutNotFound is returned when there is no saved loadout with the requested name.
+	ErrLoadoutNotFound = errors.New("No saved loadout with the provided name")
+	// ErrLoadoutItemsMissing is returned when none of the items in a saved loadout can be equipped,
+	// usually because they were dismantled or belong to a different class.
+	ErrLoadoutItemsMissing = errors.New("None of the items in the saved loadout were found")
+)
+
+// SavedLoadout is a set of equipped items that was saved with a name so it can be equipped again
+// later. The items are identified by their instance ID so the same copy is equipped even if the
+// user has more than one.
+type SavedLoadout struct {
+	Name string `json:"name"`
+	// CharacterClass is the English class name of the character the loadout was saved from
+	CharacterClass string  `json:"character_class"`
+	Light          float64 `json:"light"`
+	// Items are the saved items in equipment bucket order
+	Items []*SavedItem `json:"items"`
+}
+
+// SavedItem is the item saved in a single equipment bucket of a loadout.
+type SavedItem struct {
+	Bucket   EquipmentBucket `json:"bucket"`
+	ItemHash uint            `json:"item_hash"`
+	ItemID   string          `json:"item_id"`
+}
+
+// LoadoutStore is responsible for persisting the saved loadouts of each Destiny account as JSON.
+type LoadoutStore interface {
+	// Save will persist the loadout, replacing a loadout saved with the same name.
+	Save(membershipID, name string, loadout []byte) error
+	// Load will read the loadout with the name, nil is returned if it does not exist.
+	Load(membershipID, name string) ([]byte, error)
+	// LoadAll will read every loadout saved for the account by name.
+	LoadAll(membershipID string) (map[string][]byte, error)
+}
+
+// databaseLoadoutStore keeps the saved loadouts in the saved_loadouts table.
+type databaseLoadoutStore struct{}
+
+func (databaseLoadoutStore) Save(membershipID, name string, loadout []byte) error {
+	return db.SaveLoadout(membershipID, name, loadout)
+}
+
+func (databaseLoadoutStore) Load(membershipID, name string) ([]byte, error) {
+	return db.LoadLoadout(membershipID, name)
+}
+
+func (databaseLoadoutStore) LoadAll(membershipID string) (map[string][]byte, error) {
+	return db.LoadLoadouts(membershipID)
+}
+
+var loadouts LoadoutStore = databaseLoadoutStore{}
+
+// SetLoadoutStore will replace the store used to persist saved loadouts.
+func SetLoadoutStore(store LoadoutStore) {
+	loadouts = store
+}
+
+// LoadoutName will normalize the name of a saved loadout so names that only differ by case or
+// surrounding spaces refer to the same loadout.
+func LoadoutName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// SaveLoadout will save the items equipped on the default character, or the most recently played
+// character, with the provided name. A loadout already saved with the name is replaced.
+func SaveLoadout(accessToken, name string, prefs *db.UserPreferences) (*SavedLoadout, error) {
+
+	client := newUserClient(accessToken, prefs)
+
+	itemsChannel := make(chan *AllItemsMsg)
+	go GetAllItemsForCurrentUser(client, itemsChannel)
+
+	itemsJSON := <-itemsChannel
+	if itemsJSON.error != nil {
+		fmt.Println("Failed to read the Items response from Bungie!: ", itemsJSON.error.Error())
+		return nil, itemsJSON.error
+	}
+
+	data := itemsJSON.ItemsEndpointResponse.Response.Data
+	characterIndex := preferredCharacterIndex(data.Characters, prefs)
+	loadout := equippedLoadout(data.Items, characterIndex)
+
+	saved := &SavedLoadout{
+		Name:           LoadoutName(name),
+		CharacterClass: strings.ToLower(data.characterClassNameAtIndex(characterIndex)),
+		Light:          loadout.calculateLightLevel(),
+		Items:          make([]*SavedItem, 0, len(loadout)),
+	}
+	for bucket := Primary; bucket <= Artifact; bucket++ {
+		if item := loadout[bucket]; item != nil {
+			saved.Items = append(saved.Items, &SavedItem{Bucket: bucket, ItemHash: item.ItemHash, ItemID: item.ItemID})
+		}
+	}
+
+	body, err := json.Marshal(saved)
+	if err != nil {
+		return nil, err
+	}
+	err = loadouts.Save(itemsJSON.Membership.MembershipID, saved.Name, body)
+	if err != nil {
+		fmt.Println("Failed to save the loadout: ", err.Error())
+		return nil, err
+	}
+
+	return saved, nil
+}
+
+// SavedLoadouts will list the loadouts saved for the Destiny account on the preferred platform,
+// sorted by name.
+func SavedLoadouts(accessToken string, prefs *db.UserPreferences) ([]*SavedLoadout, error) {
+
+	membership, err := newUserClient(accessToken, prefs).GetCurrentMembership()
+	if err != nil {
+		return nil, err
+	}
+
+	saved, err := loadouts.LoadAll(membership.MembershipID)
+	if err != nil {
+		fmt.Println("Failed to load the saved loadouts: ", err.Error())
+		return nil, err
+	}
+
+	result := make([]*SavedLoadout, 0, len(saved))
+	for name, body := range saved {
+		loadout := &SavedLoadout{}
+		err = json.Unmarshal(body, loadout)
+		if err != nil {
+			fmt.Printf("Failed to read the saved loadout(%s): %s\n", name, err.Error())
+			continue
+		}
+		result = append(result, loadout)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result, nil
+}
+
+// EquipSavedLoadout will move the items of the saved loadout to the default character, or the most
+// recently played character, and equip them. Items that no longer exist or cannot be used by the
+// character are skipped. Item names in the result are in the language of the locale.
+func EquipSavedLoadout(accessToken, name, locale string, prefs *db.UserPreferences) (*LoadoutResult, error) {
+
+	client := newUserClient(accessToken, prefs)
+
+	itemsChannel := make(chan *AllItemsMsg)
+	go GetAllItemsForCurrentUser(client, itemsChannel)
+
+	itemsJSON := <-itemsChannel
+	if itemsJSON.error != nil {
+		fmt.Println("Failed to read the Items response from Bungie!: ", itemsJSON.error.Error())
+		return nil, itemsJSON.error
+	}
+
+	body, err := loadouts.Load(itemsJSON.Membership.MembershipID, LoadoutName(name))
+	if err != nil {
+		fmt.Println("Failed to load the saved loadout: ", err.Error())
+		return nil, err
+	} else if body == nil {
+		return nil, ErrLoadoutNotFound
+	}
+	saved := &SavedLoadout{}
+	err = json.Unmarshal(body, saved)
+	if err != nil {
+		return nil, err
+	}
+
+	data := itemsJSON.ItemsEndpointResponse.Response.Data
+	destinationIndex := preferredCharacterIndex(data.Characters, prefs)
+	loadout := savedItemsLoadout(saved, data, destinationIndex)
+	if len(loadout) == 0 {
+		return nil, ErrLoadoutItemsMissing
+	}
+
+	err = equipLoadout(loadout, destinationIndex, itemsJSON.ItemsEndpointResponse, itemsJSON.Membership.MembershipType, client)
+	if err != nil {
+		fmt.Println("Failed to equip the saved loadout: ", err.Error())
+		return nil, err
+	}
+
+	return loadoutResult(loadout, data.characterClassNameAtIndex(destinationIndex), i18n.For(locale)), nil
+}
+
+// equippedLoadout will find the items equipped on the character in each equipment bucket.
+func equippedLoadout(items ItemList, characterIndex int) Loadout {
+
+	loadout := make(Loadout)
+	for _, item := range items.FilterItems(itemCharacterIndexFilter, characterIndex) {
+		if item.TransferStatus != ItemIsEquipped {
+			continue
+		}
+
+		for bucket, bucketHash := range bucketHashLookup {
+			if item.BucketHash == bucketHash {
+				loadout[bucket] = item
+			}
+		}
+	}
+
+	return loadout
+}
+
+// savedItemsLoadout will find the saved items in the inventory, leaving out the items that no
+// longer exist and the armor of other classes.
+func savedItemsLoadout(saved *SavedLoadout, data *ItemsData, destinationIndex int) Loadout {
+
+	classType := data.Characters[destinationIndex].CharacterBase.ClassType
+	loadout := make(Loadout)
+	for _, savedItem := range saved.Items {
+		for _, item := range data.Items {
+			if item.ItemID != savedItem.ItemID || item.ItemHash != savedItem.ItemHash {
+				continue
+			}
+
+			if metadata, ok := itemMetadata[item.ItemHash]; ok && metadata.ClassType != UnknownClassEnum &&
+				metadata.ClassType != classType {
+				fmt.Printf("Skipping saved item(%s) that cannot be used by the character\n", item)
+				break
+			}
+			loadout[savedItem.Bucket] = item
+			break
+		}
+	}
+
+	return loadout
+}
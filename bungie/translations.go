@@ -0,0 +1,113 @@
+package bungie
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rking788/guardian-helper/db"
+)
+
+// Alexa doesn't understand some of the destiny items or splits them into separate words.
+// These translations are stored in the database and allow us to translate to the correct
+// name before doing the lookup.
+var translations = struct {
+	sync.RWMutex
+	items   map[string]string
+	classes map[string]string
+}{
+	items:   make(map[string]string),
+	classes: make(map[string]string),
+}
+
+// PopulateTranslations will load all of the item and class name translations from the database,
+// replacing any translations that were previously loaded.
+func PopulateTranslations() error {
+
+	items, err := db.LoadTranslations(db.ItemTranslation)
+	if err != nil {
+		fmt.Println("Error loading item translations: ", err.Error())
+		return err
+	}
+
+	classes, err := db.LoadTranslations(db.ClassTranslation)
+	if err != nil {
+		fmt.Println("Error loading class translations: ", err.Error())
+		return err
+	}
+
+	translations.Lock()
+	translations.items = items
+	translations.classes = classes
+	translations.Unlock()
+
+	fmt.Printf("Loaded %d item translations and %d class translations\n", len(items), len(classes))
+	return nil
+}
+
+// StartTranslationReloader will periodically reload the translations from the database so that
+// new translations are picked up without restarting the server. The translations that were already
+// loaded are kept if a reload fails. The returned function will stop reloading.
+func StartTranslationReloader(interval time.Duration) (stop func()) {
+
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				err := PopulateTranslations()
+				if err != nil {
+					fmt.Printf("Failed to reload translations, trying again in %s: %s\n", interval, err.Error())
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+		})
+	}
+}
+
+// IsClassName will return true if the name is one of the class names that can be
+// used as a source or destination for a transfer, including the vault.
+func IsClassName(name string) bool {
+	if name == "vault" {
+		return true
+	}
+
+	_, ok := classNameToHash[name]
+	return ok
+}
+
+// translateItemName will return the translated item name if Alexa commonly misinterprets the
+// provided name, otherwise the name is returned as is.
+func translateItemName(name string) string {
+	translations.RLock()
+	defer translations.RUnlock()
+
+	if translation, ok := translations.items[name]; ok {
+		return translation
+	}
+
+	return name
+}
+
+// TranslateClassName will return the translated class name if Alexa commonly misinterprets the
+// provided name, otherwise the name is returned as is.
+func TranslateClassName(name string) string {
+	translations.RLock()
+	defer translations.RUnlock()
+
+	if translation, ok := translations.classes[name]; ok {
+		return translation
+	}
+
+	return name
+}
@@ -0,0 +1,38 @@
+// Command fake-bungie runs the fake Bungie.net server from the bungietest package with the fixture
+// accounts, so the skill, the REST API, and guardian-cli can be tried locally without a Destiny
+// account. Point them at the fake with BUNGIE_BASE_URL and use one of the fixture access tokens.
+// Transfers and equips change the in-memory inventory until the server is restarted.
+//
+// Usage:
+//
+//	go run cmd/fake-bungie/main.go -addr :8000
+//	BUNGIE_BASE_URL=http://localhost:8000 go run cmd/guardian-cli/main.go -token guardian characters
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+
+	"github.com/rking788/guardian-helper/bungie/bungietest"
+)
+
+var addr = flag.String("addr", ":8000", "address to listen on")
+
+func main() {
+
+	flag.Parse()
+
+	fake := bungietest.NewServer(bungietest.Fixtures()...)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Printf("%s %s\n", r.Method, r.URL.Path)
+		fake.ServeHTTP(w, r)
+	})
+
+	fmt.Printf("Fake Bungie.net listening on %s with the access tokens: %s, %s, %s\n", *addr,
+		bungietest.GuardianToken, bungietest.FullVaultToken, bungietest.NoDestinyToken)
+	err := http.ListenAndServe(*addr, handler)
+	if err != nil {
+		fmt.Println("Failed to start the fake Bungie.net server: ", err.Error())
+	}
+}
@@ -0,0 +1,336 @@
+// Command guardian-cli runs the inventory actions and Trials of Osiris lookups of the skill from the
+// command line, which makes it possible to debug them against a real account without going through
+// Alexa. Results are printed to stdout as JSON, the logging from the other packages is written to
+// stderr so the output can be piped to tools like jq.
+//
+// The access token is a Bungie.net OAuth access token, it can also be set with BUNGIE_ACCESS_TOKEN.
+// BUNGIE_API_KEY is required like for the server, and the commands that change or count items load
+// item metadata from the database using DATABASE_URL. Requests are sent to the -base-url server, or
+// BUNGIE_BASE_URL, when it is set to use a local stand-in for Bungie.net.
+//
+// Usage:
+//
+//	go run cmd/guardian-cli/main.go [flags] <command> [arguments]
+//
+// Commands:
+//
+//	count <item>                         count an item on every character and in the vault
+//	transfer [-quantity n] [-source class] <destination> <item>
+//	                                     transfer an item to a character or the vault
+//	maxlight [-dry-run]                  equip the max light loadout
+//	unload-engrams [-dry-run] [-tier tier] [-keep-tier tier]
+//	                                     move engrams from the characters to the vault
+//	characters                           list the characters
+//	inventory                            list every item on the characters and in the vault
+//	trials map|week|weapons|my-weapons|weapon-types
+//	                                     look up Trials of Osiris stats
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/rking788/guardian-helper/bungie"
+	"github.com/rking788/guardian-helper/db"
+	"github.com/rking788/guardian-helper/i18n"
+	"github.com/rking788/guardian-helper/trials"
+)
+
+// options are the global flags shared by all of the commands.
+type options struct {
+	token  string
+	locale string
+	prefs  *db.UserPreferences
+}
+
+// command is a single subcommand, run returns the result that is printed as JSON.
+type command struct {
+	usage string
+	// inventory commands need an access token
+	inventory bool
+	// metadata commands also need the item metadata from the database
+	metadata bool
+	run      func(opts *options, args []string) (interface{}, error)
+}
+
+var commands = map[string]*command{
+	"count":          {usage: "count <item>", inventory: true, metadata: true, run: countItem},
+	"transfer":       {usage: "transfer [-quantity n] [-source class] <destination> <item>", inventory: true, metadata: true, run: transferItem},
+	"maxlight":       {usage: "maxlight [-dry-run]", inventory: true, metadata: true, run: maxLight},
+	"unload-engrams": {usage: "unload-engrams [-dry-run] [-tier tier] [-keep-tier tier]", inventory: true, metadata: true, run: unloadEngrams},
+	"characters":     {usage: "characters", inventory: true, run: characters},
+	"inventory":      {usage: "inventory", inventory: true, metadata: true, run: inventory},
+	"trials":         {usage: "trials map|week|weapons|my-weapons|weapon-types", run: trialsStats},
+}
+
+// errUsage is returned when the arguments are invalid, the usage has already been printed.
+var errUsage = errors.New("Invalid arguments")
+
+func main() {
+
+	// The other packages log to stdout, send that to stderr so only the results are on stdout
+	output := os.Stdout
+	os.Stdout = os.Stderr
+
+	err := run(os.Args[1:], output)
+	if err == errUsage {
+		os.Exit(2)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "Error: ", err.Error())
+		os.Exit(1)
+	}
+}
+
+// run will parse the global flags, run the command, and write the result to the output.
+func run(args []string, output io.Writer) error {
+
+	flags := flag.NewFlagSet("guardian-cli", flag.ContinueOnError)
+	token := flags.String("token", os.Getenv("BUNGIE_ACCESS_TOKEN"), "Bungie.net OAuth access token")
+	platform := flags.String("platform", "", "platform of the Destiny account, xbox or playstation")
+	character := flags.String("character", "", "character to use instead of the most recently played one")
+	locale := flags.String("locale", i18n.EnglishUS, "locale of item names in arguments and results")
+	baseURL := flags.String("base-url", "", "Bungie.net server to send requests to, like http://localhost:8000")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: guardian-cli [flags] <command> [arguments]\n\nCommands:")
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintln(os.Stderr, "  "+commands[name].usage)
+		}
+		fmt.Fprintln(os.Stderr, "\nFlags:")
+		flags.PrintDefaults()
+	}
+
+	err := flags.Parse(args)
+	if err != nil {
+		return errUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errUsage
+	}
+
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", flags.Arg(0))
+		flags.Usage()
+		return errUsage
+	}
+
+	opts := &options{token: *token, locale: i18n.For(*locale).Locale(), prefs: db.DefaultUserPreferences("")}
+	if *platform != "" {
+		opts.prefs.Platform, ok = bungie.PlatformFromName(strings.ToLower(*platform))
+		if !ok {
+			return errors.New("platform must be xbox or playstation")
+		}
+	}
+	if *character != "" {
+		opts.prefs.DefaultCharacter = strings.ToLower(*character)
+		if _, ok := bungie.ClassHashFromName(opts.prefs.DefaultCharacter); !ok {
+			return errors.New("character must be titan, hunter, or warlock")
+		}
+	}
+	if *baseURL != "" {
+		bungie.BaseURL = strings.TrimSuffix(*baseURL, "/")
+	}
+
+	if cmd.inventory && opts.token == "" {
+		return errors.New("An access token is required, use -token or BUNGIE_ACCESS_TOKEN")
+	}
+	if cmd.metadata {
+		err = loadItemMetadata()
+		if err != nil {
+			return err
+		}
+	}
+
+	result, err := cmd.run(opts, flags.Args()[1:])
+	if err != nil {
+		return err
+	}
+
+	body, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(output, string(body))
+
+	return err
+}
+
+// loadItemMetadata will load the lookup tables that the server loads at startup.
+func loadItemMetadata() error {
+
+	err := bungie.PopulateEngramHashes()
+	if err != nil {
+		return err
+	}
+	err = bungie.PopulateBucketHashLookup()
+	if err != nil {
+		return err
+	}
+	err = bungie.PopulateItemMetadata()
+	if err != nil {
+		return err
+	}
+
+	return bungie.PopulateTranslations()
+}
+
+func countItem(opts *options, args []string) (interface{}, error) {
+
+	if len(args) == 0 {
+		return nil, errors.New("count requires an item name")
+	}
+
+	return bungie.CountItem(itemName(args), 0, opts.token, opts.locale, opts.prefs)
+}
+
+func transferItem(opts *options, args []string) (interface{}, error) {
+
+	flags := flag.NewFlagSet("transfer", flag.ContinueOnError)
+	quantity := flags.Int("quantity", 0, "number of items to transfer, zero transfers all of them")
+	source := flags.String("source", "", "character or vault to transfer the items from")
+	if err := flags.Parse(args); err != nil {
+		return nil, errUsage
+	}
+
+	if flags.NArg() < 2 {
+		return nil, errors.New("transfer requires a destination and an item name")
+	} else if *quantity < 0 {
+		return nil, errors.New("quantity must not be negative")
+	}
+	destination := strings.ToLower(flags.Arg(0))
+	if !isCharacterName(destination) {
+		return nil, errors.New("destination must be titan, hunter, warlock, or vault")
+	} else if *source != "" && !isCharacterName(strings.ToLower(*source)) {
+		return nil, errors.New("source must be titan, hunter, warlock, or vault")
+	}
+
+	count := *quantity
+	if count == 0 {
+		count = -1
+	}
+
+	return bungie.TransferItem(itemName(flags.Args()[1:]), 0, opts.token, strings.ToLower(*source),
+		destination, count, opts.locale, opts.prefs)
+}
+
+func maxLight(opts *options, args []string) (interface{}, error) {
+
+	flags := flag.NewFlagSet("maxlight", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "describe the changes without moving or equipping anything")
+	if err := flags.Parse(args); err != nil {
+		return nil, errUsage
+	}
+
+	if *dryRun {
+		return bungie.PlanMaxLight(opts.token, opts.locale, opts.prefs)
+	}
+	return bungie.EquipMaxLightGear(opts.token, opts.locale, opts.prefs)
+}
+
+func unloadEngrams(opts *options, args []string) (interface{}, error) {
+
+	flags := flag.NewFlagSet("unload-engrams", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "count the engrams without moving them")
+	tierName := flags.String("tier", "", "only move engrams of this tier")
+	keepTierName := flags.String("keep-tier", "", "leave engrams of this tier on the characters")
+	if err := flags.Parse(args); err != nil {
+		return nil, errUsage
+	}
+
+	onlyTier, err := engramTier("tier", *tierName)
+	if err != nil {
+		return nil, err
+	}
+	keepTier, err := engramTier("keep-tier", *keepTierName)
+	if err != nil {
+		return nil, err
+	}
+
+	if *dryRun {
+		count, err := bungie.PlanUnloadEngrams(opts.token, onlyTier, keepTier, opts.prefs)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]uint{"count": count}, nil
+	}
+	return bungie.UnloadEngrams(opts.token, onlyTier, keepTier, opts.prefs)
+}
+
+func characters(opts *options, args []string) (interface{}, error) {
+	return bungie.CharacterSummary(opts.token, opts.prefs)
+}
+
+func inventory(opts *options, args []string) (interface{}, error) {
+	return bungie.GetInventory(opts.token, opts.prefs)
+}
+
+func trialsStats(opts *options, args []string) (interface{}, error) {
+
+	if len(args) != 1 {
+		return nil, errors.New("trials requires one of map, week, weapons, my-weapons, or weapon-types")
+	}
+
+	switch args[0] {
+	case "map":
+		return trials.GetCurrentMap()
+	case "weapons":
+		return trials.GetWeaponUsagePercentages()
+	case "weapon-types":
+		return trials.GetPopularWeaponTypes()
+	case "week", "my-weapons":
+		if opts.token == "" {
+			return nil, errors.New("An access token is required, use -token or BUNGIE_ACCESS_TOKEN")
+		}
+
+		client := bungie.NewClient(opts.token, os.Getenv("BUNGIE_API_KEY"))
+		client.MembershipType = opts.prefs.Platform
+		membership, err := client.GetCurrentMembership()
+		if err != nil {
+			return nil, err
+		}
+
+		if args[0] == "week" {
+			return trials.GetCurrentWeek(membership.MembershipID)
+		}
+		return trials.GetPersonalTopWeapons(membership.MembershipID, opts.locale)
+	}
+
+	return nil, fmt.Errorf("Unknown Trials stats: %s", args[0])
+}
+
+// itemName joins the remaining arguments so item names do not need to be quoted.
+func itemName(args []string) string {
+	return strings.ToLower(strings.Join(args, " "))
+}
+
+func isCharacterName(name string) bool {
+	_, ok := bungie.ClassHashFromName(name)
+	return ok || name == "vault"
+}
+
+// engramTier will find the tier for the name, UnknownTier is used if the name is empty.
+func engramTier(flagName, name string) (uint, error) {
+
+	if name == "" {
+		return bungie.UnknownTier, nil
+	}
+
+	tier, ok := bungie.TierTypeFromName(strings.ToLower(name))
+	if !ok {
+		return 0, fmt.Errorf("%s must be uncommon, rare, legendary, or exotic", flagName)
+	}
+
+	return tier, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rking788/guardian-helper/bungie"
+	"github.com/rking788/guardian-helper/db"
+)
+
+func TestRunRequiresAccessToken(t *testing.T) {
+
+	output := bytes.NewBufferString("")
+	err := run([]string{"-token", "", "count", "strange", "coins"}, output)
+	if err == nil || output.Len() != 0 {
+		t.Errorf("Expected an error without an access token, got %v", err)
+	}
+}
+
+func TestRunRejectsUnknownValues(t *testing.T) {
+
+	cases := [][]string{
+		{},
+		{"dance"},
+		{"-platform", "stadia", "characters"},
+		{"-character", "paladin", "characters"},
+	}
+	for _, args := range cases {
+		if err := run(args, bytes.NewBufferString("")); err == nil {
+			t.Errorf("Expected an error for the arguments %v", args)
+		}
+	}
+}
+
+func TestTransferArguments(t *testing.T) {
+
+	opts := &options{token: "token", prefs: db.DefaultUserPreferences("")}
+	cases := [][]string{
+		{"strange coins"},
+		{"paladin", "strange", "coins"},
+		{"-source", "paladin", "hunter", "strange", "coins"},
+		{"-quantity", "-1", "hunter", "strange", "coins"},
+	}
+	for _, args := range cases {
+		if _, err := transferItem(opts, args); err == nil {
+			t.Errorf("Expected an error for the transfer arguments %v", args)
+		}
+	}
+}
+
+func TestEngramTier(t *testing.T) {
+
+	tier, err := engramTier("tier", "Legendary")
+	if err != nil || tier != bungie.SuperiorTier {
+		t.Errorf("Expected the legendary tier, got %d (%v)", tier, err)
+	}
+
+	tier, err = engramTier("tier", "")
+	if err != nil || tier != bungie.UnknownTier {
+		t.Errorf("Expected the unknown tier without a name, got %d (%v)", tier, err)
+	}
+
+	if _, err = engramTier("keep-tier", "shiny"); err == nil {
+		t.Errorf("Expected an error for an unknown tier")
+	}
+}
+
+func TestItemName(t *testing.T) {
+
+	if name := itemName([]string{"Strange", "Coins"}); name != "strange coins" {
+		t.Errorf("Unexpected item name: %s", name)
+	}
+}
+
+func TestCommandRequirements(t *testing.T) {
+
+	cases := map[string]struct {
+		inventory bool
+		metadata  bool
+	}{
+		"count":          {true, true},
+		"transfer":       {true, true},
+		"maxlight":       {true, true},
+		"unload-engrams": {true, true},
+		"characters":     {true, false},
+		"inventory":      {true, true},
+		"trials":         {false, false},
+	}
+
+	if len(cases) != len(commands) {
+		t.Errorf("Expected %d commands, got %d", len(cases), len(commands))
+	}
+	for name, expected := range cases {
+		cmd, ok := commands[name]
+		if !ok {
+			t.Errorf("Missing the %s command", name)
+			continue
+		}
+		if cmd.inventory != expected.inventory || cmd.metadata != expected.metadata {
+			t.Errorf("Unexpected requirements for %s: inventory(%t) metadata(%t)", name, cmd.inventory, cmd.metadata)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Command interaction-model generates the Alexa interaction model for the skill from the intents
+// registered in alexa.InteractionModel. It writes the intent schema, sample utterances, and the
+// values of the custom slot types into the config directory, which can then be uploaded to the
+// Alexa developer portal. The item slot type is read from the database using DATABASE_URL, it is
+// skipped if the database is not available.
+//
+// Usage:
+//
+//	go run cmd/interaction-model/main.go -config config
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/rking788/guardian-helper/alexa"
+)
+
+var configDir = flag.String("config", "config", "directory to write the interaction model to")
+
+func main() {
+
+	flag.Parse()
+
+	err := alexa.ValidateInteractionModel()
+	if err != nil {
+		fmt.Println("Invalid interaction model: ", err.Error())
+		os.Exit(1)
+	}
+
+	err = writeFile(filepath.Join(*configDir, "intent_schema.json"), alexa.WriteIntentSchema)
+	if err != nil {
+		fmt.Println("Failed to write the intent schema: ", err.Error())
+		os.Exit(1)
+	}
+
+	err = writeFile(filepath.Join(*configDir, "sample_utterances.txt"), alexa.WriteSampleUtterances)
+	if err != nil {
+		fmt.Println("Failed to write the sample utterances: ", err.Error())
+		os.Exit(1)
+	}
+
+	for _, slotType := range alexa.SlotTypes {
+		values, err := slotType.SlotValues()
+		if err != nil {
+			fmt.Printf("Skipping %s, the values could not be loaded: %s\n", slotType.Name, err.Error())
+			continue
+		}
+
+		slotType := slotType
+		path := filepath.Join(*configDir, "custom-slot-types", slotType.File)
+		err = writeFile(path+".txt", func(w io.Writer) error {
+			return alexa.WriteSlotTypeValues(w, values)
+		})
+		if err == nil {
+			err = writeFile(path+".json", func(w io.Writer) error {
+				return alexa.WriteSlotTypeJSON(w, slotType, values)
+			})
+		}
+		if err != nil {
+			fmt.Printf("Failed to write the values for %s: %s\n", slotType.Name, err.Error())
+			os.Exit(1)
+		}
+	}
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fmt.Println("Writing ", path)
+	return write(f)
+}
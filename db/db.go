@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"sync"
 
 	"database/sql"
 
@@ -16,17 +18,46 @@ type LookupDB struct {
 	NameFromHashStmt *sql.Stmt
 	EngramHashStmt   *sql.Stmt
 	ItemMetadataStmt *sql.Stmt
+	ItemFamilyStmt   *sql.Stmt
+	ItemNamesStmt    *sql.Stmt
 }
 
 var db1 *LookupDB
 
+var (
+	itemNameMatcher     *NameMatcher
+	itemNameMatcherLock sync.Mutex
+)
+
 const (
 	// UnknownClassTable is the name of the table that will hold all the unknown class values provided by Alexa
 	UnknownClassTable = "unknown_classes"
 	// UnknownItemTable is the name of the table that will hold the unknown item name values passed by Alexa
 	UnknownItemTable = "unknown_items"
+
+	// ItemTranslation is the kind of translation used for item names
+	ItemTranslation = "item"
+	// ClassTranslation is the kind of translation used for character class names
+	ClassTranslation = "class"
+
+	// EnglishLanguage is the language of the item names in the items table
+	EnglishLanguage = "en"
 )
 
+// UnknownValue is a value provided by Alexa that could not be used along with the number of
+// times it has been received.
+type UnknownValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// Pronunciation is a phoneme override for a word that Alexa does not pronounce correctly.
+type Pronunciation struct {
+	Word     string
+	Alphabet string
+	Phoneme  string
+}
+
 // InitDatabase is in charge of preparing any Statements that will be commonly used as well
 // as setting up the database connection pool.
 func InitDatabase() error {
@@ -37,7 +68,9 @@ func InitDatabase() error {
 		return err
 	}
 
-	stmt, err := db.Prepare("SELECT item_hash FROM items WHERE item_name = $1 AND item_type_name NOT IN ('Material Exchange', '') ORDER BY max_stack_size DESC LIMIT 1")
+	// Names are compared without case so the hash matches the item slot values, see LoadItemSlotValues
+	stmt, err := db.Prepare("SELECT item_hash FROM items WHERE lower(item_name) = lower($1) AND item_type_name NOT IN ('Material Exchange', '') " +
+		"ORDER BY max_stack_size DESC, item_hash LIMIT 1")
 	if err != nil {
 		fmt.Println("DB error: ", err.Error())
 		return err
@@ -55,18 +88,32 @@ func InitDatabase() error {
 		return err
 	}
 
-	itemMetadataStmt, err := db.Prepare("SELECT item_hash, tier_type, class_type FROM items")
+	itemMetadataStmt, err := db.Prepare("SELECT item_hash, tier_type, class_type, icon FROM items")
 	if err != nil {
 		fmt.Println("DB error: ", err.Error())
 		return err
 	}
 
+	itemFamilyStmt, err := db.Prepare("SELECT f.item_hash, i.item_name FROM item_families f JOIN items i ON i.item_hash = f.item_hash WHERE f.family_name = $1")
+	if err != nil {
+		fmt.Println("DB prepare error: ", err.Error())
+		return err
+	}
+
+	itemNamesStmt, err := db.Prepare("SELECT DISTINCT item_name FROM items WHERE item_type_name NOT IN ('Material Exchange', '')")
+	if err != nil {
+		fmt.Println("DB prepare error: ", err.Error())
+		return err
+	}
+
 	db1 = &LookupDB{
 		Database:         db,
 		HashFromNameStmt: stmt,
 		NameFromHashStmt: nameFromHashStmt,
 		EngramHashStmt:   engramHashStmt,
 		ItemMetadataStmt: itemMetadataStmt,
+		ItemFamilyStmt:   itemFamilyStmt,
+		ItemNamesStmt:    itemNamesStmt,
 	}
 
 	return nil
@@ -131,7 +178,8 @@ func LoadItemMetadata() (*sql.Rows, error) {
 }
 
 // GetItemHashFromName is in charge of querying the database and reading
-// the item hash value for the given item name.
+// the item hash value for the given item name. If there is no exact match, the closest item name
+// by spelling and pronunciation will be used as long as it is a confident match.
 func GetItemHashFromName(itemName string) (uint, error) {
 
 	db, err := GetDBConnection()
@@ -144,6 +192,14 @@ func GetItemHashFromName(itemName string) (uint, error) {
 	var hash uint
 	err = row.Scan(&hash)
 
+	if err == sql.ErrNoRows {
+		match, ok := closestItemName(db, itemName)
+		if ok {
+			fmt.Printf("Matched unknown item name(%s) to item(%s)\n", itemName, match)
+			err = db.HashFromNameStmt.QueryRow(match).Scan(&hash)
+		}
+	}
+
 	if err == sql.ErrNoRows {
 		fmt.Println("Didn't find any transferrable items with that name: ", itemName)
 		InsertUnknownValueIntoTable(itemName, UnknownItemTable)
@@ -155,6 +211,107 @@ func GetItemHashFromName(itemName string) (uint, error) {
 	return hash, nil
 }
 
+// LookupItemHash will find the hash of the transferrable item with the given name, ignoring case. Unlike
+// GetItemHashFromName, the name is not matched to the closest item and unknown names are not
+// recorded, so it can be used for names that did not come from a user.
+func LookupItemHash(itemName string) (uint, error) {
+
+	db, err := GetDBConnection()
+	if err != nil {
+		return 0, err
+	}
+
+	var hash uint
+	err = db.HashFromNameStmt.QueryRow(itemName).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return 0, errors.New("No items found")
+	} else if err != nil {
+		return 0, err
+	}
+
+	return hash, nil
+}
+
+// closestItemName will find the known item name that is the closest match to the provided name.
+// The matcher is built the first time it is needed from all of the transferrable item names, if the
+// names cannot be loaded there is no match and loading is tried again on the next lookup.
+func closestItemName(db *LookupDB, itemName string) (string, bool) {
+
+	matcher, err := loadItemNameMatcher(db)
+	if err != nil {
+		fmt.Println("Failed to load item names for matching: ", err.Error())
+		return "", false
+	}
+
+	match, ok := matcher.BestMatch(itemName)
+	if !ok {
+		fmt.Printf("No confident match for item(%s), closest was: %s\n", itemName, match)
+	}
+
+	return match, ok
+}
+
+// loadItemNameMatcher will return the item name matcher, building it from the item names in the
+// database if it has not been built yet.
+func loadItemNameMatcher(db *LookupDB) (*NameMatcher, error) {
+
+	itemNameMatcherLock.Lock()
+	defer itemNameMatcherLock.Unlock()
+
+	if itemNameMatcher != nil {
+		return itemNameMatcher, nil
+	}
+
+	rows, err := db.ItemNamesStmt.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make([]string, 0, 1000)
+	for rows.Next() {
+		var name string
+		err = rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	itemNameMatcher = NewNameMatcher(names)
+	return itemNameMatcher, nil
+}
+
+// GetItemFamily will load all of the items that belong to the family with the specified name,
+// for example "planetary materials". The result maps item_hash values to the item names.
+// An empty map is returned if there is no family with the given name.
+func GetItemFamily(familyName string) (map[uint]string, error) {
+
+	db, err := GetDBConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.ItemFamilyStmt.Query(familyName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[uint]string)
+	for rows.Next() {
+		var hash uint
+		var name string
+		rows.Scan(&hash, &name)
+		result[hash] = name
+	}
+
+	return result, rows.Err()
+}
+
 // GetItemNameFromHash is in charge of querying the database and reading
 // the item name value for the given item hash.
 func GetItemNameFromHash(itemHash string) (string, error) {
@@ -179,6 +336,54 @@ func GetItemNameFromHash(itemHash string) (string, error) {
 	return name, nil
 }
 
+// GetLocalizedItemHash will find the item hash for an item name in the provided language, for example
+// "de". English names and names without a translation are looked up with GetItemHashFromName.
+func GetLocalizedItemHash(itemName, language string) (uint, error) {
+
+	if language == "" || language == EnglishLanguage {
+		return GetItemHashFromName(itemName)
+	}
+
+	conn, err := GetDBConnection()
+	if err != nil {
+		return 0, err
+	}
+
+	var hash uint
+	err = conn.Database.QueryRow("SELECT item_hash FROM localized_items WHERE language = $1 AND lower(item_name) = lower($2) LIMIT 1",
+		language, itemName).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return GetItemHashFromName(itemName)
+	} else if err != nil {
+		return 0, err
+	}
+
+	return hash, nil
+}
+
+// GetLocalizedItemName will find the name of the item in the provided language, if there is no
+// translation the English name is returned.
+func GetLocalizedItemName(itemHash uint, language string) (string, error) {
+
+	if language != "" && language != EnglishLanguage {
+		conn, err := GetDBConnection()
+		if err != nil {
+			return "", err
+		}
+
+		var name string
+		err = conn.Database.QueryRow("SELECT item_name FROM localized_items WHERE item_hash = $1 AND language = $2",
+			itemHash, language).Scan(&name)
+		if err == nil {
+			return name, nil
+		} else if err != sql.ErrNoRows {
+			return "", err
+		}
+	}
+
+	return GetItemNameFromHash(strconv.FormatUint(uint64(itemHash), 10))
+}
+
 // InsertUnknownValueIntoTable is a helper method for inserting a value into the specified table.
 // This is used when a value for a slot type is not usable. For example when a class name for a character
 // is not a valid Destiny class name.
@@ -189,5 +394,129 @@ func InsertUnknownValueIntoTable(value, tableName string) {
 		return
 	}
 
-	conn.Database.Exec("INSERT INTO "+tableName+" (value) VALUES(?)", value)
+	_, err = conn.Database.Exec("INSERT INTO "+tableName+" (value) VALUES($1)", value)
+	if err != nil {
+		fmt.Println("Failed to insert unknown value: ", err.Error())
+	}
+}
+
+// FindUnknownValues will load the most frequently received values from the specified unknown
+// value table, the most frequent values will be first.
+func FindUnknownValues(tableName string, limit int) ([]*UnknownValue, error) {
+
+	conn, err := GetDBConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Database.Query("SELECT value, COUNT(*) AS total FROM "+tableName+
+		" GROUP BY value ORDER BY total DESC, value LIMIT $1", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*UnknownValue, 0, limit)
+	for rows.Next() {
+		unknown := &UnknownValue{}
+		rows.Scan(&unknown.Value, &unknown.Count)
+		result = append(result, unknown)
+	}
+
+	return result, rows.Err()
+}
+
+// DeleteUnknownValue will remove all occurrences of the value from the specified unknown value table.
+// This should be done once a translation has been added for the value.
+func DeleteUnknownValue(value, tableName string) error {
+
+	conn, err := GetDBConnection()
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Database.Exec("DELETE FROM "+tableName+" WHERE value = $1", value)
+	return err
+}
+
+// LoadTranslations will load all of the translations of the specified kind (ItemTranslation or
+// ClassTranslation). The result maps the value provided by Alexa to the actual name.
+func LoadTranslations(kind string) (map[string]string, error) {
+
+	conn, err := GetDBConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Database.Query("SELECT alexa_value, translation FROM alexa_translations WHERE kind = $1", kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]string)
+	for rows.Next() {
+		var alexaValue, translation string
+		rows.Scan(&alexaValue, &translation)
+		result[alexaValue] = translation
+	}
+
+	return result, rows.Err()
+}
+
+// SaveTranslation will insert or update the translation of a value provided by Alexa to
+// an actual item or class name.
+func SaveTranslation(kind, alexaValue, translation string) error {
+
+	conn, err := GetDBConnection()
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Database.Exec("INSERT INTO alexa_translations (kind, alexa_value, translation) VALUES($1, $2, $3) "+
+		"ON CONFLICT (kind, alexa_value) DO UPDATE SET translation = EXCLUDED.translation", kind, alexaValue, translation)
+	return err
+}
+
+// LoadPronunciations will load all of the phoneme overrides used when building speech output.
+func LoadPronunciations() ([]*Pronunciation, error) {
+
+	conn, err := GetDBConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Database.Query("SELECT word, alphabet, phoneme FROM pronunciations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*Pronunciation, 0)
+	for rows.Next() {
+		pronunciation := &Pronunciation{}
+		rows.Scan(&pronunciation.Word, &pronunciation.Alphabet, &pronunciation.Phoneme)
+		result = append(result, pronunciation)
+	}
+
+	return result, rows.Err()
+}
+
+// ItemNameExists will check if there are any transferrable items with exactly the given name.
+func ItemNameExists(itemName string) (bool, error) {
+
+	db, err := GetDBConnection()
+	if err != nil {
+		return false, err
+	}
+
+	var hash uint
+	err = db.HashFromNameStmt.QueryRow(itemName).Scan(&hash)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
 }
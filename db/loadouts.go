@@ -0,0 +1,68 @@
+package db
+
+import (
+	"database/sql"
+)
+
+// SaveLoadout will save the JSON of the loadout for the Destiny account, replacing a loadout that was
+// already saved with the same name.
+func SaveLoadout(membershipID, name string, loadout []byte) error {
+
+	conn, err := GetDBConnection()
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Database.Exec("INSERT INTO saved_loadouts (membership_id, name, loadout) VALUES($1, $2, $3) "+
+		"ON CONFLICT (membership_id, name) DO UPDATE SET loadout = EXCLUDED.loadout, updated_at = now()",
+		membershipID, name, string(loadout))
+	return err
+}
+
+// LoadLoadout will read the JSON of the loadout saved with the name, nil is returned if there is no
+// loadout with that name.
+func LoadLoadout(membershipID, name string) ([]byte, error) {
+
+	conn, err := GetDBConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	var loadout string
+	err = conn.Database.QueryRow("SELECT loadout FROM saved_loadouts WHERE membership_id = $1 AND name = $2",
+		membershipID, name).Scan(&loadout)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return []byte(loadout), nil
+}
+
+// LoadLoadouts will read the JSON of every loadout saved for the Destiny account by name.
+func LoadLoadouts(membershipID string) (map[string][]byte, error) {
+
+	conn, err := GetDBConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Database.Query("SELECT name, loadout FROM saved_loadouts WHERE membership_id = $1", membershipID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string][]byte)
+	for rows.Next() {
+		var name, loadout string
+		err = rows.Scan(&name, &loadout)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = []byte(loadout)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,332 @@
+package db
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// MinimumMatchScore is the lowest similarity score (0.0 - 1.0) that will be accepted
+	// as a confident match for an item name that was not found exactly.
+	MinimumMatchScore = 0.75
+	// MinimumMatchMargin is how much better the best candidate must score compared to
+	// the next best candidate, otherwise the match is considered ambiguous.
+	MinimumMatchMargin = 0.05
+
+	// Weights used to combine the spelling and pronunciation similarity of two names.
+	textWeight     = 0.6
+	phoneticWeight = 0.4
+)
+
+// NameMatch is a single candidate item name along with how similar it is to the requested name.
+type NameMatch struct {
+	Name  string
+	Score float64
+}
+
+// NameMatcher is responsible for finding the closest known item names to a value provided
+// by Alexa. Candidates are ranked by edit distance of both the spelling and the phonetic
+// (Metaphone) encoding of each name, this handles cases where Alexa splits a name into
+// multiple words or picks a word that sounds similar ("spin mental" or "worms for").
+type NameMatcher struct {
+	candidates []matchCandidate
+}
+
+type matchCandidate struct {
+	name     string
+	text     string
+	phonetic string
+}
+
+// NewNameMatcher will create a NameMatcher that can match against the provided list of names.
+func NewNameMatcher(names []string) *NameMatcher {
+
+	matcher := &NameMatcher{
+		candidates: make([]matchCandidate, 0, len(names)),
+	}
+
+	for _, name := range names {
+		text := normalizeName(name)
+		if text == "" {
+			continue
+		}
+
+		matcher.candidates = append(matcher.candidates, matchCandidate{
+			name:     name,
+			text:     text,
+			phonetic: metaphone(text),
+		})
+	}
+
+	return matcher
+}
+
+// Rank will return up to limit candidate names sorted by their similarity to the provided name,
+// the most similar name will be first.
+func (matcher *NameMatcher) Rank(name string, limit int) []NameMatch {
+
+	text := normalizeName(name)
+	phonetic := metaphone(text)
+
+	result := make([]NameMatch, 0, len(matcher.candidates))
+	for _, candidate := range matcher.candidates {
+		score := textWeight*similarity(text, candidate.text) +
+			phoneticWeight*similarity(phonetic, candidate.phonetic)
+		result = append(result, NameMatch{Name: candidate.name, Score: score})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Score > result[j].Score
+	})
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+
+	return result
+}
+
+// BestMatch will find the closest candidate to the provided name. The boolean return value
+// will only be true if the match is confident, meaning it scored above MinimumMatchScore and
+// was not too close to the score of a different candidate.
+func (matcher *NameMatcher) BestMatch(name string) (string, bool) {
+
+	ranked := matcher.Rank(name, 2)
+	if len(ranked) == 0 {
+		return "", false
+	}
+
+	best := ranked[0]
+	if best.Score < MinimumMatchScore {
+		return best.Name, false
+	}
+
+	if len(ranked) > 1 && best.Score-ranked[1].Score < MinimumMatchMargin {
+		return best.Name, false
+	}
+
+	return best.Name, true
+}
+
+// normalizeName will lowercase the name and strip out all spaces and punctuation so that
+// names split into multiple words can still be compared to the single word version.
+func normalizeName(name string) string {
+
+	var builder bytes.Buffer
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			builder.WriteRune(r)
+		}
+	}
+
+	return builder.String()
+}
+
+// similarity will return a score between 0.0 and 1.0 based on the edit distance between a and b,
+// 1.0 meaning the values are identical.
+func similarity(a, b string) float64 {
+
+	longest := len([]rune(a))
+	if bLen := len([]rune(b)); bLen > longest {
+		longest = bLen
+	}
+	if longest == 0 {
+		return 0.0
+	}
+
+	return 1.0 - float64(levenshtein(a, b))/float64(longest)
+}
+
+// levenshtein calculates the minimum number of single character insertions, deletions, or
+// substitutions required to change a into b.
+func levenshtein(a, b string) int {
+
+	aRunes := []rune(a)
+	bRunes := []rune(b)
+
+	previous := make([]int, len(bRunes)+1)
+	current := make([]int, len(bRunes)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(aRunes); i++ {
+		current[0] = i
+		for j := 1; j <= len(bRunes); j++ {
+			cost := 1
+			if aRunes[i-1] == bRunes[j-1] {
+				cost = 0
+			}
+
+			current[j] = minInt(previous[j]+1, minInt(current[j-1]+1, previous[j-1]+cost))
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(bRunes)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// metaphone will generate the phonetic encoding of a word using the original Metaphone rules.
+// Words that sound alike will generally produce the same or very similar encodings.
+func metaphone(word string) string {
+
+	letters := []rune(strings.ToUpper(word))
+	// Only letters are encoded, digits and anything else are dropped
+	filtered := letters[:0]
+	for _, r := range letters {
+		if r >= 'A' && r <= 'Z' {
+			filtered = append(filtered, r)
+		}
+	}
+	letters = filtered
+	if len(letters) == 0 {
+		return ""
+	}
+
+	// Initial letter exceptions
+	start := 0
+	if len(letters) > 1 {
+		switch string(letters[:2]) {
+		case "AE", "GN", "KN", "PN", "WR":
+			start = 1
+		case "WH":
+			letters[1] = 'W'
+			start = 1
+		}
+	}
+	if letters[0] == 'X' {
+		letters[0] = 'S'
+	}
+
+	at := func(i int) rune {
+		if i < 0 || i >= len(letters) {
+			return 0
+		}
+		return letters[i]
+	}
+
+	var builder bytes.Buffer
+	for i := start; i < len(letters); i++ {
+		current := letters[i]
+
+		// Skip duplicate adjacent letters except for C
+		if current != 'C' && i > start && current == at(i-1) {
+			continue
+		}
+
+		switch current {
+		case 'A', 'E', 'I', 'O', 'U':
+			if i == start {
+				builder.WriteRune(current)
+			}
+		case 'B':
+			// Silent at the end of a word after M ("dumb")
+			if !(at(i-1) == 'M' && i == len(letters)-1) {
+				builder.WriteRune('B')
+			}
+		case 'C':
+			if at(i+1) == 'I' && at(i+2) == 'A' {
+				builder.WriteRune('X')
+			} else if at(i+1) == 'H' {
+				if at(i-1) == 'S' {
+					builder.WriteRune('K')
+				} else {
+					builder.WriteRune('X')
+				}
+				i++
+			} else if isFrontVowel(at(i + 1)) {
+				if at(i-1) != 'S' {
+					builder.WriteRune('S')
+				}
+			} else {
+				builder.WriteRune('K')
+			}
+		case 'D':
+			if at(i+1) == 'G' && isFrontVowel(at(i+2)) {
+				builder.WriteRune('J')
+				i++
+			} else {
+				builder.WriteRune('T')
+			}
+		case 'G':
+			if at(i+1) == 'H' && !isVowel(at(i+2)) && i+2 < len(letters) {
+				// Silent in the middle of words like "light"
+				continue
+			} else if at(i+1) == 'N' && (i+2 == len(letters) ||
+				(at(i+2) == 'E' && at(i+3) == 'D' && i+4 == len(letters))) {
+				continue
+			} else if isFrontVowel(at(i+1)) && at(i-1) != 'G' {
+				builder.WriteRune('J')
+			} else {
+				builder.WriteRune('K')
+			}
+		case 'H':
+			if isVowel(at(i+1)) && !strings.ContainsRune("CSPTG", at(i-1)) {
+				builder.WriteRune('H')
+			}
+		case 'K':
+			if at(i-1) != 'C' {
+				builder.WriteRune('K')
+			}
+		case 'P':
+			if at(i+1) == 'H' {
+				builder.WriteRune('F')
+				i++
+			} else {
+				builder.WriteRune('P')
+			}
+		case 'Q':
+			builder.WriteRune('K')
+		case 'S':
+			if at(i+1) == 'H' {
+				builder.WriteRune('X')
+				i++
+			} else if at(i+1) == 'I' && (at(i+2) == 'O' || at(i+2) == 'A') {
+				builder.WriteRune('X')
+			} else {
+				builder.WriteRune('S')
+			}
+		case 'T':
+			if at(i+1) == 'I' && (at(i+2) == 'O' || at(i+2) == 'A') {
+				builder.WriteRune('X')
+			} else if at(i+1) == 'H' {
+				builder.WriteRune('0')
+				i++
+			} else if !(at(i+1) == 'C' && at(i+2) == 'H') {
+				builder.WriteRune('T')
+			}
+		case 'V':
+			builder.WriteRune('F')
+		case 'W', 'Y':
+			if isVowel(at(i + 1)) {
+				builder.WriteRune(current)
+			}
+		case 'X':
+			builder.WriteString("KS")
+		case 'Z':
+			builder.WriteRune('S')
+		default:
+			// F, J, L, M, N, R are encoded as themselves
+			builder.WriteRune(current)
+		}
+	}
+
+	return builder.String()
+}
+
+func isVowel(r rune) bool {
+	return r == 'A' || r == 'E' || r == 'I' || r == 'O' || r == 'U'
+}
+
+func isFrontVowel(r rune) bool {
+	return r == 'E' || r == 'I' || r == 'Y'
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"testing"
+)
+
+var testItemNames = []string{
+	"spinmetal", "relic iron", "wormspore", "helium filaments", "spirit bloom",
+	"hadium flake", "mote of light", "strange coin", "passage coin", "exotic shard",
+	"three of coins", "ammo synthesis", "heavy ammo synthesis", "special ammo synthesis",
+	"weapon parts", "armor materials", "ascendant shard", "ascendant energy",
+	"radiant shard", "radiant energy", "gjallarhorn", "thorn",
+}
+
+func TestLevenshtein(t *testing.T) {
+
+	cases := []struct {
+		a, b     string
+		distance int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"spinmetal", "spinmental", 1},
+		{"thorn", "thorn", 0},
+	}
+
+	for _, c := range cases {
+		if result := levenshtein(c.a, c.b); result != c.distance {
+			t.Errorf("levenshtein(%s, %s) = %d, expected %d", c.a, c.b, result, c.distance)
+		}
+	}
+}
+
+func TestMetaphone(t *testing.T) {
+
+	cases := map[string]string{
+		"thorn":      "0RN",
+		"knight":     "NT",
+		"phone":      "FN",
+		"wormspore":  "WRMSPR",
+		"spinmetal":  "SPNMTL",
+		"spinmental": "SPNMNTL",
+		"exotic":     "EKSTK",
+	}
+
+	for word, expected := range cases {
+		if result := metaphone(word); result != expected {
+			t.Errorf("metaphone(%s) = %s, expected %s", word, result, expected)
+		}
+	}
+}
+
+func TestBestMatch(t *testing.T) {
+
+	matcher := NewNameMatcher(testItemNames)
+
+	confident := map[string]string{
+		"spin mental":      "spinmetal",
+		"spin metal":       "spinmetal",
+		"worms for":        "wormspore",
+		"worm spore":       "wormspore",
+		"relic irons":      "relic iron",
+		"helium filament":  "helium filaments",
+		"gjallar horn":     "gjallarhorn",
+		"strange coins":    "strange coin",
+		"ascendant shards": "ascendant shard",
+	}
+
+	for input, expected := range confident {
+		match, ok := matcher.BestMatch(input)
+		if !ok || match != expected {
+			t.Errorf("Expected a confident match of %s for %s, got %s (%v)", expected, input, match, ok)
+		}
+	}
+
+	for _, input := range []string{"ascendant raisins", "bank test", "pizza"} {
+		if match, ok := matcher.BestMatch(input); ok {
+			t.Errorf("Did not expect a confident match for %s, got %s", input, match)
+		}
+	}
+}
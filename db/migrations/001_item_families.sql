@@ -0,0 +1,54 @@
+-- Item families group related items (planetary materials, telemetries, etc.) so they
+-- can be counted together with a single request. item_hash references the items table
+-- loaded from the manifest.
+CREATE TABLE IF NOT EXISTS item_families (
+    family_name TEXT NOT NULL,
+    item_hash BIGINT NOT NULL,
+    PRIMARY KEY (family_name, item_hash)
+);
+
+INSERT INTO item_families (family_name, item_hash)
+SELECT 'planetary materials', item_hash FROM items
+WHERE lower(item_name) IN ('spinmetal', 'relic iron', 'helium filaments', 'spirit bloom', 'wormspore', 'hadium flake')
+    AND item_type_name NOT IN ('Material Exchange', '')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO item_families (family_name, item_hash)
+SELECT 'weapon upgrade materials', item_hash FROM items
+WHERE lower(item_name) IN ('weapon parts', 'ascendant energy', 'radiant energy')
+    AND item_type_name NOT IN ('Material Exchange', '')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO item_families (family_name, item_hash)
+SELECT 'armor upgrade materials', item_hash FROM items
+WHERE lower(item_name) IN ('armor materials', 'ascendant shard', 'radiant shard')
+    AND item_type_name NOT IN ('Material Exchange', '')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO item_families (family_name, item_hash)
+SELECT 'upgrade materials', item_hash FROM item_families
+WHERE family_name IN ('weapon upgrade materials', 'armor upgrade materials')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO item_families (family_name, item_hash)
+SELECT 'telemetries', item_hash FROM items
+WHERE lower(item_name) LIKE '%telemetry'
+ON CONFLICT DO NOTHING;
+
+INSERT INTO item_families (family_name, item_hash)
+SELECT 'ammo synthesis', item_hash FROM items
+WHERE lower(item_name) IN ('ammo synthesis', 'special ammo synthesis', 'heavy ammo synthesis')
+    AND item_type_name NOT IN ('Material Exchange', '')
+ON CONFLICT DO NOTHING;
+
+-- Engrams grouped by Destiny.TierType: 3 = Common (uncommon), 4 = Rare, 5 = Superior (legendary), 6 = Exotic
+INSERT INTO item_families (family_name, item_hash)
+SELECT CASE tier_type
+        WHEN 3 THEN 'uncommon engrams'
+        WHEN 4 THEN 'rare engrams'
+        WHEN 5 THEN 'legendary engrams'
+        WHEN 6 THEN 'exotic engrams'
+    END, item_hash
+FROM items
+WHERE item_name LIKE '%engram%' AND tier_type BETWEEN 3 AND 6
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,42 @@
+-- Translations for values that Alexa commonly misrecognizes or splits into separate words.
+-- kind is either 'item' or 'class'. These are loaded at startup and reloaded periodically.
+CREATE TABLE IF NOT EXISTS alexa_translations (
+    kind TEXT NOT NULL CHECK (kind IN ('item', 'class')),
+    alexa_value TEXT NOT NULL,
+    translation TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (kind, alexa_value)
+);
+
+CREATE TABLE IF NOT EXISTS unknown_items (
+    value TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS unknown_classes (
+    value TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS unknown_items_value_idx ON unknown_items (value);
+CREATE INDEX IF NOT EXISTS unknown_classes_value_idx ON unknown_classes (value);
+
+INSERT INTO alexa_translations (kind, alexa_value, translation) VALUES
+    ('item', 'spin metal', 'spinmetal'),
+    ('item', 'spin mental', 'spinmetal'),
+    ('item', 'spin middle', 'spinmetal'),
+    ('item', 'passage coins', 'passage coin'),
+    ('item', 'strange coins', 'strange coin'),
+    ('item', 'exotic shards', 'exotic shard'),
+    ('item', 'worm spore', 'wormspore'),
+    ('item', 'worms for', 'wormspore'),
+    ('item', 'worm for', 'wormspore'),
+    ('item', '3 of coins', 'three of coins'),
+    ('item', 'motes', 'mote of light'),
+    ('item', 'motes of light', 'mote of light'),
+    ('item', 'planet materials', 'planetary materials'),
+    ('item', 'planetary material', 'planetary materials'),
+    ('item', 'telemetry', 'telemetries'),
+    ('item', 'ammo synthesis''s', 'ammo synthesis'),
+    ('item', 'ammo synths', 'ammo synthesis'),
+    ('class', 'fault', 'vault'),
+    ('class', 'tatum', 'titan')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,6 @@
+-- Settings for each Alexa user, keyed by the userId sent with every Alexa request.
+CREATE TABLE IF NOT EXISTS user_preferences (
+    alexa_user_id TEXT PRIMARY KEY,
+    require_confirmation BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
@@ -0,0 +1,21 @@
+-- Phoneme overrides for Destiny names that Alexa mispronounces. word is matched case insensitively
+-- against the speech output and replaced with an SSML phoneme tag using the provided alphabet.
+CREATE TABLE IF NOT EXISTS pronunciations (
+    word TEXT PRIMARY KEY,
+    alphabet TEXT NOT NULL DEFAULT 'ipa' CHECK (alphabet IN ('ipa', 'x-sampa')),
+    phoneme TEXT NOT NULL
+);
+
+INSERT INTO pronunciations (word, alphabet, phoneme) VALUES
+    ('gjallarhorn', 'ipa', 'ˈjɑːlɑːrhɔːrn'),
+    ('osiris', 'ipa', 'oʊˈsaɪrɪs'),
+    ('thorn', 'ipa', 'θɔːrn'),
+    ('mythoclast', 'ipa', 'ˈmɪθoʊklæst'),
+    ('cayde', 'ipa', 'keɪd'),
+    ('xur', 'ipa', 'zʊər'),
+    ('oryx', 'ipa', 'ˈɔːrɪks'),
+    ('crota', 'ipa', 'ˈkroʊtə'),
+    ('atheon', 'ipa', 'ˈæθiɒn'),
+    ('spinmetal', 'ipa', 'ˈspɪnmɛtəl'),
+    ('wormspore', 'ipa', 'ˈwɜːrmspɔːr')
+ON CONFLICT (word) DO NOTHING;
@@ -0,0 +1,3 @@
+-- The icon path for each item from the manifest (DestinyInventoryItemDefinition.icon). The path is
+-- relative to https://www.bungie.net and is used for the images in Alexa cards.
+ALTER TABLE items ADD COLUMN IF NOT EXISTS icon TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+-- Item names from the localised manifest databases. The items table holds the English names, this
+-- table holds the names for the other languages Alexa supports. language is the manifest language
+-- code, for example 'de'.
+CREATE TABLE IF NOT EXISTS localized_items (
+    item_hash BIGINT NOT NULL,
+    language TEXT NOT NULL,
+    item_name TEXT NOT NULL,
+    PRIMARY KEY (item_hash, language)
+);
+
+CREATE INDEX IF NOT EXISTS localized_items_name_idx ON localized_items (language, lower(item_name));
@@ -0,0 +1,8 @@
+-- Additional settings for each Alexa user. platform is the Bungie.net membership type of the Destiny
+-- account to use (1 for Xbox, 2 for PlayStation) or 0 to use the first account. default_character is
+-- the class name used for max light and transfers when a character is not specified.
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS platform INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS default_character TEXT NOT NULL DEFAULT ''
+    CHECK (default_character IN ('', 'titan', 'hunter', 'warlock'));
+ALTER TABLE user_preferences ADD COLUMN IF NOT EXISTS verbosity TEXT NOT NULL DEFAULT 'normal'
+    CHECK (verbosity IN ('normal', 'brief'));
@@ -0,0 +1,9 @@
+-- Loadouts saved by a user to be equipped again later, keyed by the Destiny membership ID so they are
+-- shared by every client of the same account. loadout is the JSON of the saved item instances.
+CREATE TABLE IF NOT EXISTS saved_loadouts (
+    membership_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    loadout TEXT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT now(),
+    PRIMARY KEY (membership_id, name)
+);
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"database/sql"
+)
+
+// Verbosity levels for the skill's responses
+const (
+	// VerbosityNormal responses include all of the details, like the counts on each character
+	VerbosityNormal = "normal"
+	// VerbosityBrief responses only include the most important details
+	VerbosityBrief = "brief"
+)
+
+// UserPreferences are the settings for an Alexa user that are kept between sessions.
+type UserPreferences struct {
+	AlexaUserID string
+	// Platform is the Bungie.net membership type of the Destiny account to use, zero will use
+	// the first Destiny account linked to the Bungie.net account.
+	Platform uint
+	// DefaultCharacter is the class name of the character to use for max light and transfers when
+	// one is not specified, an empty string will use the most recently played character.
+	DefaultCharacter string
+	// Verbosity is either VerbosityNormal or VerbosityBrief
+	Verbosity string
+	// RequireConfirmation is true if the user wants to confirm large or disruptive inventory actions
+	// before they are performed.
+	RequireConfirmation bool
+}
+
+// DefaultUserPreferences are the preferences for an Alexa user that has not saved any.
+func DefaultUserPreferences(alexaUserID string) *UserPreferences {
+	return &UserPreferences{
+		AlexaUserID:         alexaUserID,
+		Verbosity:           VerbosityNormal,
+		RequireConfirmation: true,
+	}
+}
+
+// Brief is true if the user prefers short responses.
+func (prefs *UserPreferences) Brief() bool {
+	return prefs.Verbosity == VerbosityBrief
+}
+
+// LoadUserPreferences will read the saved preferences for the Alexa user. The default preferences are
+// returned if the user has not saved any, or along with the error if they could not be loaded.
+func LoadUserPreferences(alexaUserID string) (*UserPreferences, error) {
+
+	prefs := DefaultUserPreferences(alexaUserID)
+	conn, err := GetDBConnection()
+	if err != nil {
+		return prefs, err
+	}
+
+	var platform int64
+	err = conn.Database.QueryRow("SELECT platform, default_character, verbosity, require_confirmation "+
+		"FROM user_preferences WHERE alexa_user_id = $1", alexaUserID).
+		Scan(&platform, &prefs.DefaultCharacter, &prefs.Verbosity, &prefs.RequireConfirmation)
+	if err == sql.ErrNoRows {
+		return DefaultUserPreferences(alexaUserID), nil
+	} else if err != nil {
+		return DefaultUserPreferences(alexaUserID), err
+	}
+	prefs.Platform = uint(platform)
+
+	return prefs, nil
+}
+
+// SaveUserPreferences will save all of the preferences for the Alexa user.
+func SaveUserPreferences(prefs *UserPreferences) error {
+
+	conn, err := GetDBConnection()
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Database.Exec("INSERT INTO user_preferences "+
+		"(alexa_user_id, platform, default_character, verbosity, require_confirmation) VALUES($1, $2, $3, $4, $5) "+
+		"ON CONFLICT (alexa_user_id) DO UPDATE SET platform = EXCLUDED.platform, "+
+		"default_character = EXCLUDED.default_character, verbosity = EXCLUDED.verbosity, "+
+		"require_confirmation = EXCLUDED.require_confirmation, updated_at = now()",
+		prefs.AlexaUserID, prefs.Platform, prefs.DefaultCharacter, prefs.Verbosity, prefs.RequireConfirmation)
+	return err
+}
+
+// CreateUserPreferences will save the default preferences for the Alexa user if they do not already
+// have any saved.
+func CreateUserPreferences(alexaUserID string) error {
+
+	conn, err := GetDBConnection()
+	if err != nil {
+		return err
+	}
+
+	prefs := DefaultUserPreferences(alexaUserID)
+	_, err = conn.Database.Exec("INSERT INTO user_preferences (alexa_user_id, verbosity, require_confirmation) "+
+		"VALUES($1, $2, $3) ON CONFLICT (alexa_user_id) DO NOTHING",
+		prefs.AlexaUserID, prefs.Verbosity, prefs.RequireConfirmation)
+	return err
+}
+
+// DeleteUserData will remove the rows stored in the database for the Alexa user, which are only their
+// preferences. This is used when the user disables the skill, any new tables keyed by the Alexa user
+// ID need to be cleaned up here too. State kept in memory by the alexa package is cleared separately.
+func DeleteUserData(alexaUserID string) error {
+
+	conn, err := GetDBConnection()
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Database.Exec("DELETE FROM user_preferences WHERE alexa_user_id = $1", alexaUserID)
+	return err
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// FamilyEntityPrefix is added to the family name to build the entity ID of an item family,
+	// items use their item hash as the entity ID.
+	FamilyEntityPrefix = "family:"
+)
+
+// SlotValue is a single value of a custom slot type in the Alexa interaction model. The ID is
+// returned by Alexa entity resolution when the value or one of its synonyms is spoken.
+type SlotValue struct {
+	ID       string
+	Name     string
+	Synonyms []string
+}
+
+// LoadItemSlotValues will build the values of the item slot type from the transferrable items and
+// the item families. The item translations are included as synonyms of the item they translate to.
+// Items with the same name, ignoring case, use the hash that GetItemHashFromName would find.
+func LoadItemSlotValues() ([]*SlotValue, error) {
+
+	conn, err := GetDBConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Database.Query("SELECT DISTINCT ON (lower(item_name)) lower(item_name), item_hash FROM items " +
+		"WHERE item_type_name NOT IN ('Material Exchange', '') AND item_name <> '' " +
+		"ORDER BY lower(item_name), max_stack_size DESC, item_hash")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := make(map[string]*SlotValue)
+	for rows.Next() {
+		var name string
+		var hash uint
+		rows.Scan(&name, &hash)
+		values[name] = &SlotValue{ID: strconv.FormatUint(uint64(hash), 10), Name: name}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	families, err := conn.Database.Query("SELECT DISTINCT family_name FROM item_families")
+	if err != nil {
+		return nil, err
+	}
+	defer families.Close()
+
+	for families.Next() {
+		var name string
+		families.Scan(&name)
+		values[name] = &SlotValue{ID: FamilyEntityPrefix + name, Name: name}
+	}
+	if err := families.Err(); err != nil {
+		return nil, err
+	}
+
+	translations, err := LoadTranslations(ItemTranslation)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildSlotValues(values, translations), nil
+}
+
+// buildSlotValues will add the translations as synonyms of the values they translate to and sort
+// the values by name. Translations to unknown values are ignored.
+func buildSlotValues(values map[string]*SlotValue, translations map[string]string) []*SlotValue {
+
+	for alexaValue, translation := range translations {
+		if value, ok := values[strings.ToLower(translation)]; ok && values[alexaValue] == nil {
+			value.Synonyms = append(value.Synonyms, alexaValue)
+		}
+	}
+
+	result := make([]*SlotValue, 0, len(values))
+	for _, value := range values {
+		sort.Strings(value.Synonyms)
+		result = append(result, value)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
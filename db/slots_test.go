@@ -0,0 +1,42 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildSlotValues(t *testing.T) {
+
+	values := map[string]*SlotValue{
+		"spinmetal":           {ID: "2882093969", Name: "spinmetal"},
+		"mote of light":       {ID: "937555249", Name: "mote of light"},
+		"planetary materials": {ID: FamilyEntityPrefix + "planetary materials", Name: "planetary materials"},
+	}
+	translations := map[string]string{
+		"spin mental":        "spinmetal",
+		"spin metal":         "spinmetal",
+		"planet materials":   "planetary materials",
+		"worms for":          "wormspore",
+		"motes":              "Mote of Light",
+		"planetary material": "planetary materials",
+	}
+
+	result := buildSlotValues(values, translations)
+	names := make([]string, 0, len(result))
+	for _, value := range result {
+		names = append(names, value.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"mote of light", "planetary materials", "spinmetal"}) {
+		t.Errorf("Expected the values to be sorted by name, got: %v", names)
+	}
+
+	if !reflect.DeepEqual(result[2].Synonyms, []string{"spin mental", "spin metal"}) {
+		t.Errorf("Unexpected spinmetal synonyms: %v", result[2].Synonyms)
+	}
+	if !reflect.DeepEqual(result[0].Synonyms, []string{"motes"}) {
+		t.Errorf("Unexpected mote of light synonyms: %v", result[0].Synonyms)
+	}
+	if len(result[1].Synonyms) != 2 {
+		t.Errorf("Expected the family to have synonyms, got: %v", result[1].Synonyms)
+	}
+}
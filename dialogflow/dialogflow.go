@@ -0,0 +1,292 @@
+// Package dialogflow implements Dialogflow webhook fulfillment for the Google Assistant. Dialogflow
+// requests are converted into the same requests the Alexa skill receives so the intent handlers in
+// the alexa package can be reused, and their responses are converted back into the Dialogflow
+// webhook response format.
+package dialogflow
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mikeflynn/go-alexa/skillserver"
+	"github.com/rking788/guardian-helper/alexa"
+	"github.com/rking788/guardian-helper/i18n"
+)
+
+// LaunchIntent is the Dialogflow intent that starts a conversation, it is handled like an Alexa
+// LaunchRequest.
+const LaunchIntent = "Default Welcome Intent"
+
+// BackgroundThreshold is how long a long running operation can take before the response is sent and
+// the operation finishes in the background. Dialogflow will time out a webhook request after 5 seconds.
+const BackgroundThreshold = 4 * time.Second
+
+// IntentNames maps the names of the Dialogflow intents that differ from the Alexa intent names.
+// Any other Dialogflow intent needs to have the same name as the intent in alexa.InteractionModel.
+var IntentNames = map[string]string{
+	"Default Fallback Intent": "AMAZON.FallbackIntent",
+	"Help":                    "AMAZON.HelpIntent",
+	"Yes":                     "AMAZON.YesIntent",
+	"No":                      "AMAZON.NoIntent",
+	"Stop":                    "AMAZON.StopIntent",
+	"Cancel":                  "AMAZON.CancelIntent",
+	"Repeat":                  "AMAZON.RepeatIntent",
+	"Start Over":              "AMAZON.StartOverIntent",
+}
+
+// WebhookRequest is the part of the Dialogflow webhook request used to fulfill an intent.
+type WebhookRequest struct {
+	ResponseID  string `json:"responseId"`
+	Session     string `json:"session"`
+	QueryResult struct {
+		QueryText    string                 `json:"queryText"`
+		Parameters   map[string]interface{} `json:"parameters"`
+		LanguageCode string                 `json:"languageCode"`
+		Intent       struct {
+			DisplayName string `json:"displayName"`
+		} `json:"intent"`
+	} `json:"queryResult"`
+	OriginalDetectIntentRequest struct {
+		Source  string `json:"source"`
+		Payload struct {
+			User struct {
+				UserID      string `json:"userId"`
+				AccessToken string `json:"accessToken"`
+			} `json:"user"`
+		} `json:"payload"`
+	} `json:"originalDetectIntentRequest"`
+}
+
+// WebhookResponse is the Dialogflow webhook response, the payload includes the rich response for
+// the Google Assistant.
+type WebhookResponse struct {
+	FulfillmentText string          `json:"fulfillmentText"`
+	Payload         responsePayload `json:"payload"`
+}
+
+type responsePayload struct {
+	Google googlePayload `json:"google"`
+}
+
+type googlePayload struct {
+	ExpectUserResponse bool            `json:"expectUserResponse"`
+	RichResponse       richResponse    `json:"richResponse"`
+	NoInputPrompts     []simpleMessage `json:"noInputPrompts,omitempty"`
+	SystemIntent       *systemIntent   `json:"systemIntent,omitempty"`
+}
+
+type richResponse struct {
+	Items []richItem `json:"items"`
+}
+
+type richItem struct {
+	SimpleResponse *simpleMessage `json:"simpleResponse,omitempty"`
+	BasicCard      *basicCard     `json:"basicCard,omitempty"`
+}
+
+type simpleMessage struct {
+	TextToSpeech string `json:"textToSpeech"`
+}
+
+type basicCard struct {
+	Title         string     `json:"title"`
+	FormattedText string     `json:"formattedText"`
+	Image         *cardImage `json:"image,omitempty"`
+}
+
+type cardImage struct {
+	URL               string `json:"url"`
+	AccessibilityText string `json:"accessibilityText"`
+}
+
+type systemIntent struct {
+	Intent string            `json:"intent"`
+	Data   map[string]string `json:"data"`
+}
+
+// signInValueSpec is the type of the data for the actions.intent.SIGN_IN helper
+const signInValueSpec = "type.googleapis.com/google.actions.v2.SignInValueSpec"
+
+// UserIDPrefix is added to the Google user IDs so the preferences and per-user state of Google Assistant
+// users are never shared with an Alexa user that has the same ID.
+const UserIDPrefix = "google:"
+
+// ToAlexaRequest will convert the Dialogflow request into the request that the Alexa intent handlers
+// expect. The intent is mapped with IntentNames and the parameters are matched to the slots of the
+// intent in alexa.InteractionModel, ignoring case and separators so "keep-tier" fills "KeepTier".
+func ToAlexaRequest(webhookRequest *WebhookRequest) *alexa.Request {
+
+	echoRequest := &skillserver.EchoRequest{Version: "1.0"}
+	echoRequest.Session.SessionID = webhookRequest.Session
+	userID := webhookRequest.OriginalDetectIntentRequest.Payload.User.UserID
+	if userID == "" {
+		userID = webhookRequest.Session
+	}
+	echoRequest.Session.User.UserID = UserIDPrefix + userID
+	echoRequest.Session.User.AccessToken = webhookRequest.OriginalDetectIntentRequest.Payload.User.AccessToken
+	echoRequest.Request.RequestID = webhookRequest.ResponseID
+
+	displayName := webhookRequest.QueryResult.Intent.DisplayName
+	if displayName == LaunchIntent {
+		echoRequest.Request.Type = "LaunchRequest"
+	} else {
+		echoRequest.Request.Type = "IntentRequest"
+		echoRequest.Request.Intent.Name = intentName(displayName)
+		echoRequest.Request.Intent.Slots = slotValues(echoRequest.Request.Intent.Name, webhookRequest.QueryResult.Parameters)
+	}
+
+	request := alexa.NewRequest(echoRequest, nil)
+	request.Locale = webhookRequest.QueryResult.LanguageCode
+	request.BackgroundThreshold = BackgroundThreshold
+
+	return request
+}
+
+func intentName(displayName string) string {
+	if name, ok := IntentNames[displayName]; ok {
+		return name
+	}
+	return displayName
+}
+
+// slotValues will match the Dialogflow parameters to the slots of the intent. Empty parameters are
+// left out like slots that Alexa did not fill.
+func slotValues(intentName string, parameters map[string]interface{}) map[string]skillserver.EchoSlot {
+
+	slots := make(map[string]skillserver.EchoSlot)
+	for _, intent := range alexa.InteractionModel {
+		if intent.Name != intentName {
+			continue
+		}
+
+		for _, slot := range intent.Slots {
+			for name, value := range parameters {
+				if normalizeName(name) != normalizeName(slot.Name) {
+					continue
+				}
+				if text := parameterText(value); text != "" {
+					slots[slot.Name] = skillserver.EchoSlot{Name: slot.Name, Value: text}
+				}
+			}
+		}
+	}
+
+	return slots
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(name))
+}
+
+// parameterText will convert a parameter value to the text of a slot, numbers are sent by
+// Dialogflow as floats.
+func parameterText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+// FromAlexaResponse will convert the response from an Alexa intent handler into the Dialogflow
+// webhook response. Cards are shown as basic cards and the account linking card starts the
+// Google sign in flow, the localizer is used for the sign in text since the Alexa response
+// refers to the Alexa app.
+func FromAlexaResponse(l *i18n.Localizer, response *skillserver.EchoResponse) *WebhookResponse {
+
+	body := response.Response
+	webhookResponse := &WebhookResponse{}
+	webhookResponse.Payload.Google.ExpectUserResponse = !body.ShouldEndSession
+	webhookResponse.Payload.Google.RichResponse.Items = make([]richItem, 0, 2)
+
+	linkAccount := body.Card != nil && body.Card.Type == "LinkAccount"
+	speech := ""
+	if linkAccount {
+		speech = l.Sprintf("sign_in.speech")
+	} else if body.OutputSpeech != nil {
+		speech = body.OutputSpeech.Text
+	}
+	webhookResponse.FulfillmentText = speech
+	webhookResponse.Payload.Google.RichResponse.Items = append(webhookResponse.Payload.Google.RichResponse.Items,
+		richItem{SimpleResponse: &simpleMessage{TextToSpeech: speech}})
+
+	if body.Reprompt != nil && body.Reprompt.OutputSpeech.Text != "" {
+		webhookResponse.Payload.Google.NoInputPrompts = []simpleMessage{{TextToSpeech: body.Reprompt.OutputSpeech.Text}}
+	}
+
+	if card := body.Card; linkAccount {
+		webhookResponse.Payload.Google.ExpectUserResponse = true
+		webhookResponse.Payload.Google.SystemIntent = &systemIntent{
+			Intent: "actions.intent.SIGN_IN",
+			Data:   map[string]string{"@type": signInValueSpec, "optContext": l.Sprintf("sign_in.context")},
+		}
+	} else if card != nil {
+		// Standard cards use Text and simple cards use Content
+		content := card.Text
+		if content == "" {
+			content = card.Content
+		}
+		basic := &basicCard{Title: card.Title, FormattedText: content}
+		if card.Image.LargeImageURL != "" {
+			basic.Image = &cardImage{URL: card.Image.LargeImageURL, AccessibilityText: card.Title}
+		}
+		webhookResponse.Payload.Google.RichResponse.Items = append(webhookResponse.Payload.Google.RichResponse.Items,
+			richItem{BasicCard: basic})
+	}
+
+	return webhookResponse
+}
+
+// WebhookHandler will create the HTTP handler for Dialogflow fulfillment requests, each request is
+// answered by the handler. The DIALOGFLOW_TOKEN needs to be sent as a bearer token in the Authorization
+// header, this is configured in the Dialogflow fulfillment headers. If no token is configured, all
+// requests will be rejected.
+func WebhookHandler(handler alexa.Handler) http.HandlerFunc {
+
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		token := os.Getenv("DIALOGFLOW_TOKEN")
+		if token == "" {
+			http.Error(w, "Dialogflow fulfillment is disabled", http.StatusForbidden)
+			return
+		}
+
+		provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			http.Error(w, "Not Authorized", http.StatusUnauthorized)
+			return
+		}
+
+		webhookRequest := &WebhookRequest{}
+		err := json.NewDecoder(r.Body).Decode(webhookRequest)
+		if err != nil {
+			fmt.Println("Failed to decode the Dialogflow request: ", err.Error())
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+
+		request := ToAlexaRequest(webhookRequest)
+		fmt.Printf("Dialogflow request for intent: %s\n", webhookRequest.QueryResult.Intent.DisplayName)
+
+		response := handler(request)
+		alexa.AttachCompletedJobs(request, response)
+
+		body, err := json.Marshal(FromAlexaResponse(request.Localizer(), response))
+		if err != nil {
+			fmt.Println("Failed to serialize the Dialogflow response: ", err.Error())
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+		w.Write(body)
+	}
+}
@@ -0,0 +1,159 @@
+package dialogflow
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mikeflynn/go-alexa/skillserver"
+	"github.com/rking788/guardian-helper/alexa"
+)
+
+var update = flag.Bool("update", false, "update the expected responses in testdata")
+
+// testHandlers route the fixture requests to the Alexa handlers that do not need Bungie.net, the
+// character summary is replaced to test how cards are converted.
+var testHandlers = map[string]alexa.Handler{
+	"CountItem":             alexa.AuthWrapper(alexa.CountItem),
+	"TransferItem":          alexa.AuthWrapper(alexa.TransferItem),
+	"AMAZON.HelpIntent":     alexa.HelpPrompt,
+	"AMAZON.FallbackIntent": alexa.Fallback,
+	"CharacterSummary": func(request *alexa.Request) *skillserver.EchoResponse {
+		response := skillserver.NewEchoResponse()
+		response.OutputSpeech("Your awoken female warlock is 335 light and was last played today.").
+			StandardCard("Warlock", "335 light", "https://www.bungie.net/icon-small.png", "https://www.bungie.net/icon.png")
+		return response
+	},
+}
+
+func routeTestIntent(request *alexa.Request) *skillserver.EchoResponse {
+	if request.GetRequestType() == "LaunchRequest" {
+		return alexa.WelcomePrompt(request)
+	}
+	return testHandlers[request.GetIntentName()](request)
+}
+
+func TestFixtures(t *testing.T) {
+
+	os.Setenv("DIALOGFLOW_TOKEN", "dialogflow-token")
+	defer os.Unsetenv("DIALOGFLOW_TOKEN")
+
+	alexa.SetSessionStore(alexa.NewMemorySessionStore(time.Minute))
+	handler := WebhookHandler(routeTestIntent)
+
+	requests, _ := filepath.Glob(filepath.Join("testdata", "*.request.json"))
+	if len(requests) == 0 {
+		t.Fatal("No fixtures found in testdata")
+	}
+
+	for _, requestPath := range requests {
+		body, err := ioutil.ReadFile(requestPath)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %s", requestPath, err.Error())
+		}
+
+		recorder := httptest.NewRecorder()
+		req := httptest.NewRequest("POST", "/dialogflow/fulfillment", bytes.NewReader(body))
+		req.Header.Set("Authorization", "Bearer dialogflow-token")
+		handler(recorder, req)
+		if recorder.Code != http.StatusOK {
+			t.Errorf("Unexpected status for %s: %d", requestPath, recorder.Code)
+			continue
+		}
+
+		responsePath := strings.Replace(requestPath, ".request.json", ".response.json", 1)
+		if *update {
+			var indented bytes.Buffer
+			json.Indent(&indented, recorder.Body.Bytes(), "", "  ")
+			indented.WriteString("\n")
+			ioutil.WriteFile(responsePath, indented.Bytes(), 0644)
+		}
+
+		expected, err := ioutil.ReadFile(responsePath)
+		if err != nil {
+			t.Errorf("Failed to read the expected response %s: %s", responsePath, err.Error())
+			continue
+		}
+
+		var expectedJSON, actualJSON interface{}
+		json.Unmarshal(expected, &expectedJSON)
+		json.Unmarshal(recorder.Body.Bytes(), &actualJSON)
+		if !reflect.DeepEqual(expectedJSON, actualJSON) {
+			t.Errorf("Unexpected response for %s:\n%s", requestPath, recorder.Body.String())
+		}
+	}
+}
+
+func TestToAlexaRequest(t *testing.T) {
+
+	webhookRequest := &WebhookRequest{Session: "projects/p/agent/sessions/s1"}
+	webhookRequest.QueryResult.Intent.DisplayName = "UnloadEngrams"
+	webhookRequest.QueryResult.LanguageCode = "de"
+	webhookRequest.QueryResult.Parameters = map[string]interface{}{"tier": "", "keep-tier": "exotic", "unused": "value"}
+
+	request := ToAlexaRequest(webhookRequest)
+	if request.GetIntentName() != "UnloadEngrams" || request.GetUserID() != "google:projects/p/agent/sessions/s1" ||
+		request.Localizer().Locale() != "de-DE" {
+		t.Errorf("Unexpected request: %+v", request.EchoRequest)
+	}
+
+	if keepTier, _ := request.GetSlotValue("KeepTier"); keepTier != "exotic" {
+		t.Errorf("Expected the keep-tier parameter to fill the KeepTier slot, got %s", keepTier)
+	}
+	if _, ok := request.Request.Intent.Slots["Tier"]; ok {
+		t.Error("Expected empty parameters to be left out")
+	}
+	if request.BackgroundThreshold <= 0 || request.BackgroundThreshold >= 5*time.Second {
+		t.Errorf("Expected long operations to move to the background before Dialogflow times out, got %s", request.BackgroundThreshold)
+	}
+}
+
+func TestWebhookHandlerRequiresToken(t *testing.T) {
+
+	cases := []struct {
+		token         string
+		authorization string
+		status        int
+	}{
+		{"", "", http.StatusForbidden},
+		{"", "Bearer ", http.StatusForbidden},
+		{"dialogflow-token", "", http.StatusUnauthorized},
+		{"dialogflow-token", "Bearer wrong", http.StatusUnauthorized},
+	}
+
+	defer os.Unsetenv("DIALOGFLOW_TOKEN")
+	handler := WebhookHandler(routeTestIntent)
+	for _, c := range cases {
+		os.Setenv("DIALOGFLOW_TOKEN", c.token)
+
+		req := httptest.NewRequest("POST", "/dialogflow/fulfillment", strings.NewReader("{}"))
+		if c.authorization != "" {
+			req.Header.Set("Authorization", c.authorization)
+		}
+		recorder := httptest.NewRecorder()
+		handler(recorder, req)
+		if recorder.Code != c.status {
+			t.Errorf("Expected status %d with token(%s) and header(%s), got %d",
+				c.status, c.token, c.authorization, recorder.Code)
+		}
+	}
+}
+
+func TestGoogleUserIDsArePrefixed(t *testing.T) {
+
+	webhookRequest := &WebhookRequest{Session: "projects/p/agent/sessions/s1"}
+	webhookRequest.OriginalDetectIntentRequest.Payload.User.UserID = "amzn1.ask.account.same"
+
+	if userID := ToAlexaRequest(webhookRequest).GetUserID(); userID != "google:amzn1.ask.account.same" {
+		t.Errorf("Expected the Google user ID to be prefixed, got %s", userID)
+	}
+}
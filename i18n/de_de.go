@@ -0,0 +1,203 @@
+package i18n
+
+var german = Messages{
+	// Lists
+	"list.pair": "%s und %s",
+	"list.last": "%s und %s",
+
+	// General
+	"error.link_account":       "Entschuldige Hüter, dein Bungie.net Konto muss zuerst in der Alexa App verknüpft werden.",
+	"error.no_destiny_account": "Entschuldige Hüter, ich konnte kein Destiny Konto finden, das mit deinem Bungie.net Konto verknüpft ist.",
+	"error.bungie_unavailable": "Entschuldige Hüter, Bungie.net ist gerade nicht erreichbar. Bitte versuche es in ein paar Minuten noch einmal.",
+	"error.unexpected":         "Entschuldige Hüter, etwas ist schiefgelaufen. Bitte versuche es noch einmal.",
+	"error.rate_limited":       "Nicht so schnell Hüter, du hast sehr viele Anfragen gestellt. Bitte versuche es in einer Minute noch einmal.",
+	"sign_in.context":          "Um dein Destiny Inventar zu verwalten",
+	"sign_in.speech":           "Entschuldige Hüter, dein Bungie.net Konto muss zuerst verknüpft werden.",
+	"welcome": "Willkommen Hüter, möchtest du deine Ausrüstung mit dem höchsten Licht anlegen, Engramme ausladen, einen Gegenstand " +
+		"zu einem Charakter transferieren, wissen wie viele Gegenstände du hast, oder etwas über die Prüfungen von Osiris erfahren?",
+	"welcome.reprompt": "Möchtest du das höchste Licht anlegen, Engramme ausladen, einen Gegenstand transferieren, Gegenstände zählen oder etwas über die Prüfungen von Osiris erfahren?",
+	"welcome.brief":    "Was kann ich für dich tun, Hüter?",
+	"help": "Willkommen Hüter, ich helfe dir dabei dein Inventar in Destiny zu verwalten. Du kannst mich bitten, deine " +
+		"Ausrüstung mit dem höchsten Licht anzulegen, Engramme aus deinem Inventar auszuladen oder Gegenstände zwischen deinen " +
+		"Charakteren und dem Tresor zu transferieren. Du kannst auch fragen, wie viele Gegenstände du hast. " +
+		"Statistiken zu den Prüfungen von Osiris von Trials Report sind ebenfalls verfügbar.",
+	"dialog.unknown": "Entschuldige Hüter, ich weiß nicht, was ich damit tun soll. Du kannst mich bitten, " +
+		"einen Gegenstand zu transferieren oder Gegenstände zu zählen.",
+	"repeat.nothing":       "Entschuldige Hüter, ich habe noch nichts gesagt. Was möchtest du tun?",
+	"fallback":             "Entschuldige Hüter, das habe ich nicht verstanden. %s",
+	"fallback.confirm":     "Bitte sage ja um fortzufahren oder nein um abzubrechen.",
+	"fallback.suggestions": "Entschuldige Hüter, dabei kann ich nicht helfen. Du kannst mich bitten, das höchste Licht anzulegen, Engramme auszuladen, einen Gegenstand zu transferieren, Gegenstände zu zählen oder etwas über die Prüfungen von Osiris zu erfahren.",
+	"character.unknown":    "Entschuldige Hüter, ich weiß nicht welcher Charakter %s ist. %s",
+	"items.default":        "Gegenstände",
+	"item.unknown":         "Unbekannt",
+	"item.gear":            "Ausrüstung",
+
+	// Counting items
+	"count.ask_item":       "Welchen Gegenstand soll ich zählen?",
+	"count.error":          "Entschuldige Hüter, beim Zählen ist ein Fehler aufgetreten.",
+	"count.not_found":      "Entschuldige Hüter, ich konnte keine Gegenstände namens %s in deinem Inventar finden.",
+	"count.none":           "Du hast auf keinem deiner Charaktere %s.",
+	"count.character":      "Dein %[1]s hat %[2]d %[3]s. ",
+	"count.total":          "Du hast insgesamt %d %s.",
+	"count.family":         "Auf deinen Charakteren und im Tresor hast du %s.",
+	"count.family.entry":   "%d %s",
+	"count.family.none":    "Du hast weder auf deinen Charakteren noch im Tresor %s.",
+	"card.inventory.entry": "%s: %d",
+	"card.inventory.total": "Gesamt: %d",
+
+	// Transferring items
+	"transfer.ask_item":           "Welchen Gegenstand möchtest du transferieren?",
+	"transfer.ask_source":         "Von welchem Charakter soll ich sie transferieren?",
+	"transfer.ask_destination":    "Welcher Charakter soll deine %s bekommen?",
+	"transfer.invalid_count":      "Entschuldige Hüter, ich habe die Anzahl nicht verstanden. Nenne keine Anzahl, wenn alles transferiert werden soll.",
+	"transfer.non_positive_count": "Entschuldige Hüter, die Anzahl muss größer als null sein, nicht %d",
+	"transfer.progress":           "Ich transferiere jetzt deine %s, Hüter.",
+	"transfer.description":        "Transfer von %s",
+	"transfer.error":              "Entschuldige Hüter, beim Transferieren ist ein Fehler aufgetreten.",
+	"transfer.no_character":       "Entschuldige Hüter, ich konnte deine %s nicht transferieren, weil du keinen %s in Destiny hast.",
+	"transfer.partial":            "Du hattest nur %d %s auf anderen Charakteren, alles wurde zu deinem %s transferiert",
+	"transfer.done":               "Alles erledigt Hüter, %d %s wurden zu deinem %s transferiert",
+	"card.transfer.title":         "%s transferiert",
+	"card.transfer":               "%d zu deinem %s transferiert.\n\nVor dem Transfer:\n%s",
+
+	// Max light
+	"maxlight.error":             "Entschuldige Hüter, beim Anlegen deiner Ausrüstung ist ein Fehler aufgetreten.",
+	"maxlight.progress":          "Ich bin dabei Hüter, ich suche deine Ausrüstung mit dem höchsten Licht.",
+	"maxlight.description":       "Ausrüstung mit dem höchsten Licht",
+	"maxlight.done":              "Das höchste Licht ist auf deinem %s angelegt, Hüter. Du bist eine Macht, mit der man rechnen muss.",
+	"maxlight.plan.one":          "Dabei wird %d Gegenstand zu deinem %s bewegt",
+	"maxlight.plan.other":        "Dabei werden %d Gegenstände zu deinem %s bewegt",
+	"maxlight.plan.unequip":      "%s und %s abgelegt",
+	"maxlight.plan.unequip_item": "dein %s von deinem %s",
+	"card.maxlight.title":        "Höchstes Licht %s",
+	"card.maxlight.slot":         "%s: %s (%d)",
+	"card.maxlight.light":        "Licht: %.1f",
+	"display.slot":               "%s (%d)",
+	"bucket.Primary":             "Primärwaffe",
+	"bucket.Special":             "Spezialwaffe",
+	"bucket.Heavy":               "Schwere Waffe",
+	"bucket.Ghost":               "Geist",
+	"bucket.Helmet":              "Helm",
+	"bucket.Arms":                "Arme",
+	"bucket.Chest":               "Brust",
+	"bucket.Legs":                "Beine",
+	"bucket.ClassArmor":          "Klassengegenstand",
+	"bucket.Artifact":            "Artefakt",
+
+	// Confirmations
+	"confirm.continue":         "%s Möchtest du fortfahren?",
+	"confirm.nothing":          "Entschuldige Hüter, es wartet nichts auf eine Bestätigung.",
+	"cancel.action":            "Okay Hüter, ich ändere nichts.",
+	"cancel.ok":                "Okay Hüter.",
+	"confirmations.save_error": "Entschuldige Hüter, ich konnte diese Einstellung gerade nicht speichern.",
+	"confirmations.enabled":    "Okay Hüter, ich frage dich, bevor ich das höchste Licht anlege oder Engramme auslade.",
+	"confirmations.disabled":   "Okay Hüter, ich lege das höchste Licht an und lade Engramme aus, ohne vorher zu fragen.",
+
+	// Preferences
+	"preferences.platform":          "Okay Hüter, ich verwende ab jetzt dein %s Konto.",
+	"preferences.unknown_platform":  "Entschuldige Hüter, die Plattform %s kenne ich nicht. Du kannst Xbox oder PlayStation sagen.",
+	"preferences.character":         "Okay Hüter, dein %s ist jetzt dein Standardcharakter für das höchste Licht und Transfers.",
+	"preferences.vault_character":   "Entschuldige Hüter, der Tresor kann nicht dein Standardcharakter sein. %s",
+	"preferences.character_hint":    "Du kannst deinen Titan, Jäger oder Warlock wählen.",
+	"preferences.verbosity.brief":   "Okay Hüter, ich fasse mich kurz.",
+	"preferences.verbosity.normal":  "Okay Hüter, ich nenne dir alle Details.",
+	"preferences.unknown_verbosity": "Entschuldige Hüter, ich habe nicht verstanden, wie ausführlich ich antworten soll. Du kannst kurze oder ausführliche Antworten wählen.",
+
+	// Unloading engrams
+	"unload.unknown_tier":      "Entschuldige Hüter, ich habe nicht verstanden, welche Engramme du ausladen möchtest.",
+	"unload.unknown_keep_tier": "Entschuldige Hüter, ich habe nicht verstanden, welche Engramme du behalten möchtest.",
+	"unload.error":             "Entschuldige Hüter, beim Bewegen deiner Engramme ist ein Fehler aufgetreten.",
+	"unload.confirm.one":       "Dabei wird %d Engramm in deinen Tresor bewegt. Möchtest du fortfahren?",
+	"unload.confirm.other":     "Dabei werden %d Engramme in deinen Tresor bewegt. Möchtest du fortfahren?",
+	"unload.progress":          "Ich bin dabei Hüter, ich bewege deine Engramme in den Tresor.",
+	"unload.description":       "Engramme ausladen",
+	"unload.none":              "Du hast keine Engramme auf deinen Charakteren. Viel Spaß beim Farmen, Hüter!",
+	"unload.none_filtered":     "Du hast keine Engramme, die bewegt werden müssen. Viel Spaß beim Farmen, Hüter!",
+	"unload.moved.one":         "Alles erledigt Hüter, %s wurde in deinen Tresor bewegt. ",
+	"unload.moved.other":       "Alles erledigt Hüter, %s wurden in deinen Tresor bewegt. ",
+	"unload.not_moved":         "%s passten nicht mehr in deinen Tresor. ",
+	"unload.farming":           "Viel Spaß beim Farmen, Hüter!",
+	"engrams.one":              "%d Engramm",
+	"engrams.other":            "%d Engramme",
+	"engrams.tier.one":         "%d %ses Engramm",
+	"engrams.tier.other":       "%d %se Engramme",
+
+	// Background operations
+	"background.still_working": "Das dauert etwas länger als sonst Hüter. Ich arbeite weiter an: %s, und das Ergebnis findest du in der Alexa App.",
+	"card.still_working.title": "In Arbeit",
+	"card.still_working":       "Guardian Helper arbeitet noch an: %s.",
+	"card.finished.title":      "Erledigt",
+	"card.finished":            "Erledigt: %s",
+
+	// Characters
+	"characters.error":       "Entschuldige Hüter, beim Laden deiner Charaktere ist ein Fehler aufgetreten.",
+	"characters.none":        "Du hast noch keine Charaktere in Destiny, Hüter.",
+	"characters.summary":     "Dein %s hat eine Lichtstufe von %d und wurde zuletzt %s gespielt. ",
+	"character.describe":     "%[3]s, %[1]s, %[2]s",
+	"card.characters.title":  "Deine Hüter",
+	"card.character":         "%s\nLicht: %d\nZuletzt gespielt: %s\n\n",
+	"lastplayed.unknown":     "zu einem unbekannten Zeitpunkt",
+	"lastplayed.recent":      "innerhalb der letzten Stunde",
+	"lastplayed.hours.one":   "vor %d Stunde",
+	"lastplayed.hours.other": "vor %d Stunden",
+	"lastplayed.yesterday":   "gestern",
+	"lastplayed.days":        "vor %d Tagen",
+	"lastplayed.months":      "vor %d Monaten",
+
+	// Trials of Osiris
+	"trials.unavailable":         "Entschuldige Hüter, ich kann gerade nicht auf diese Informationen zugreifen, bitte versuche es später noch einmal.",
+	"trials.map":                 "Laut Trials Report ist die aktuelle Karte der Prüfungen von Osiris seit dem %[2]d. %[1]s %[3]s, viel Glück Hüter.",
+	"card.trials.map":            "Karte: %[1]s\nBeginn: %[3]d. %[2]s",
+	"trials.week":                "Bisher hast du laut Trials Report %d Spiele mit %d Siegen, %d Niederlagen und einer KD von %s gespielt",
+	"trials.week.none":           "Du hast diese Woche noch keine Spiele in den Prüfungen von Osiris gespielt, Hüter.",
+	"card.trials.week":           "Spiele: %d\nSiege: %d\nNiederlagen: %d\nKD: %s",
+	"trials.top_weapons":         "Laut Trials Report sind die beliebtesten Waffen in den Prüfungen diese Woche: %s",
+	"trials.top_weapons.none":    "Trials Report hat für diese Woche noch keine Daten zu den benutzten Waffen",
+	"trials.top_weapons.entry":   "%s mit %.1f%%",
+	"card.trials.top_weapons":    "Beliebteste Waffen",
+	"card.trials.usage":          "%d. %s: %.1f%%",
+	"display.usage":              "%.1f%%",
+	"trials.personal":            "Laut Trials Report sind deine Waffen mit den meisten Kills: %s",
+	"trials.personal.none":       "Du hast noch keine Waffen in den Prüfungen von Osiris benutzt",
+	"card.trials.personal":       "Deine meistgenutzten Waffen",
+	"card.trials.personal.entry": "%d. %s: %d Kills, %d Kopfschüsse in %d Spielen",
+	"display.top_weapon":         "%d Kills, %d Kopfschüsse in %d Spielen",
+	"trials.weapon_types":        "Bei den Primärwaffen sind diese Woche %s und %s am beliebtesten. Bei den Spezialwaffen sind es laut Trials Report %s und %s. Viel Glück Hüter!",
+	"card.trials.weapon_types":   "Beliebte Waffentypen",
+	"card.trials.primaries":      "Primärwaffen:",
+	"card.trials.specials":       "Spezialwaffen:",
+	"card.trials.weapon_type":    "%s: %d Kills",
+
+	// Names
+	"class.titan":     "Titan",
+	"class.hunter":    "Jäger",
+	"class.warlock":   "Warlock",
+	"class.vault":     "Tresor",
+	"class.guardian":  "Hüter",
+	"race.awoken":     "Erwachter",
+	"race.human":      "Mensch",
+	"race.exo":        "Exo",
+	"gender.male":     "männlich",
+	"gender.female":   "weiblich",
+	"tier.uncommon":   "ungewöhnlich",
+	"tier.rare":       "selten",
+	"tier.legendary":  "legendär",
+	"tier.exotic":     "exotisch",
+	"month.January":   "Januar",
+	"month.February":  "Februar",
+	"month.March":     "März",
+	"month.April":     "April",
+	"month.May":       "Mai",
+	"month.June":      "Juni",
+	"month.July":      "Juli",
+	"month.August":    "August",
+	"month.September": "September",
+	"month.October":   "Oktober",
+	"month.November":  "November",
+	"month.December":  "Dezember",
+
+	"platform.xbox":        "Xbox",
+	"platform.playstation": "PlayStation",
+	"verbosity.brief":      "kurz",
+	"verbosity.normal":     "ausführlich",
+}
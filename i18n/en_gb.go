@@ -0,0 +1,14 @@
+package i18n
+
+// englishGB only includes the messages that differ from englishUS.
+var englishGB = Messages{
+	"list.last": "%s and %s",
+
+	"bucket.ClassArmor": "Class Armour",
+
+	"maxlight.done":       "Max light equipped to your %s Guardian. You are a force to be reckoned with.",
+	"trials.map":          "According to Trials Report, the current Trials of Osiris map beginning %[2]d %[1]s is %[3]s, good luck Guardian.",
+	"card.trials.map":     "Map: %[1]s\nStarted: %[3]d %[2]s",
+	"trials.week.none":    "You haven't played any Trials of Osiris matches this week Guardian.",
+	"trials.weapon_types": "For primaries it looks like %ss and %ss are the most popular this week. %ss and %ss seem to be the most popular special weapons according to Trials Report. Good luck Guardian!",
+}
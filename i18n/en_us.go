@@ -0,0 +1,203 @@
+package i18n
+
+var englishUS = Messages{
+	// Lists
+	"list.pair": "%s and %s",
+	"list.last": "%s, and %s",
+
+	// General
+	"error.link_account":       "Sorry Guardian, it looks like your Bungie.net account needs to be linked in the Alexa app.",
+	"error.no_destiny_account": "Sorry Guardian, I could not find a Destiny account linked to your Bungie.net account.",
+	"error.bungie_unavailable": "Sorry Guardian, Bungie.net is not available right now. Please try again in a few minutes.",
+	"error.unexpected":         "Sorry Guardian, something went wrong. Please try again.",
+	"error.rate_limited":       "Slow down Guardian, you have made a lot of requests. Please try again in a minute.",
+	"sign_in.context":          "To manage your Destiny inventory",
+	"sign_in.speech":           "Sorry Guardian, your Bungie.net account needs to be linked first.",
+	"welcome": "Welcome Guardian, would you like to equip max light, unload engrams, or transfer an item to a specific character, " +
+		"find out how many of an item you have, or ask about Trials of Osiris?",
+	"welcome.reprompt": "Do you want to equip max light, unload engrams, transfer an item, find out how much of an item you have, or ask about Trials of Osiris?",
+	"welcome.brief":    "What would you like to do, Guardian?",
+	"help": "Welcome Guardian, I am here to help manage your Destiny in-game inventory. You can ask " +
+		"me to equip your max light loadout, unload engrams from your inventory, or transfer items between your available " +
+		"characters including the vault. You can also ask how many of an " +
+		"item you have. Trials of Osiris statistics provided by Trials Report are available too.",
+	"dialog.unknown": "Sorry Guardian, I'm not sure what you would like me to do with that. You can ask me to " +
+		"transfer an item or find out how many of an item you have.",
+	"repeat.nothing":       "Sorry Guardian, I haven't said anything yet. What would you like me to do?",
+	"fallback":             "Sorry Guardian, I didn't catch that. %s",
+	"fallback.confirm":     "Please say yes to continue or no to cancel.",
+	"fallback.suggestions": "Sorry Guardian, I can't help with that. You can ask me to equip max light, unload engrams, transfer an item, count an item, or ask about Trials of Osiris.",
+	"character.unknown":    "Sorry Guardian, I don't know which character %s is. %s",
+	"items.default":        "items",
+	"item.unknown":         "Unknown",
+	"item.gear":            "gear",
+
+	// Counting items
+	"count.ask_item":       "Which item would you like me to count?",
+	"count.error":          "Sorry Guardian, an error occurred counting that item.",
+	"count.not_found":      "Sorry Guardian, I could not find any items named %s in your inventory.",
+	"count.none":           "You don't have any %s on any of your characters.",
+	"count.character":      "Your %s has %d %s. ",
+	"count.total":          "You have %d %s in total.",
+	"count.family":         "Across your characters and vault you have %s.",
+	"count.family.entry":   "%d %s",
+	"count.family.none":    "You don't have any %s on any of your characters or in your vault.",
+	"card.inventory.entry": "%s: %d",
+	"card.inventory.total": "Total: %d",
+
+	// Transferring items
+	"transfer.ask_item":           "Which item would you like to transfer?",
+	"transfer.ask_source":         "Which character should I transfer them from?",
+	"transfer.ask_destination":    "Which character should get your %s?",
+	"transfer.invalid_count":      "Sorry Guardian, I didn't understand the number you asked to be transferred. Do not specify a quantity if you want all to be transferred.",
+	"transfer.non_positive_count": "Sorry Guardian, you need to specify a positive, non-zero number to be transferred, not %d",
+	"transfer.progress":           "Transferring your %s now Guardian.",
+	"transfer.description":        "%s transfer",
+	"transfer.error":              "Sorry Guardian, an error occurred trying to transfer that item.",
+	"transfer.no_character":       "Sorry Guardian, I could not transfer your %s because you do not have any %s characters in Destiny.",
+	"transfer.partial":            "You only had %d %s on other characters, all of it has been transferred to your %s",
+	"transfer.done":               "All set Guardian, %d %s have been transferred to your %s",
+	"card.transfer.title":         "Transferred %s",
+	"card.transfer":               "Transferred %d to your %s.\n\nBefore the transfer:\n%s",
+
+	// Max light
+	"maxlight.error":             "Sorry Guardian, an error occurred equipping your max light gear.",
+	"maxlight.progress":          "Working on it Guardian, finding your highest light gear.",
+	"maxlight.description":       "max light loadout",
+	"maxlight.done":              "Max light equipped to your %s Guardian. You are a force to be wreckoned with.",
+	"maxlight.plan.one":          "This will move %d item to your %s",
+	"maxlight.plan.other":        "This will move %d items to your %s",
+	"maxlight.plan.unequip":      "%s and unequip %s",
+	"maxlight.plan.unequip_item": "your %s from your %s",
+	"card.maxlight.title":        "Max Light %s",
+	"card.maxlight.slot":         "%s: %s (%d)",
+	"card.maxlight.light":        "Light: %.1f",
+	"display.slot":               "%s (%d)",
+	"bucket.Primary":             "Primary",
+	"bucket.Special":             "Special",
+	"bucket.Heavy":               "Heavy",
+	"bucket.Ghost":               "Ghost",
+	"bucket.Helmet":              "Helmet",
+	"bucket.Arms":                "Arms",
+	"bucket.Chest":               "Chest",
+	"bucket.Legs":                "Legs",
+	"bucket.ClassArmor":          "Class Armor",
+	"bucket.Artifact":            "Artifact",
+
+	// Confirmations
+	"confirm.continue":         "%s Do you want to continue?",
+	"confirm.nothing":          "Sorry Guardian, there is nothing waiting to be confirmed.",
+	"cancel.action":            "Okay Guardian, I won't change anything.",
+	"cancel.ok":                "Okay Guardian.",
+	"confirmations.save_error": "Sorry Guardian, I could not save that setting right now.",
+	"confirmations.enabled":    "Okay Guardian, I will check with you before equipping max light or unloading engrams.",
+	"confirmations.disabled":   "Okay Guardian, I will equip max light and unload engrams without asking first.",
+
+	// Preferences
+	"preferences.platform":          "Okay Guardian, I will use your %s account from now on.",
+	"preferences.unknown_platform":  "Sorry Guardian, I don't know the platform %s. You can say Xbox or PlayStation.",
+	"preferences.character":         "Okay Guardian, your %s is now your default character for max light and transfers.",
+	"preferences.vault_character":   "Sorry Guardian, the vault can't be your default character. %s",
+	"preferences.character_hint":    "You can choose your titan, hunter, or warlock.",
+	"preferences.verbosity.brief":   "Okay Guardian, I will keep my answers short.",
+	"preferences.verbosity.normal":  "Okay Guardian, I will give you all of the details.",
+	"preferences.unknown_verbosity": "Sorry Guardian, I didn't understand how much detail you want. You can ask for brief or detailed answers.",
+
+	// Unloading engrams
+	"unload.unknown_tier":      "Sorry Guardian, I didn't understand which engrams you want to unload.",
+	"unload.unknown_keep_tier": "Sorry Guardian, I didn't understand which engrams you want to keep.",
+	"unload.error":             "Sorry Guardian, an error occurred moving your engrams.",
+	"unload.confirm.one":       "This will move %d engram to your vault. Do you want to continue?",
+	"unload.confirm.other":     "This will move %d engrams to your vault. Do you want to continue?",
+	"unload.progress":          "Working on it Guardian, moving your engrams to the vault.",
+	"unload.description":       "engram unload",
+	"unload.none":              "You don't have any engrams on your characters. Happy farming Guardian!",
+	"unload.none_filtered":     "You don't have any engrams that need to be moved. Happy farming Guardian!",
+	"unload.moved.one":         "All set Guardian, %s was moved to your vault. ",
+	"unload.moved.other":       "All set Guardian, %s were moved to your vault. ",
+	"unload.not_moved":         "%s could not fit in your vault. ",
+	"unload.farming":           "Happy farming Guardian!",
+	"engrams.one":              "%d engram",
+	"engrams.other":            "%d engrams",
+	"engrams.tier.one":         "%d %s engram",
+	"engrams.tier.other":       "%d %s engrams",
+
+	// Background operations
+	"background.still_working": "This is taking a little longer than usual Guardian, I will keep working on your %s and the results will be in the Alexa app when you come back.",
+	"card.still_working.title": "Still working",
+	"card.still_working":       "Guardian Helper is still working on your %s.",
+	"card.finished.title":      "Finished working",
+	"card.finished":            "Your %s finished",
+
+	// Characters
+	"characters.error":       "Sorry Guardian, an error occurred loading your characters.",
+	"characters.none":        "You don't have any characters in Destiny yet Guardian.",
+	"characters.summary":     "Your %s has a light level of %d and was last played %s. ",
+	"character.describe":     "%s %s %s",
+	"card.characters.title":  "Your Guardians",
+	"card.character":         "%s\nLight: %d\nLast played: %s\n\n",
+	"lastplayed.unknown":     "at an unknown time",
+	"lastplayed.recent":      "within the last hour",
+	"lastplayed.hours.one":   "%d hour ago",
+	"lastplayed.hours.other": "%d hours ago",
+	"lastplayed.yesterday":   "yesterday",
+	"lastplayed.days":        "%d days ago",
+	"lastplayed.months":      "%d months ago",
+
+	// Trials of Osiris
+	"trials.unavailable":         "Sorry Guardian, I cannot access this information right now, please try again later.",
+	"trials.map":                 "According to Trials Report, the current Trials of Osiris map beginning %s %d is %s, goodluck Guardian.",
+	"card.trials.map":            "Map: %s\nStarted: %s %d",
+	"trials.week":                "So far you have played %d matches with %d wins, %d losses and a combined KD of %s, according to Trials Report",
+	"trials.week.none":           "You have not yet played any Trials of Osiris matches this week guardian.",
+	"card.trials.week":           "Matches: %d\nWins: %d\nLosses: %d\nKD: %s",
+	"trials.top_weapons":         "According to Trials Report, the top weapons used in trials this week are: %s",
+	"trials.top_weapons.none":    "Trials Report does not have any weapon usage for this week yet",
+	"trials.top_weapons.entry":   "%s with %.1f%%",
+	"card.trials.top_weapons":    "Top Trials Weapons",
+	"card.trials.usage":          "%d. %s: %.1f%%",
+	"display.usage":              "%.1f%%",
+	"trials.personal":            "According to Trials Report, your top weapons by kills are: %s",
+	"trials.personal.none":       "You have no top used weapons in Trials of Osiris",
+	"card.trials.personal":       "Your Top Trials Weapons",
+	"card.trials.personal.entry": "%d. %s: %d kills, %d headshots in %d matches",
+	"display.top_weapon":         "%d kills, %d headshots in %d matches",
+	"trials.weapon_types":        "For primaries it looks like %ss and %ss are the most popular this week. %ss and %ss seem to be the most popular special weapons acoording to Trials Report. Goodluck Guardian!",
+	"card.trials.weapon_types":   "Popular Trials Weapon Types",
+	"card.trials.primaries":      "Primary weapons:",
+	"card.trials.specials":       "Special weapons:",
+	"card.trials.weapon_type":    "%s: %d kills",
+
+	// Names
+	"class.titan":     "titan",
+	"class.hunter":    "hunter",
+	"class.warlock":   "warlock",
+	"class.vault":     "vault",
+	"class.guardian":  "guardian",
+	"race.awoken":     "awoken",
+	"race.human":      "human",
+	"race.exo":        "exo",
+	"gender.male":     "male",
+	"gender.female":   "female",
+	"tier.uncommon":   "uncommon",
+	"tier.rare":       "rare",
+	"tier.legendary":  "legendary",
+	"tier.exotic":     "exotic",
+	"month.January":   "January",
+	"month.February":  "February",
+	"month.March":     "March",
+	"month.April":     "April",
+	"month.May":       "May",
+	"month.June":      "June",
+	"month.July":      "July",
+	"month.August":    "August",
+	"month.September": "September",
+	"month.October":   "October",
+	"month.November":  "November",
+	"month.December":  "December",
+
+	"platform.xbox":        "Xbox",
+	"platform.playstation": "PlayStation",
+	"verbosity.brief":      "brief",
+	"verbosity.normal":     "detailed",
+}
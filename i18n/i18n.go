@@ -0,0 +1,145 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Supported locales
+const (
+	EnglishUS = "en-US"
+	EnglishGB = "en-GB"
+	German    = "de-DE"
+
+	// DefaultLocale is used when a request does not include a locale or the locale is not supported,
+	// it is also used for any messages that are missing from another locale.
+	DefaultLocale = EnglishUS
+)
+
+// Messages maps a message ID to the fmt template used for that message in a single locale.
+type Messages map[string]string
+
+// catalog holds the messages for each supported locale. Locales only need to include the messages
+// that differ from the DefaultLocale.
+var catalog = map[string]Messages{
+	EnglishUS: englishUS,
+	EnglishGB: englishGB,
+	German:    german,
+}
+
+// Localizer formats the messages from the catalog for a single locale.
+type Localizer struct {
+	locale string
+}
+
+// For will return a Localizer for the provided locale. If the exact locale is not supported, another
+// locale with the same language is used (de-AT will use de-DE), otherwise the DefaultLocale is used.
+func For(locale string) *Localizer {
+
+	if _, ok := catalog[locale]; ok {
+		return &Localizer{locale: locale}
+	}
+
+	language := languageOf(locale)
+	if language == languageOf(DefaultLocale) {
+		return &Localizer{locale: DefaultLocale}
+	}
+	for supported := range catalog {
+		if languageOf(supported) == language {
+			return &Localizer{locale: supported}
+		}
+	}
+
+	return &Localizer{locale: DefaultLocale}
+}
+
+func languageOf(locale string) string {
+	return strings.ToLower(strings.SplitN(locale, "-", 2)[0])
+}
+
+// Locale is the supported locale used by the Localizer.
+func (l *Localizer) Locale() string {
+	return l.locale
+}
+
+// Language is the two letter language code of the locale, for example "en" or "de".
+func (l *Localizer) Language() string {
+	return languageOf(l.locale)
+}
+
+// Sprintf will format the message with the provided ID using the args. Messages missing from the
+// locale fall back to the DefaultLocale, unknown message IDs are returned as is.
+func (l *Localizer) Sprintf(id string, args ...interface{}) string {
+
+	template, ok := catalog[l.locale][id]
+	if !ok {
+		template, ok = catalog[DefaultLocale][id]
+	}
+	if !ok {
+		fmt.Printf("Missing message for ID: %s\n", id)
+		return id
+	}
+
+	if len(args) == 0 {
+		return template
+	}
+
+	return fmt.Sprintf(template, args...)
+}
+
+// Plural will format the singular ("id.one") or plural ("id.other") form of the message depending
+// on the count. The count is not included in the args automatically.
+func (l *Localizer) Plural(id string, count uint, args ...interface{}) string {
+
+	if count == 1 {
+		return l.Sprintf(id+".one", args...)
+	}
+
+	return l.Sprintf(id+".other", args...)
+}
+
+// Name will translate one of the fixed names used in responses, like class or tier names. The kind
+// is the message ID prefix, for example Name("class", "titan"). If there is no translation the
+// name is returned as is.
+func (l *Localizer) Name(kind, name string) string {
+
+	id := kind + "." + name
+	if template, ok := catalog[l.locale][id]; ok {
+		return template
+	} else if template, ok := catalog[DefaultLocale][id]; ok {
+		return template
+	}
+
+	return name
+}
+
+// FindName is the reverse of Name, it will find which of the names has the provided translation.
+// This is used to understand the values spoken by the user. Names in the DefaultLocale are also
+// accepted. false is returned if none of the names match.
+func (l *Localizer) FindName(kind, translation string, names []string) (string, bool) {
+
+	translation = strings.ToLower(strings.TrimSpace(translation))
+	for _, name := range names {
+		if strings.ToLower(l.Name(kind, name)) == translation || name == translation {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// List will join the phrases into a list that reads naturally when spoken in the locale.
+// For example: "a, b, and c"
+func (l *Localizer) List(phrases []string) string {
+
+	switch len(phrases) {
+	case 0:
+		return ""
+	case 1:
+		return phrases[0]
+	case 2:
+		return l.Sprintf("list.pair", phrases[0], phrases[1])
+	}
+
+	return l.Sprintf("list.last", strings.Join(phrases[:len(phrases)-1], ", "), phrases[len(phrases)-1])
+}
@@ -0,0 +1,122 @@
+package i18n
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+var verbPattern = regexp.MustCompile(`%(?:\[(\d+)\])?[-+# 0]*\d*(?:\.\d+)?([a-zA-Z%])`)
+
+// templateArgs will find the verb used for each argument of a template, so templates that reorder
+// their arguments can be compared with the DefaultLocale.
+func templateArgs(template string) map[int]string {
+
+	args := make(map[int]string)
+	next := 1
+	for _, match := range verbPattern.FindAllStringSubmatch(template, -1) {
+		if match[2] == "%" {
+			continue
+		}
+		if match[1] != "" {
+			next, _ = strconv.Atoi(match[1])
+		}
+		args[next] = match[2]
+		next++
+	}
+
+	return args
+}
+
+func TestCatalogMatchesDefaultLocale(t *testing.T) {
+
+	for locale, messages := range catalog {
+		for id, template := range messages {
+			defaultTemplate, ok := catalog[DefaultLocale][id]
+			if !ok {
+				t.Errorf("%s message %s is missing from %s", locale, id, DefaultLocale)
+				continue
+			}
+
+			if !reflect.DeepEqual(templateArgs(template), templateArgs(defaultTemplate)) {
+				t.Errorf("%s message %s does not use the same arguments as %s", locale, id, DefaultLocale)
+			}
+		}
+	}
+
+	// Every message should be translated to German
+	for id := range catalog[DefaultLocale] {
+		if _, ok := catalog[German][id]; !ok {
+			t.Errorf("Message %s is missing from %s", id, German)
+		}
+	}
+}
+
+func TestFor(t *testing.T) {
+
+	cases := map[string]string{
+		"en-US": EnglishUS,
+		"en-GB": EnglishGB,
+		"en-IN": EnglishUS,
+		"de-DE": German,
+		"de-AT": German,
+		"ja-JP": DefaultLocale,
+		"":      DefaultLocale,
+	}
+	for locale, expected := range cases {
+		if result := For(locale).Locale(); result != expected {
+			t.Errorf("Expected %s to use %s, got %s", locale, expected, result)
+		}
+	}
+}
+
+func TestSprintfFallsBack(t *testing.T) {
+
+	l := For(EnglishGB)
+	if result := l.Sprintf("count.none", "spinmetal"); result != "You don't have any spinmetal on any of your characters." {
+		t.Errorf("Expected the en-US message, got: %s", result)
+	}
+	if result := l.Sprintf("trials.map", "June", 9, "Asylum"); result != "According to Trials Report, the current Trials of Osiris map beginning 9 June is Asylum, good luck Guardian." {
+		t.Errorf("Unexpected en-GB message: %s", result)
+	}
+	if result := l.Sprintf("missing.message"); result != "missing.message" {
+		t.Errorf("Expected the ID for an unknown message, got: %s", result)
+	}
+}
+
+func TestList(t *testing.T) {
+
+	phrases := []string{"a", "b", "c"}
+	cases := map[string]string{
+		EnglishUS: "a, b, and c",
+		EnglishGB: "a, b and c",
+		German:    "a, b und c",
+	}
+	for locale, expected := range cases {
+		if result := For(locale).List(phrases); result != expected {
+			t.Errorf("Unexpected %s list: %s", locale, result)
+		}
+	}
+
+	if result := For(German).List([]string{"a", "b"}); result != "a und b" {
+		t.Errorf("Unexpected list of two: %s", result)
+	}
+	if result := For(EnglishUS).List([]string{"a"}); result != "a" {
+		t.Errorf("Unexpected list of one: %s", result)
+	}
+}
+
+func TestFindName(t *testing.T) {
+
+	classes := []string{"titan", "hunter", "warlock", "vault"}
+	if name, ok := For(German).FindName("class", "Jäger", classes); !ok || name != "hunter" {
+		t.Errorf("Expected to find the hunter class, got %s", name)
+	}
+	if name, ok := For(German).FindName("class", "titan", classes); !ok || name != "titan" {
+		t.Errorf("Expected the default locale names to be accepted, got %s", name)
+	}
+	if _, ok := For(EnglishUS).FindName("class", "paladin", classes); ok {
+		t.Errorf("Expected an unknown class to not be found")
+	}
+}
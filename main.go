@@ -3,14 +3,21 @@ package main
 import (
 	"flag"
 	"fmt"
+	"net/http"
 	"net/http/httputil"
 	"os"
+	"time"
 
+	"github.com/rking788/guardian-helper/admin"
+	"github.com/rking788/guardian-helper/api"
 	"github.com/rking788/guardian-helper/bungie"
+	"github.com/rking788/guardian-helper/dialogflow"
 
 	"github.com/rking788/guardian-helper/alexa"
 
+	"github.com/codegangsta/negroni"
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/mux"
 	"github.com/mikeflynn/go-alexa/skillserver"
 )
 
@@ -18,38 +25,96 @@ import (
 var (
 	Applications = map[string]interface{}{
 		"/echo/guardian-helper": skillserver.EchoApplication{ // Route
-			AppID:          os.Getenv("ALEXA_APP_ID"), // Echo App ID from Amazon Dashboard
-			OnIntent:       EchoIntentHandler,
-			OnLaunch:       EchoIntentHandler,
-			OnSessionEnded: EchoSessionEndedHandler,
+			AppID:   os.Getenv("ALEXA_APP_ID"), // Echo App ID from Amazon Dashboard
+			Handler: EchoRequestHandler,
+		},
+		"/alexa/events": skillserver.StdApplication{
+			Methods: "POST",
+			Handler: alexa.SkillEventHandler(os.Getenv("ALEXA_APP_ID")),
+		},
+		"/admin/unknown-values": skillserver.StdApplication{
+			Methods: "GET",
+			Handler: admin.Authenticated(admin.UnknownValues),
+		},
+		"/admin/translations": skillserver.StdApplication{
+			Methods: "POST",
+			Handler: admin.Authenticated(admin.AddTranslation),
 		},
 	}
 )
 
 // AlexaHandlers are the handler functions mapped by the intent name that they should handle.
+// Every intent in alexa.InteractionModel needs a handler here.
 var (
 	AlexaHandlers = map[string]alexa.Handler{
-		"CountItem":                alexa.AuthWrapper(alexa.CountItem),
-		"TransferItem":             alexa.AuthWrapper(alexa.TransferItem),
+		"CountItem":                linked(alexa.CountItem),
+		"TransferItem":             linked(alexa.TransferItem),
 		"TrialsCurrentMap":         alexa.CurrentTrialsMap,
-		"TrialsCurrentWeek":        alexa.AuthWrapper(alexa.CurrentTrialsWeek),
+		"TrialsCurrentWeek":        withMembership(alexa.CurrentTrialsWeek),
 		"TrialsTopWeapons":         alexa.PopularWeapons,
 		"TrialsPopularWeaponTypes": alexa.PopularWeaponTypes,
-		"TrialsPersonalTopWeapons": alexa.AuthWrapper(alexa.PersonalTopWeapons),
-		"UnloadEngrams":            alexa.AuthWrapper(alexa.UnloadEngrams),
-		"EquipMaxLight":            alexa.AuthWrapper(alexa.MaxLight),
+		"TrialsPersonalTopWeapons": withMembership(alexa.PersonalTopWeapons),
+		"UnloadEngrams":            linked(alexa.UnloadEngrams),
+		"EquipMaxLight":            linked(alexa.MaxLight),
+		"CharacterSummary":         linked(alexa.CharacterSummary),
+		"ProvideItem":              linked(alexa.ContinueDialog),
+		"ProvideCharacter":         linked(alexa.ContinueDialog),
+		"EnableConfirmations":      alexa.EnableConfirmations,
+		"DisableConfirmations":     alexa.DisableConfirmations,
+		"SetPlatform":              alexa.SetPlatform,
+		"SetDefaultCharacter":      alexa.SetDefaultCharacter,
+		"SetVerbosity":             alexa.SetVerbosity,
+		"AMAZON.YesIntent":         linked(alexa.ConfirmAction),
+		"AMAZON.NoIntent":          alexa.CancelAction,
 		"AMAZON.HelpIntent":        alexa.HelpPrompt,
+		"AMAZON.StopIntent":        alexa.EndSession,
+		"AMAZON.CancelIntent":      alexa.EndSession,
+		"AMAZON.RepeatIntent":      alexa.Repeat,
+		"AMAZON.StartOverIntent":   alexa.StartOver,
+		"AMAZON.FallbackIntent":    alexa.Fallback,
 	}
 )
 
+// IntentMiddleware is applied to every launch and intent request, including intents without a
+// handler in AlexaHandlers.
+var IntentMiddleware = []alexa.Middleware{
+	alexa.Recover,
+	alexa.Timing,
+	alexa.RateLimit(RequestsPerMinute, time.Minute),
+	alexa.RememberResponse,
+}
+
+// linked will wrap a handler that requires a linked Bungie.net account.
+func linked(handler alexa.Handler) alexa.Handler {
+	return alexa.Chain(handler, alexa.AuthWrapper)
+}
+
+// withMembership will wrap a handler that requires the Destiny membership of the linked account.
+func withMembership(handler alexa.Handler) alexa.Handler {
+	return alexa.Chain(handler, alexa.AuthWrapper, alexa.ResolveMembership)
+}
+
 var memprofile = flag.String("memprofile", "", "write memory profile to this file")
 
+const (
+	// TranslationReloadInterval is how often the Alexa translations are reloaded from the database
+	TranslationReloadInterval = 5 * time.Minute
+	// RequestsPerMinute is how many requests a single Alexa user can make each minute
+	RequestsPerMinute = 20
+)
+
 func main() {
 
 	flag.Parse()
 	port := os.Getenv("PORT")
 
-	err := bungie.PopulateEngramHashes()
+	err := alexa.CheckHandlers(AlexaHandlers)
+	if err != nil {
+		fmt.Printf("The handlers do not match the interaction model: %s\nExiting...", err.Error())
+		return
+	}
+
+	err = bungie.PopulateEngramHashes()
 	if err != nil {
 		fmt.Printf("Error populating engram hashes: %s\nExiting...", err.Error())
 		return
@@ -64,8 +129,17 @@ func main() {
 		fmt.Printf("Error populating item metadata lookup table: %s\nExiting...", err.Error())
 		return
 	}
-
-	//bungie.EquipMaxLightGear("access-token")
+	err = bungie.PopulateTranslations()
+	if err != nil {
+		fmt.Printf("Error populating Alexa translations: %s\nExiting...", err.Error())
+		return
+	}
+	bungie.StartTranslationReloader(TranslationReloadInterval)
+	err = alexa.PopulatePronunciations()
+	if err != nil {
+		fmt.Printf("Error populating pronunciations: %s\nExiting...", err.Error())
+		return
+	}
 
 	// c := make(chan os.Signal, 1)
 	// signal.Notify(c, os.Interrupt)
@@ -84,14 +158,68 @@ func main() {
 	// 	}
 	// }()
 
+	// The Dialogflow fulfillment is only served when it can be authenticated
+	if os.Getenv("DIALOGFLOW_TOKEN") != "" {
+		Applications["/dialogflow/fulfillment"] = skillserver.StdApplication{
+			Methods: "POST",
+			Handler: dialogflow.WebhookHandler(handleIntent),
+		}
+	} else {
+		fmt.Println("DIALOGFLOW_TOKEN is not set, the Dialogflow fulfillment route is disabled")
+	}
+
 	fmt.Println(fmt.Sprintf("Start listening on port(%s)", port))
-	skillserver.Run(Applications, port)
+	router := mux.NewRouter()
+	// The REST API is added before skillserver registers its catch-all route for the other applications
+	router.PathPrefix("/api/").Handler(api.NewRouter())
+	skillserver.Init(Applications, router)
+
+	// The raw request body is captured before skillserver decodes it so the parts of the
+	// request envelope that skillserver ignores are available to the handlers.
+	n := negroni.Classic()
+	n.Use(negroni.HandlerFunc(alexa.CaptureRequestBody))
+	n.UseHandler(router)
+	n.Run(":" + port)
 }
 
 // Alexa skill related functions
 
+// EchoRequestHandler replaces the default skillserver handler so that the handlers receive the full
+// Alexa request, including the fields skillserver does not decode. The results of any operations that
+// finished in the background since the user's last request are added to the response, devices with a
+// screen are sent a display template, and the speech is converted to SSML.
+func EchoRequestHandler(w http.ResponseWriter, r *http.Request) {
+
+	echoRequest := alexa.RequestFromHTTP(r)
+	echoResponse := skillserver.NewEchoResponse()
+
+	switch echoRequest.GetRequestType() {
+	case "LaunchRequest", "IntentRequest":
+		EchoIntentHandler(echoRequest, echoResponse)
+	case "SessionEndedRequest":
+		EchoSessionEndedHandler(echoRequest, echoResponse)
+	default:
+		http.Error(w, "Invalid request.", http.StatusBadRequest)
+		return
+	}
+
+	alexa.AttachCompletedJobs(echoRequest, echoResponse)
+	alexa.RenderDisplay(echoRequest, echoResponse)
+	alexa.ConvertToSSML(echoResponse)
+
+	json, err := alexa.MarshalResponse(echoRequest, echoResponse)
+	if err != nil {
+		fmt.Println("Failed to serialize the Alexa response: ", err.Error())
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
+	w.Write(json)
+}
+
 // EchoSessionEndedHandler is responsible for cleaning up an open session since the user has quit the session.
-func EchoSessionEndedHandler(echoRequest *skillserver.EchoRequest, echoResponse *skillserver.EchoResponse) {
+func EchoSessionEndedHandler(echoRequest *alexa.Request, echoResponse *skillserver.EchoResponse) {
 	*echoResponse = *skillserver.NewEchoResponse()
 
 	alexa.ClearSession(echoRequest.GetSessionID())
@@ -99,37 +227,42 @@ func EchoSessionEndedHandler(echoRequest *skillserver.EchoRequest, echoResponse
 
 // EchoIntentHandler is a handler method that is responsible for receiving the
 // call from a Alexa command and returning the correct speech or cards.
-func EchoIntentHandler(echoRequest *skillserver.EchoRequest, echoResponse *skillserver.EchoResponse) {
+func EchoIntentHandler(echoRequest *alexa.Request, echoResponse *skillserver.EchoResponse) {
+	*echoResponse = *handleIntent(echoRequest)
+}
 
-	var response *skillserver.EchoResponse
+// handleIntent will answer a launch request or intent with the IntentMiddleware, this is shared by the
+// Alexa skill and the Dialogflow fulfillment. The session is cleared when the response ends it.
+func handleIntent(echoRequest *alexa.Request) *skillserver.EchoResponse {
 
 	// See if there is an existing session, or create a new one.
 	session := alexa.GetSession(echoRequest.GetSessionID())
 	alexa.SaveSession(session)
 
-	intentName := echoRequest.GetIntentName()
+	fmt.Printf("Launching with RequestType: %s, IntentName: %s\n", echoRequest.GetRequestType(), echoRequest.GetIntentName())
 
-	fmt.Printf("Launching with RequestType: %s, IntentName: %s\n", echoRequest.GetRequestType(), intentName)
+	response := alexa.Chain(routeIntent, IntentMiddleware...)(echoRequest)
 
+	if response.Response.ShouldEndSession {
+		alexa.ClearSession(session.ID)
+	}
+
+	return response
+}
+
+// routeIntent will call the handler for the launch request or intent. Intents without a handler
+// are treated like the AMAZON.FallbackIntent.
+func routeIntent(echoRequest *alexa.Request) *skillserver.EchoResponse {
+
+	intentName := echoRequest.GetIntentName()
 	handler, ok := AlexaHandlers[intentName]
 	if echoRequest.GetRequestType() == "LaunchRequest" {
-		response = alexa.WelcomePrompt(echoRequest)
-	} else if intentName == "AMAZON.StopIntent" {
-		response = skillserver.NewEchoResponse()
-	} else if intentName == "AMAZON.CancelIntent" {
-		response = skillserver.NewEchoResponse()
+		return alexa.WelcomePrompt(echoRequest)
 	} else if ok {
-		response = handler(echoRequest)
-	} else {
-		response = skillserver.NewEchoResponse()
-		response.OutputSpeech("Sorry Guardian, I did not understand your request.")
-	}
-
-	if response.Response.ShouldEndSession {
-		alexa.ClearSession(session.ID)
+		return handler(echoRequest)
 	}
 
-	*echoResponse = *response
+	return alexa.Fallback(echoRequest)
 }
 
 func dumpRequest(ctx *gin.Context) {
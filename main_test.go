@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/rking788/guardian-helper/alexa"
+)
+
+func TestHandlersMatchInteractionModel(t *testing.T) {
+
+	err := alexa.CheckHandlers(AlexaHandlers)
+	if err != nil {
+		t.Errorf("The handlers do not match the interaction model: %s", err.Error())
+	}
+}
+
+func TestGeneratedModelIsCurrent(t *testing.T) {
+
+	generated := bytes.NewBufferString("")
+	alexa.WriteIntentSchema(generated)
+	schema, err := ioutil.ReadFile("config/intent_schema.json")
+	if err != nil || !bytes.Equal(schema, generated.Bytes()) {
+		t.Errorf("config/intent_schema.json is out of date, run: go run cmd/interaction-model/main.go")
+	}
+
+	generated.Reset()
+	alexa.WriteSampleUtterances(generated)
+	samples, err := ioutil.ReadFile("config/sample_utterances.txt")
+	if err != nil || !bytes.Equal(samples, generated.Bytes()) {
+		t.Errorf("config/sample_utterances.txt is out of date, run: go run cmd/interaction-model/main.go")
+	}
+}
@@ -15,3 +15,8 @@ const (
 	// How many weapons to return in the Alexa response describing usage stats
 	TopWeaponUsageLimit = 3
 )
+
+const (
+	// TrialsCardTitle is the title of the Alexa app cards describing the current Trials week
+	TrialsCardTitle = "Trials of Osiris"
+)
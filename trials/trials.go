@@ -5,18 +5,16 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
-	"os"
 	"sort"
 
 	"time"
 
 	"strconv"
+	"strings"
 
-	"bytes"
-
-	"github.com/mikeflynn/go-alexa/skillserver"
 	"github.com/rking788/guardian-helper/bungie"
 	"github.com/rking788/guardian-helper/db"
+	"github.com/rking788/guardian-helper/i18n"
 )
 
 const (
@@ -58,7 +56,6 @@ type WeaponStats struct {
 	Bucket     string `json:"bucket"`
 	FileName   string `json:"file_name"`
 	SumKills   string `json:"sum_kills"`
-	killsInt   int64
 }
 
 // WeaponUsage is used in the response from the weapon percentage endpoint. It describes the popularity
@@ -78,22 +75,68 @@ type PersonalWeaponStats struct {
 	WeaponID     string `json:"weaponId"`
 }
 
-// GetCurrentMap will make a request to the Trials Report API endpoint and
-// return an Alexa response describing the current map.
-func GetCurrentMap() (*skillserver.EchoResponse, error) {
+// MapSummary describes the map for the active Trials of Osiris week.
+type MapSummary struct {
+	Name       string    `json:"name"`
+	WeekNumber string    `json:"week_number"`
+	Start      time.Time `json:"start"`
+}
+
+// WeekSummary is the linked player's record for the current Trials of Osiris week.
+type WeekSummary struct {
+	Matches int64  `json:"matches"`
+	Wins    int64  `json:"wins"`
+	Losses  int64  `json:"losses"`
+	KD      string `json:"kd"`
+}
+
+// PopularWeapon is one of the most used weapons by all players for the current week.
+type PopularWeapon struct {
+	Name       string  `json:"name"`
+	Percentage float64 `json:"percentage"`
+	IconURL    string  `json:"icon_url"`
+}
+
+// TopWeapon is one of the weapons used the most by the linked player.
+type TopWeapon struct {
+	ItemHash uint `json:"item_hash"`
+	// Name is in the language of the locale, empty if the weapon is unknown
+	Name         string `json:"name"`
+	IconURL      string `json:"icon_url"`
+	Kills        int    `json:"kills"`
+	Headshots    int    `json:"headshots"`
+	TotalMatches int    `json:"total_matches"`
+}
+
+// WeaponTypeKills is the number of kills for a weapon type like "Hand Cannon" in the current week.
+type WeaponTypeKills struct {
+	WeaponType string `json:"weapon_type"`
+	Kills      int64  `json:"kills"`
+}
 
-	response := skillserver.NewEchoResponse()
+// WeaponTypeStats are the kills for each primary and special weapon type, sorted by the most kills.
+type WeaponTypeStats struct {
+	Primaries []*WeaponTypeKills `json:"primaries"`
+	Specials  []*WeaponTypeKills `json:"specials"`
+}
+
+// GetCurrentMap will make a request to the Trials Report API endpoint and
+// return the current map.
+func GetCurrentMap() (*MapSummary, error) {
 
 	currentMap, err := requestCurrentMap()
-	start, err := time.Parse("2006-01-02 15:04:05", currentMap.StartDate)
 	if err != nil {
 		fmt.Println("Failed to read the current map from Trials Report!: ", err.Error())
 		return nil, err
 	}
 
-	response.OutputSpeech(fmt.Sprintf("According to Trials Report, the current Trials of Osiris map beginning %s %d is %s, goodluck Guardian.", start.Month().String(), start.Day(), currentMap.Name))
+	start, err := time.Parse("2006-01-02 15:04:05", currentMap.StartDate)
+	if err != nil {
+		fmt.Println("Failed to read the current map from Trials Report!: ", err.Error())
+		return nil, err
+	}
 
-	return response, nil
+	return &MapSummary{Name: currentMap.Name, WeekNumber: currentMap.WeekNumber, Start: start}, nil
 }
 
 // Convenience method for loading current map data from Trials Report. This is used in a
@@ -121,10 +164,9 @@ func requestCurrentMap() (*CurrentMap, error) {
 }
 
 // GetCurrentWeek is responsible for requesting the players stats from the current week from Trials Report.
-func GetCurrentWeek(token string) (*skillserver.EchoResponse, error) {
-	response := skillserver.NewEchoResponse()
-
-	membershipID, err := findMembershipID(token)
+// The membershipID is the Destiny membership of the linked account. The summary has zero matches if
+// the player has not played this week.
+func GetCurrentWeek(membershipID string) (*WeekSummary, error) {
 
 	url := fmt.Sprintf(TrialsCurrentWeekEndpointFmt, membershipID)
 	req, _ := http.NewRequest("GET", url, nil)
@@ -143,43 +185,23 @@ func GetCurrentWeek(token string) (*skillserver.EchoResponse, error) {
 	if err != nil {
 		fmt.Println("Error parsing trials report response: ", err.Error())
 		return nil, err
+	} else if len(currentWeeks) <= 0 {
+		return &WeekSummary{}, nil
 	}
 
-	matches, _ := strconv.ParseInt(currentWeeks[0].Matches, 10, 32)
-	if matches != 0 {
-		losses, _ := strconv.ParseInt(currentWeeks[0].Losses, 10, 32)
-		wins := matches - losses
-		kd := currentWeeks[0].KD
-		response.OutputSpeech(fmt.Sprintf("So far you have played %d matches with %d wins, %d losses and a combined KD of %s, according to Trials Report", matches, wins, losses, kd))
-	} else {
-		response.OutputSpeech("You have not yet played any Trials of Osiris matches this week guardian.")
+	summary := &WeekSummary{KD: currentWeeks[0].KD}
+	summary.Matches, _ = strconv.ParseInt(currentWeeks[0].Matches, 10, 32)
+	if summary.Matches != 0 {
+		summary.Losses, _ = strconv.ParseInt(currentWeeks[0].Losses, 10, 32)
+		summary.Wins = summary.Matches - summary.Losses
 	}
 
-	return response, nil
+	return summary, nil
 }
 
-// findMembershipID is a helper function for loading the membership ID from the currently
-// linked account, this eventually should take platform into account.
-func findMembershipID(token string) (string, error) {
-
-	client := bungie.NewClient(token, os.Getenv("BUNGIE_API_KEY"))
-	currentAccount, err := client.GetCurrentAccount()
-	if err != nil {
-		fmt.Println("Error loading current account info from Bungie.net: ", err.Error())
-		return "", err
-	} else if currentAccount.Response == nil || currentAccount.Response.DestinyMemberships == nil ||
-		len(currentAccount.Response.DestinyMemberships) == 0 {
-		return "", errors.New("No linked Destiny account found on Bungie.net")
-	}
-
-	// TODO: This should take the platform into account instead of just defaulting to the first one.
-	return currentAccount.Response.DestinyMemberships[0].MembershipID, nil
-}
-
-// GetWeaponUsagePercentages will return a response describing the top 3 used weapons
-// by all players for the current week.
-func GetWeaponUsagePercentages() (*skillserver.EchoResponse, error) {
-	response := skillserver.NewEchoResponse()
+// GetWeaponUsagePercentages will return the top used weapons by all players for the current week,
+// at most TopWeaponUsageLimit weapons are returned.
+func GetWeaponUsagePercentages() ([]*PopularWeapon, error) {
 
 	currentMap, err := requestCurrentMap()
 	if err != nil {
@@ -201,27 +223,31 @@ func GetWeaponUsagePercentages() (*skillserver.EchoResponse, error) {
 
 	usages := make([]WeaponUsage, 0, 50)
 	err = json.NewDecoder(weaponResponse.Body).Decode(&usages)
+	if err != nil {
+		fmt.Println("Failed to decode weapon percentages from Trials Report: ", err.Error())
+		return nil, err
+	}
 
-	buffer := bytes.NewBufferString("According to Trials Report, the top weapons used in trials this week are: ")
+	weapons := make([]*PopularWeapon, 0, TopWeaponUsageLimit)
 	// TODO: Maybe it would be good to have the user specify the number of top weapons they want returned.
-	for i := 0; i < TopWeaponUsageLimit; i++ {
+	for i := 0; i < TopWeaponUsageLimit && i < len(usages); i++ {
 		usagePercent, _ := strconv.ParseFloat(usages[i].Percentage, 64)
-		buffer.WriteString(fmt.Sprintf("%s with %.1f%%, ", usages[i].Name, usagePercent))
+		weapons = append(weapons, &PopularWeapon{
+			Name:       usages[i].Name,
+			Percentage: usagePercent,
+			IconURL:    weaponIconURL(usages[i].Name),
+		})
 	}
 
-	response.OutputSpeech(buffer.String())
-	return response, nil
+	return weapons, nil
 }
 
-// GetPersonalTopWeapons will return a summary of the top weapons used by the linked player/account.
-func GetPersonalTopWeapons(token string) (*skillserver.EchoResponse, error) {
-	response := skillserver.NewEchoResponse()
+// GetPersonalTopWeapons will return the top weapons used by the linked player/account, at most
+// TopWeaponUsageLimit weapons are returned. The membershipID is the Destiny membership of the linked
+// account and the weapon names are in the language of the locale.
+func GetPersonalTopWeapons(membershipID, locale string) ([]*TopWeapon, error) {
 
-	membershipID, err := findMembershipID(token)
-	if err != nil {
-		fmt.Println("Error loading membership ID for linked account: ", err.Error())
-		return nil, err
-	}
+	language := i18n.For(locale).Language()
 
 	url := fmt.Sprintf(TrialsTopWeaponsEndpointFmt, membershipID)
 	req, _ := http.NewRequest("GET", url, nil)
@@ -237,37 +263,37 @@ func GetPersonalTopWeapons(token string) (*skillserver.EchoResponse, error) {
 
 	usages := make([]PersonalWeaponStats, 0, 10)
 	err = json.NewDecoder(topWeaponsResponse.Body).Decode(&usages)
-
-	if len(usages) <= 0 {
-		response.OutputSpeech("You have no top used weapons in Trials of Osiris")
-		return response, nil
+	if err != nil {
+		fmt.Println("Failed to decode top weapons from Trials Report: ", err.Error())
+		return nil, err
 	}
 
-	buffer := bytes.NewBufferString("According to Trials Report, your top weapons by kills are: ")
+	weapons := make([]*TopWeapon, 0, TopWeaponUsageLimit)
 	for index, usage := range usages {
 
 		if index >= TopWeaponUsageLimit {
 			break
 		}
 
-		name, err := db.GetItemNameFromHash(usage.WeaponID)
-		if err != nil {
-			name = "Unknown"
+		weapon := &TopWeapon{Kills: usage.Kills, Headshots: usage.Headshots, TotalMatches: usage.TotalMatches}
+		if hash, err := strconv.ParseUint(usage.WeaponID, 10, 32); err == nil {
+			weapon.ItemHash = uint(hash)
+			if localized, err := db.GetLocalizedItemName(weapon.ItemHash, language); err == nil {
+				weapon.Name = localized
+			}
+			weapon.IconURL = bungie.ItemIconURL(weapon.ItemHash)
 		}
 
-		buffer.WriteString(fmt.Sprintf("%s, ", name))
+		weapons = append(weapons, weapon)
 	}
 
-	response.OutputSpeech(buffer.String())
-
-	return response, nil
+	return weapons, nil
 }
 
 // GetPopularWeaponTypes will hit the Trials Report endpoint to load info about which weapon
-// types are getting the most kills
-func GetPopularWeaponTypes() (*skillserver.EchoResponse, error) {
-
-	response := skillserver.NewEchoResponse()
+// types are getting the most kills. An error is returned if there are not at least two primary
+// and two special weapon types.
+func GetPopularWeaponTypes() (*WeaponTypeStats, error) {
 
 	req, _ := http.NewRequest("GET", TrialsCurrentWeekStatsEndpoint, nil)
 	req.Header.Add("Content-Type", "application/json")
@@ -287,45 +313,55 @@ func GetPopularWeaponTypes() (*skillserver.EchoResponse, error) {
 		return nil, err
 	}
 
-	primaries := make([]WeaponStats, 0, 4)
-	primaryKills := int64(0)
-	specials := make([]WeaponStats, 0, 4)
-	specialKills := int64(0)
+	stats := popularWeaponTypes(weekInfo.WeaponStats)
+	if len(stats.Primaries) < 2 || len(stats.Specials) < 2 {
+		return nil, errors.New("Not enough weapon types in the Trials Report stats")
+	}
 
-	for _, weapon := range weekInfo.WeaponStats {
+	return stats, nil
+}
+
+// popularWeaponTypes will split the weapon stats into primaries and specials sorted by the most kills.
+func popularWeaponTypes(weaponStats []WeaponStats) *WeaponTypeStats {
+
+	stats := &WeaponTypeStats{
+		Primaries: make([]*WeaponTypeKills, 0, 4),
+		Specials:  make([]*WeaponTypeKills, 0, 4),
+	}
+
+	for _, weapon := range weaponStats {
 		kills, err := strconv.ParseInt(weapon.Kills, 10, 64)
 		if err != nil {
 			kills = 0
 		}
 
-		weapon.killsInt = kills
-
 		if weapon.isPrimary() {
-			primaries = append(primaries, weapon)
-			primaryKills += kills
+			stats.Primaries = append(stats.Primaries, &WeaponTypeKills{WeaponType: weapon.WeaponType, Kills: kills})
 		} else if weapon.isSpecial() {
-			specials = append(specials, weapon)
-			specialKills += kills
+			stats.Specials = append(stats.Specials, &WeaponTypeKills{WeaponType: weapon.WeaponType, Kills: kills})
 		}
 	}
 
-	sort.Slice(primaries, func(i, j int) bool {
-		return primaries[i].killsInt > primaries[j].killsInt
+	sort.Slice(stats.Primaries, func(i, j int) bool {
+		return stats.Primaries[i].Kills > stats.Primaries[j].Kills
 	})
-	sort.Slice(specials, func(i, j int) bool {
-		return specials[i].killsInt > specials[j].killsInt
+	sort.Slice(stats.Specials, func(i, j int) bool {
+		return stats.Specials[i].Kills > stats.Specials[j].Kills
 	})
 
-	// NOTE: These aren't returned in the response so maybe the percentages aren't even needed.
-	//primaryPercent := float64(primaries[0].killsInt) / float64(primaryKills)
-	//specialPercent := float64(specials[0].killsInt) / float64(specialKills)
+	return stats
+}
+
+// weaponIconURL will find the icon for the weapon with the provided name, if the weapon is not
+// found an empty string is returned so the card is shown without an image.
+func weaponIconURL(name string) string {
 
-	outputBuffer := bytes.NewBufferString("For primaries it looks like ")
-	outputBuffer.WriteString(fmt.Sprintf("%ss and %ss are the most popular this week. ", primaries[0].WeaponType, primaries[1].WeaponType))
-	outputBuffer.WriteString(fmt.Sprintf("%ss and %ss seem to be the most popular special weapons acoording to Trials Report. Goodluck Guardian!", specials[0].WeaponType, specials[1].WeaponType))
+	hash, err := db.LookupItemHash(strings.ToLower(name))
+	if err != nil {
+		return ""
+	}
 
-	response.OutputSpeech(outputBuffer.String())
-	return response, nil
+	return bungie.ItemIconURL(hash)
 }
 
 // killsSort will return true if the number of kills in A is greater than the number in b
@@ -53,3 +53,21 @@ func TestPopularWeaponTypes(t *testing.T) {
 
 	fmt.Printf("Response: %+v\n", response.Response.OutputSpeech.Text)*/
 }
+
+func TestPopularWeaponTypesSorting(t *testing.T) {
+
+	stats := popularWeaponTypes([]WeaponStats{
+		{WeaponType: "Scout Rifle", Kills: "120", Bucket: "1498876634"},
+		{WeaponType: "Hand Cannon", Kills: "300", Bucket: "1498876634"},
+		{WeaponType: "Shotgun", Kills: "250", Bucket: "2465295065"},
+		{WeaponType: "Sniper Rifle", Kills: "not a number", Bucket: "2465295065"},
+		{WeaponType: "Rocket Launcher", Kills: "90", Bucket: "953998645"},
+	})
+
+	if len(stats.Primaries) != 2 || stats.Primaries[0].WeaponType != "Hand Cannon" || stats.Primaries[1].Kills != 120 {
+		t.Errorf("Unexpected primary weapon types: %+v", stats.Primaries)
+	}
+	if len(stats.Specials) != 2 || stats.Specials[0].WeaponType != "Shotgun" || stats.Specials[1].Kills != 0 {
+		t.Errorf("Unexpected special weapon types: %+v", stats.Specials)
+	}
+}